@@ -5,9 +5,34 @@ import { useAuth } from '../context/AuthContext';
 import { 
   Plus, Search, Flag, User, FileText, MoreVertical, 
   Loader2, X, Upload, Filter, Pencil, Trash2, Calendar, Lock, Eye, CheckCircle, Users,
-  ChevronDown, AlertTriangle, MoreHorizontal
+  ChevronDown, AlertTriangle, MoreHorizontal, ListChecks, XCircle, Undo2
 } from 'lucide-react';
 
+// Steps an admin can take from each nomination status; FINAL comes from publishing the list.
+const NOMINATION_ACTIONS = {
+  FILED: [{ status: 'UNDER_SCRUTINY', label: 'Start Scrutiny' }],
+  UNDER_SCRUTINY: [{ status: 'ACCEPTED', label: 'Accept' }, { status: 'REJECTED', label: 'Reject' }],
+  ACCEPTED: [{ status: 'WITHDRAWN', label: 'Withdraw' }],
+};
+
+const NOMINATION_STYLES = {
+  FILED: 'bg-slate-100 text-slate-500 border-slate-200',
+  UNDER_SCRUTINY: 'bg-amber-50 text-amber-600 border-amber-200',
+  ACCEPTED: 'bg-sky-50 text-sky-600 border-sky-200',
+  REJECTED: 'bg-rose-50 text-rose-600 border-rose-200',
+  WITHDRAWN: 'bg-slate-100 text-slate-400 border-slate-200',
+  FINAL: 'bg-emerald-50 text-emerald-600 border-emerald-200',
+};
+
+// Candidates outside the national and state parties stand on a free symbol.
+const needsFreeSymbol = (c) => !c.party || !['NATIONAL', 'STATE'].includes(c.party.recognition);
+
+const NominationBadge = ({ status }) => (
+  <span className={`px-2 py-0.5 rounded-full text-[10px] font-bold uppercase border ${NOMINATION_STYLES[status] || NOMINATION_STYLES.FILED}`}>
+    {(status || 'FILED').replace('_', ' ')}
+  </span>
+);
+
 const Candidates = () => {
   const { user } = useAuth();
   const [candidates, setCandidates] = useState([]);
@@ -44,6 +69,12 @@ const Candidates = () => {
 
   const [searchTerm, setSearchTerm] = useState('');
   const [filterParty, setFilterParty] = useState('ALL');
+  const [filterElection, setFilterElection] = useState('ALL');
+
+  // --- Nomination workflow state ---
+  const [nominationModal, setNominationModal] = useState({ show: false, candidate: null, status: '', reason: '' });
+  const [symbolModal, setSymbolModal] = useState({ show: false, candidate: null, symbols: [], symbol: '' });
+  const [finalizeConfirm, setFinalizeConfirm] = useState(false);
 
   const getLogoUrl = (path) => {
       if (!path) return null;
@@ -88,9 +119,81 @@ const Candidates = () => {
     const matchesSearch = !searchTerm || candidate.full_name.toLowerCase().includes(searchTerm.toLowerCase());
     const candidatePartyId = candidate.party ? getId(candidate.party) : null;
     const matchesParty = filterParty === 'ALL' || (candidatePartyId && candidatePartyId.toString() === filterParty.toString());
-    return matchesSearch && matchesParty;
+    const candidateElectionId = c => c.election_id || c.ElectionID;
+    const matchesElection = filterElection === 'ALL' || String(candidateElectionId(candidate)) === String(filterElection);
+    return matchesSearch && matchesParty && matchesElection;
   });
 
+  const selectedElection = elections.find(e => String(getId(e)) === String(filterElection));
+
+  // --- Nomination workflow ---
+  const openNominationModal = (candidate, status) => {
+      setActiveDropdown(null);
+      setNominationModal({ show: true, candidate, status, reason: '' });
+  };
+
+  const closeNominationModal = () => setNominationModal({ show: false, candidate: null, status: '', reason: '' });
+
+  const submitNominationStatus = async (e) => {
+      e.preventDefault();
+      const { candidate, status, reason } = nominationModal;
+      setSubmitting(true);
+      try {
+          await api.put(`/api/admin/candidates/${getId(candidate)}/nomination`, { status, reason });
+          addToast(`Nomination of ${candidate.full_name} moved to ${status.replace('_', ' ').toLowerCase()}`, "success");
+          closeNominationModal(); fetchData();
+      } catch (err) { addToast(err.response?.data?.error || "Failed to update nomination", "error"); }
+      finally { setSubmitting(false); }
+  };
+
+  const openSymbolModal = async (candidate) => {
+      setActiveDropdown(null);
+      try {
+          const res = await api.get(`/api/admin/symbols?election_id=${candidate.election_id || candidate.ElectionID}`);
+          setSymbolModal({ show: true, candidate, symbols: res.data.data || [], symbol: candidate.symbol || '' });
+      } catch (err) { addToast(err.response?.data?.error || "Failed to load symbols", "error"); }
+  };
+
+  const closeSymbolModal = () => setSymbolModal({ show: false, candidate: null, symbols: [], symbol: '' });
+
+  const submitSymbol = async (e) => {
+      e.preventDefault();
+      const { candidate, symbol } = symbolModal;
+      setSubmitting(true);
+      try {
+          await api.put(`/api/admin/candidates/${getId(candidate)}/symbol`, { symbol });
+          addToast(`Symbol "${symbol}" allotted to ${candidate.full_name}`, "success");
+          closeSymbolModal(); fetchData();
+      } catch (err) { addToast(err.response?.data?.error || "Failed to allot symbol", "error"); }
+      finally { setSubmitting(false); }
+  };
+
+  const publishFinalList = async () => {
+      setSubmitting(true);
+      try {
+          const res = await api.post(`/api/admin/elections/${filterElection}/nominations/finalize`);
+          addToast(`Final list published with ${(res.data.data || []).length} candidates`, "success");
+          setFinalizeConfirm(false); fetchData();
+      } catch (err) { addToast(err.response?.data?.error || "Failed to publish the final list", "error"); }
+      finally { setSubmitting(false); }
+  };
+
+  const nominationMenu = (c) => {
+      const status = c.nomination_status || 'FILED';
+      return (
+          <>
+              {(NOMINATION_ACTIONS[status] || []).map(a => (
+                  <button key={a.status} onClick={() => openNominationModal(c, a.status)} className={`w-full text-left px-3 py-2.5 text-sm rounded-lg flex items-center gap-2 ${a.status === 'REJECTED' ? 'text-rose-600 hover:bg-rose-50' : 'hover:bg-slate-50'}`}>
+                      {a.status === 'REJECTED' ? <XCircle size={14}/> : a.status === 'WITHDRAWN' ? <Undo2 size={14}/> : <CheckCircle size={14} className="text-emerald-500"/>} {a.label}
+                  </button>
+              ))}
+              {needsFreeSymbol(c) && status !== 'REJECTED' && status !== 'WITHDRAWN' && !c.serial_no && (
+                  <button onClick={() => openSymbolModal(c)} className="w-full text-left px-3 py-2.5 text-sm rounded-lg hover:bg-slate-50 flex items-center gap-2"><Flag size={14} className="text-amber-500"/> Allot Symbol</button>
+              )}
+          </>
+      );
+  };
+
   const handleImageChange = (e) => {
     const file = e.target.files[0];
     if (file) {
@@ -251,6 +354,19 @@ const Candidates = () => {
                   className="w-full bg-white border border-slate-200 text-slate-900 pl-12 pr-4 py-3 rounded-2xl focus:outline-none focus:border-indigo-500 font-medium shadow-sm"
                 />
             </div>
+            <div className="relative w-full sm:w-64">
+                <Calendar className="absolute left-4 top-1/2 -translate-y-1/2 text-slate-400 pointer-events-none" size={18} />
+                <select value={filterElection} onChange={(e) => setFilterElection(e.target.value)} className="w-full bg-white border border-slate-200 text-slate-600 pl-12 pr-10 py-3 rounded-2xl focus:outline-none focus:border-indigo-500 font-medium appearance-none shadow-sm">
+                    <option value="ALL">All Elections</option>
+                    {elections.map(e => <option key={getId(e)} value={getId(e)}>{e.title}</option>)}
+                </select>
+                <ChevronDown size={16} className="absolute right-4 top-1/2 -translate-y-1/2 text-slate-400 pointer-events-none" />
+            </div>
+            {selectedElection && !selectedElection.ballot_frozen_at && (
+                <button onClick={() => setFinalizeConfirm(true)} className="flex justify-center items-center gap-2 px-5 py-3 bg-emerald-600 hover:bg-emerald-700 text-white rounded-2xl font-bold shadow-sm active:scale-95 transition-all whitespace-nowrap">
+                    <ListChecks size={18} /> Publish Final List
+                </button>
+            )}
             <div className="relative w-full sm:w-64">
                 <Filter className="absolute left-4 top-1/2 -translate-y-1/2 text-slate-400 pointer-events-none" size={18} />
                 <select value={filterParty} onChange={(e) => setFilterParty(e.target.value)} className="w-full bg-white border border-slate-200 text-slate-600 pl-12 pr-10 py-3 rounded-2xl focus:outline-none focus:border-indigo-500 font-medium appearance-none shadow-sm">
@@ -291,7 +407,13 @@ const Candidates = () => {
                          <div className="w-12 h-12 rounded-full bg-slate-100 border border-slate-200 overflow-hidden flex items-center justify-center shrink-0">
                             {(c.photo || c.Photo) ? <img src={getLogoUrl(c.photo || c.Photo)} className="w-full h-full object-cover" /> : <User className="text-slate-400" />}
                          </div>
-                         <div><span className="text-slate-900 font-bold block">{c.full_name}</span><span className="text-xs text-slate-400 font-mono">ID: {cId}</span></div>
+                         <div>
+                           <span className="text-slate-900 font-bold block">{c.full_name}</span>
+                           <div className="flex items-center gap-2 mt-0.5">
+                             <span className="text-xs text-slate-400 font-mono">ID: {cId}</span>
+                             <NominationBadge status={c.nomination_status} />
+                           </div>
+                         </div>
                        </div>
                      </td>
                      <td className="px-6 py-5">
@@ -299,6 +421,7 @@ const Candidates = () => {
                            {c.party?.logo ? <img src={getLogoUrl(c.party.logo)} className="w-6 h-6 object-contain bg-white rounded-md border p-0.5" /> : <Flag size={14} className="text-slate-400" />}
                            <span className="text-slate-700 font-semibold">{c.party?.name || 'Independent'}</span>
                        </div>
+                       {c.symbol && <span className="text-xs text-slate-400 block mt-1">Symbol: {c.symbol}</span>}
                      </td>
                      <td className="px-6 py-5">
                         {elec ? (
@@ -316,6 +439,7 @@ const Candidates = () => {
                            <div ref={dropdownRef} className="absolute right-12 top-10 w-48 bg-white border border-slate-100 rounded-xl shadow-xl z-50 p-1.5 animate-in fade-in zoom-in-95">
                                <button onClick={() => openViewModal(c)} className="w-full text-left px-3 py-2.5 text-sm rounded-lg hover:bg-slate-50 flex items-center gap-2"><Eye size={14} className="text-sky-500"/> View</button>
                                <button onClick={() => handleEditCandidate(c)} disabled={isLocked} className={`w-full text-left px-3 py-2.5 text-sm rounded-lg flex items-center gap-2 ${isLocked ? 'text-slate-300' : 'hover:bg-slate-50'}`}><Pencil size={14}/> Edit</button>
+                               {!isLocked && nominationMenu(c)}
                                <div className="h-px bg-slate-100 my-1"/>
                                <button onClick={() => initiateDelete('CANDIDATE', c)} disabled={isLocked} className={`w-full text-left px-3 py-2.5 text-sm rounded-lg flex items-center gap-2 ${isLocked ? 'text-slate-300' : 'text-rose-600 hover:bg-rose-50'}`}><Trash2 size={14}/> Delete</button>
                            </div>
@@ -348,6 +472,7 @@ const Candidates = () => {
                                  {c.party?.logo ? <img src={getLogoUrl(c.party.logo)} className="w-4 h-4 object-contain"/> : <Flag size={10}/>}
                                  <span className="truncate">{c.party?.name || 'Independent'}</span>
                               </div>
+                              <div className="mt-1"><NominationBadge status={c.nomination_status} /></div>
                            </div>
                         </div>
                         {/* Mobile Actions */}
@@ -366,6 +491,11 @@ const Candidates = () => {
                            {isLocked && <Lock size={12} className="text-slate-400"/>}
                         </div>
                     )}
+                    {!isLocked && (NOMINATION_ACTIONS[c.nomination_status] || needsFreeSymbol(c)) && (
+                        <div onClick={(e) => e.stopPropagation()} className="mt-3 pt-3 border-t border-slate-100 grid grid-cols-2 gap-1">
+                           {nominationMenu(c)}
+                        </div>
+                    )}
                  </div>
               )})}
         </div>
@@ -409,6 +539,7 @@ const Candidates = () => {
                         <label className="text-xs font-bold text-slate-400 uppercase">Party *</label>
                         <select required value={candidateForm.party_id} onChange={e => setCandidateForm({...candidateForm, party_id: e.target.value})} className="w-full bg-slate-50 border border-slate-200 rounded-xl p-3.5 outline-none">
                            <option value="">Select Party...</option>
+                           <option value="0">Independent</option>
                            {parties.map(p => <option key={getId(p)} value={getId(p)}>{p.name}</option>)}
                         </select>
                      </div>
@@ -494,6 +625,75 @@ const Candidates = () => {
         </div>
       )}
 
+      {/* --- NOMINATION STATUS MODAL --- */}
+      {nominationModal.show && (
+        <div className="fixed inset-0 z-[110] flex items-center justify-center p-4">
+          <div className="absolute inset-0 bg-slate-900/40 backdrop-blur-sm" onClick={closeNominationModal} />
+          <form onSubmit={submitNominationStatus} className="relative bg-white rounded-[2rem] w-full max-w-sm shadow-2xl p-6 sm:p-8 animate-in zoom-in-95 duration-200 space-y-5">
+            <div>
+              <h3 className="text-xl font-bold text-slate-900">{(NOMINATION_ACTIONS[nominationModal.candidate.nomination_status] || []).find(a => a.status === nominationModal.status)?.label} Nomination</h3>
+              <p className="text-slate-500 text-sm mt-1">{nominationModal.candidate.full_name}</p>
+            </div>
+            <div className="space-y-1.5">
+              <label className="text-xs font-bold text-slate-400 uppercase">Reason {nominationModal.status === 'REJECTED' ? '*' : ''}</label>
+              <textarea rows="3" required={nominationModal.status === 'REJECTED'} value={nominationModal.reason} onChange={e => setNominationModal({ ...nominationModal, reason: e.target.value })} className="w-full bg-slate-50 border border-slate-200 rounded-xl p-3.5 outline-none resize-none" />
+            </div>
+            <div className="flex gap-3">
+              <button type="button" onClick={closeNominationModal} className="flex-1 py-3 border border-slate-200 rounded-xl font-bold text-slate-600">Cancel</button>
+              <button type="submit" disabled={submitting} className={`flex-1 py-3 text-white rounded-xl font-bold shadow-lg ${nominationModal.status === 'REJECTED' ? 'bg-rose-600' : 'bg-indigo-600'}`}>
+                {submitting ? <Loader2 className="animate-spin mx-auto" /> : 'Confirm'}
+              </button>
+            </div>
+          </form>
+        </div>
+      )}
+
+      {/* --- SYMBOL ALLOTMENT MODAL --- */}
+      {symbolModal.show && (
+        <div className="fixed inset-0 z-[110] flex items-center justify-center p-4">
+          <div className="absolute inset-0 bg-slate-900/40 backdrop-blur-sm" onClick={closeSymbolModal} />
+          <form onSubmit={submitSymbol} className="relative bg-white rounded-[2rem] w-full max-w-sm shadow-2xl p-6 sm:p-8 animate-in zoom-in-95 duration-200 space-y-5">
+            <div>
+              <h3 className="text-xl font-bold text-slate-900">Allot Symbol</h3>
+              <p className="text-slate-500 text-sm mt-1">{symbolModal.candidate.full_name}</p>
+            </div>
+            <select required value={symbolModal.symbol} onChange={e => setSymbolModal({ ...symbolModal, symbol: e.target.value })} className="w-full bg-slate-50 border border-slate-200 rounded-xl p-3.5 outline-none">
+              <option value="">Select Symbol...</option>
+              {symbolModal.symbols.map(s => {
+                  const takenByOther = s.taken_by && s.taken_by !== getId(symbolModal.candidate);
+                  return <option key={s.id || s.ID} value={s.name} disabled={takenByOther}>{s.name}{takenByOther ? ` (taken by ${s.taken_by_name})` : ''}</option>;
+              })}
+            </select>
+            <div className="flex gap-3">
+              <button type="button" onClick={closeSymbolModal} className="flex-1 py-3 border border-slate-200 rounded-xl font-bold text-slate-600">Cancel</button>
+              <button type="submit" disabled={submitting} className="flex-1 py-3 bg-indigo-600 text-white rounded-xl font-bold shadow-lg">
+                {submitting ? <Loader2 className="animate-spin mx-auto" /> : 'Allot'}
+              </button>
+            </div>
+          </form>
+        </div>
+      )}
+
+      {/* --- PUBLISH FINAL LIST CONFIRMATION --- */}
+      {finalizeConfirm && selectedElection && (
+        <div className="fixed inset-0 z-[110] flex items-center justify-center p-4">
+          <div className="absolute inset-0 bg-slate-900/40 backdrop-blur-sm" onClick={() => setFinalizeConfirm(false)} />
+          <div className="relative bg-white rounded-[2rem] w-full max-w-sm shadow-2xl p-6 sm:p-8 text-center animate-in zoom-in-95 duration-200">
+            <div className="w-16 h-16 rounded-full mx-auto mb-6 flex items-center justify-center bg-emerald-50 text-emerald-500">
+              <ListChecks size={32} />
+            </div>
+            <h3 className="text-xl font-bold text-slate-900 mb-2">Publish Final List?</h3>
+            <p className="text-slate-500 mb-8 text-sm">Every accepted nomination of <strong>{selectedElection.title}</strong> goes on the ballot and the ballot order is fixed. This cannot be undone.</p>
+            <div className="flex gap-3">
+              <button onClick={() => setFinalizeConfirm(false)} className="flex-1 py-3 border border-slate-200 rounded-xl font-bold text-slate-600">Cancel</button>
+              <button onClick={publishFinalList} disabled={submitting} className="flex-1 py-3 bg-emerald-600 text-white rounded-xl font-bold shadow-lg">
+                {submitting ? <Loader2 className="animate-spin mx-auto" /> : 'Publish'}
+              </button>
+            </div>
+          </div>
+        </div>
+      )}
+
       {/* --- CONFIRMATION MODAL --- */}
       {confirmModal.show && (
         <div className="fixed inset-0 z-[110] flex items-center justify-center p-4">
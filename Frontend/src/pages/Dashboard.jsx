@@ -65,7 +65,8 @@ const Dashboard = () => {
     if (wsRef.current && (wsRef.current.readyState === WebSocket.OPEN || wsRef.current.readyState === WebSocket.CONNECTING)) {
         return; 
     }
-    const wsUrl = 'ws://localhost:8080/ws/notifications';
+    const token = localStorage.getItem('admin_token');
+    const wsUrl = `ws://localhost:8080/ws/notifications?token=${encodeURIComponent(token || '')}`;
     const ws = new WebSocket(wsUrl);
     wsRef.current = ws;
 
@@ -7,6 +7,7 @@ const VotingBooth = () => {
   const { id } = useParams();
   const navigate = useNavigate();
   const [candidates, setCandidates] = useState([]);
+  const [reservation, setReservation] = useState(null);
   const [selectedCandidate, setSelectedCandidate] = useState(null);
   const [loading, setLoading] = useState(true);
   const [submitting, setSubmitting] = useState(false);
@@ -44,7 +45,8 @@ const VotingBooth = () => {
       try {
         const res = await api.get(`/api/voter/elections/${id}/candidates`);
         if (res.data.success) {
-            setCandidates(res.data.data || []);
+            setCandidates(res.data.data?.candidates || []);
+            setReservation(res.data.data?.reservation !== 'GENERAL' ? res.data.data?.reservation_label : null);
         }
       } catch (err) {
         // If API returns error, assume access denied / already voted
@@ -64,7 +66,11 @@ const VotingBooth = () => {
     setSubmitting(true);
     
     try {
-        const res = await api.post('/api/voter/vote', {
+        // NOTA is sent as a flag; its synthetic entry has no candidate ID
+        const res = await api.post('/api/voter/vote', selectedCandidate.is_nota ? {
+            election_id: parseInt(id),
+            nota: true
+        } : {
             election_id: parseInt(id),
             candidate_id: selectedCandidate.ID
         });
@@ -129,6 +135,11 @@ const VotingBooth = () => {
         <div>
             <h1 className="text-2xl font-bold text-slate-900">Ballot Paper</h1>
             <p className="text-slate-500 text-sm">Select one candidate to cast your vote.</p>
+            {reservation && (
+                <span className="inline-block mt-2 bg-amber-50 text-amber-700 px-3 py-1 rounded-lg text-xs font-bold border border-amber-100">
+                    Seat reserved for {reservation}
+                </span>
+            )}
         </div>
         <div className="bg-emerald-50 text-emerald-600 px-4 py-2 rounded-xl text-xs font-bold flex items-center gap-2 border border-emerald-100">
             <Lock size={14} /> Secure Connection
@@ -138,7 +149,7 @@ const VotingBooth = () => {
       <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 gap-4">
         {candidates.map(candidate => (
             <div 
-                key={candidate.ID}
+                key={candidate.is_nota ? 'nota' : candidate.ID}
                 onClick={() => !submitting && setSelectedCandidate(candidate)}
                 className={`cursor-pointer p-1 rounded-2xl transition-all duration-300 ${
                     selectedCandidate?.ID === candidate.ID 
@@ -157,7 +168,11 @@ const VotingBooth = () => {
                     <div className={`w-16 h-16 rounded-full border-2 overflow-hidden shrink-0 ${
                         selectedCandidate?.ID === candidate.ID ? 'bg-slate-800 border-slate-700' : 'bg-slate-50 border-slate-100'
                     }`}>
-                       {candidate.photo ? (
+                       {candidate.is_nota ? (
+                           <div className={`w-full h-full flex items-center justify-center ${
+                               selectedCandidate?.ID === candidate.ID ? 'text-slate-400' : 'text-slate-300'
+                           }`}><Ban size={28} /></div>
+                       ) : candidate.photo ? (
                            <img src={`http://localhost:8080${candidate.photo}`} className="w-full h-full object-cover" alt={candidate.full_name} />
                        ) : (
                            <div className={`w-full h-full flex items-center justify-center font-bold text-xl ${
@@ -172,12 +187,12 @@ const VotingBooth = () => {
                         }`}>{candidate.full_name}</h3>
                         
                         <div className="flex items-center gap-2 mt-1">
-                            {candidate.party?.logo ? (
+                            {!candidate.is_nota && candidate.party?.logo ? (
                                 <img src={`http://localhost:8080${candidate.party.logo}`} className="w-5 h-5 object-contain" alt="Party Logo" />
                             ) : null}
                             <span className={`text-sm font-medium ${
                                 selectedCandidate?.ID === candidate.ID ? 'text-slate-400' : 'text-slate-500'
-                            }`}>{candidate.party?.name || 'Independent'}</span>
+                            }`}>{candidate.is_nota ? 'None of the Above' : (candidate.party?.name || 'Independent')}</span>
                         </div>
                     </div>
                 </div>
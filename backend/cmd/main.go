@@ -6,6 +6,7 @@ import (
 	"E-voting/internal/database"
 	"E-voting/internal/service"
 	"E-voting/internal/utils"
+	"E-voting/internal/worker"
 	"log"
 	"os"
 
@@ -20,17 +21,39 @@ func main() {
 	utils.InitFirebase()
 
 	database.ConnectPostgres()
+
+	// `main rebuild-tallies` recomputes the live tallies from the votes table and exits.
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-tallies" {
+		count, err := service.RebuildTallies()
+		if err != nil {
+			log.Fatal("Failed to rebuild tallies:", err)
+		}
+		log.Printf(" Tallies rebuilt for %d elections", count)
+		return
+	}
+
 	database.ConnectMongo()
 	database.SeedSuperAdmin()
 	database.SeedKeralaAdminData()
 
+	service.EnsureTallies()
+	service.RefreshFeedRevisions()
+	service.BackfillCandidateNameKeys()
 	service.InitBlockchain()
 
 	api.InitializeDefaults()
+	api.InitRealtime()
+
+	service.RegisterEventHandlers()
+	api.RegisterEventHandlers()
+	worker.StartWebhookDispatcher()
 
 	if err := os.MkdirAll("./uploads/avatars", 0755); err != nil {
 		log.Fatal("Failed to create upload directory:", err)
 	}
+	if err := os.MkdirAll(service.CandidateDocumentDir, 0700); err != nil {
+		log.Fatal("Failed to create document directory:", err)
+	}
 
 	app := fiber.New()
 	app.Use(cors.New(cors.Config{
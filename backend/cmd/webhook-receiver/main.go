@@ -0,0 +1,68 @@
+// Command webhook-receiver is a local endpoint for trying out webhook
+// subscriptions. It verifies each request's signature and prints the event.
+//
+//	go run ./cmd/webhook-receiver -addr :9000 -secret <secret>
+//
+// Register http://localhost:9000/ as the webhook URL. Use -fail N to answer the
+// first N requests with a 500 and watch the retries arrive.
+package main
+
+import (
+	"E-voting/internal/utils"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	secret := flag.String("secret", "", "subscription signing secret")
+	fail := flag.Int64("fail", 0, "respond 500 to the first N requests")
+	maxSkew := flag.Duration("max-skew", 5*time.Minute, "reject timestamps older than this")
+	flag.Parse()
+
+	var seen int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+
+		ts := r.Header.Get("X-EVoting-Timestamp")
+		sig := r.Header.Get("X-EVoting-Signature")
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil || time.Since(time.Unix(sec, 0)) > *maxSkew {
+			log.Printf("rejected %s: stale or missing timestamp", r.Header.Get("X-EVoting-Delivery"))
+			http.Error(w, "stale timestamp", http.StatusUnauthorized)
+			return
+		}
+		if *secret != "" && !utils.VerifyWebhookSignature(*secret, ts, body, sig) {
+			log.Printf("rejected %s: bad signature", r.Header.Get("X-EVoting-Delivery"))
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+
+		n := atomic.AddInt64(&seen, 1)
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") != nil {
+			pretty.Write(body)
+		}
+		log.Printf("#%d %s delivery=%s\n%s", n, r.Header.Get("X-EVoting-Event"), r.Header.Get("X-EVoting-Delivery"), pretty.String())
+
+		if n <= *fail {
+			http.Error(w, "simulated failure", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("webhook receiver listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
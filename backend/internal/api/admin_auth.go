@@ -1,8 +1,10 @@
 package api
 
 import (
+	"E-voting/internal/events"
 	"E-voting/internal/service"
 	"E-voting/internal/utils"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -45,6 +47,13 @@ func VerifyAdminOTP(c *fiber.Ctx) error {
 		return utils.Error(c, 401, err.Error())
 	}
 
+	events.Publish(events.AdminLoggedIn{
+		AdminID: admin.ID,
+		Email:   admin.Email,
+		IsSuper: admin.IsSuper,
+		At:      time.Now(),
+	})
+
 	roleLabel := "STAFF"
 	if admin.IsSuper {
 		roleLabel = "SUPER_ADMIN"
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ListAlliances returns every front with its full membership history.
+func ListAlliances(c *fiber.Ctx) error {
+	var alliances []models.Alliance
+	err := database.PostgresDB.
+		Preload("Memberships", func(db *gorm.DB) *gorm.DB { return db.Order("valid_from asc") }).
+		Preload("Memberships.Party").
+		Order("name asc").
+		Find(&alliances).Error
+	if err != nil {
+		return utils.Error(c, 500, "Failed to fetch alliances")
+	}
+	return utils.Success(c, alliances)
+}
+
+func CreateAlliance(c *fiber.Ctx) error {
+	var req struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return utils.Error(c, 400, "Alliance name is required")
+	}
+
+	alliance := models.Alliance{Name: strings.TrimSpace(req.Name), FullName: strings.TrimSpace(req.FullName)}
+	if err := database.PostgresDB.Create(&alliance).Error; err != nil {
+		return utils.Error(c, 500, "Failed to create alliance. Name might be duplicate.")
+	}
+
+	logAdminAction(c, "CREATE_ALLIANCE", alliance.ID, map[string]interface{}{"name": alliance.Name})
+	return utils.Success(c, alliance)
+}
+
+func UpdateAlliance(c *fiber.Ctx) error {
+	var alliance models.Alliance
+	if err := database.PostgresDB.First(&alliance, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Alliance not found")
+	}
+
+	var req struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+	details := map[string]interface{}{"name": alliance.Name}
+	if val := strings.TrimSpace(req.Name); val != "" && val != alliance.Name {
+		if err := service.CheckAllianceRename(alliance.ID); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+		details["from"] = alliance.Name
+		details["name"] = val
+		alliance.Name = val
+	}
+	if val := strings.TrimSpace(req.FullName); val != "" {
+		alliance.FullName = val
+	}
+
+	if err := database.PostgresDB.Save(&alliance).Error; err != nil {
+		return utils.Error(c, 500, "Failed to update alliance")
+	}
+
+	logAdminAction(c, "UPDATE_ALLIANCE", alliance.ID, details)
+	return utils.Success(c, alliance)
+}
+
+func AddAllianceMember(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid alliance ID")
+	}
+
+	var req struct {
+		PartyID   uint       `json:"party_id"`
+		ValidFrom time.Time  `json:"valid_from"`
+		ValidTo   *time.Time `json:"valid_to"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	m, err := service.AddAllianceMember(uint(id), req.PartyID, req.ValidFrom, req.ValidTo)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "ADD_ALLIANCE_MEMBER", m.AllianceID, map[string]interface{}{
+		"party_id":   m.PartyID,
+		"party":      m.Party.Name,
+		"valid_from": m.ValidFrom,
+		"valid_to":   m.ValidTo,
+	})
+	return utils.Success(c, m)
+}
+
+// EndAllianceMembership records a party leaving its front.
+func EndAllianceMembership(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid membership ID")
+	}
+
+	var req struct {
+		ValidTo time.Time `json:"valid_to"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.ValidTo.IsZero() {
+		return utils.Error(c, 400, "valid_to is required")
+	}
+
+	m, err := service.EndAllianceMembership(uint(id), req.ValidTo)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "END_ALLIANCE_MEMBER", m.AllianceID, map[string]interface{}{
+		"membership_id": m.ID,
+		"party":         m.Party.Name,
+		"valid_to":      m.ValidTo,
+	})
+	return utils.Success(c, m)
+}
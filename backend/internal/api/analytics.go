@@ -0,0 +1,22 @@
+package api
+
+import (
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTurnoutAnalytics reports electorate, polling and hourly turnout for one election.
+func GetTurnoutAnalytics(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	report, err := service.BuildTurnoutReport(uint(id))
+	if err != nil {
+		return utils.Error(c, 404, err.Error())
+	}
+	return utils.Success(c, report)
+}
@@ -6,10 +6,13 @@ import (
 	"E-voting/internal/service"
 	"E-voting/internal/utils"
 	"fmt"
+	"log"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // --- PARTY MANAGEMENT ---
@@ -20,8 +23,18 @@ func CreateParty(c *fiber.Ctx) error {
 	if name == "" {
 		return utils.Error(c, 400, "Party name is required")
 	}
+	if service.IsIndependentLabel(name) {
+		return utils.Error(c, 400, "Independents stand without a party; do not create one for them")
+	}
 
-	party := models.Party{Name: name}
+	recognition, err := service.NormalizeRecognition(c.FormValue("recognition"))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	party := models.Party{Name: name, Recognition: recognition, Symbol: strings.TrimSpace(c.FormValue("symbol"))}
+	if err := checkPartySymbol(party); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
 
 	// Handle Logo Upload
 	file, err := c.FormFile("logo")
@@ -65,8 +78,24 @@ func UpdateParty(c *fiber.Ctx) error {
 	// Update Fields
 	name := c.FormValue("name")
 	if name != "" {
+		if service.IsIndependentLabel(name) {
+			return utils.Error(c, 400, "Independents stand without a party; do not create one for them")
+		}
 		party.Name = name
 	}
+	if val := c.FormValue("recognition"); val != "" {
+		recognition, err := service.NormalizeRecognition(val)
+		if err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+		party.Recognition = recognition
+	}
+	if val := c.FormValue("symbol"); val != "" {
+		party.Symbol = strings.TrimSpace(val)
+	}
+	if err := checkPartySymbol(party); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
 
 	file, err := c.FormFile("logo")
 	if err == nil {
@@ -109,6 +138,25 @@ func DeleteParty(c *fiber.Ctx) error {
 	return utils.Success(c, "Party deleted successfully")
 }
 
+// checkPartySymbol keeps reserved symbols unique and off the free list.
+func checkPartySymbol(p models.Party) error {
+	if p.Recognition != service.PartyNational && p.Recognition != service.PartyState {
+		return nil
+	}
+	if p.Symbol == "" {
+		return fmt.Errorf("a %s party needs a reserved symbol", strings.ToLower(p.Recognition))
+	}
+	if service.IsReservedSymbol(p.Symbol, p.ID) {
+		return fmt.Errorf("symbol %q is already reserved for another party", p.Symbol)
+	}
+	var count int64
+	database.PostgresDB.Model(&models.FreeSymbol{}).Where("LOWER(name) = LOWER(?)", p.Symbol).Count(&count)
+	if count > 0 {
+		return fmt.Errorf("symbol %q is on the free symbol list", p.Symbol)
+	}
+	return nil
+}
+
 func ListParties(c *fiber.Ctx) error {
 	var parties []models.Party
 	database.PostgresDB.Order("name asc").Find(&parties)
@@ -119,9 +167,17 @@ func ListParties(c *fiber.Ctx) error {
 
 type CreateCandidateRequest struct {
 	FullName   string `form:"full_name"`
-	PartyID    uint   `form:"party_id"`
+	PartyID    uint   `form:"party_id"` // 0 for an independent
+	Symbol     string `form:"symbol"`   // free symbol chosen by an independent
 	ElectionID uint   `form:"election_id"`
 	Bio        string `form:"bio"`
+	VoterID    uint   `form:"voter_id"` // the candidate's own voter registration, if known
+	Gender     string `form:"gender"`
+	Category   string `form:"category"`
+
+	// Phones allowed to sign in to the candidate portal
+	Mobile      string `form:"mobile"`
+	AgentMobile string `form:"agent_mobile"`
 }
 
 func CreateCandidate(c *fiber.Ctx) error {
@@ -132,8 +188,50 @@ func CreateCandidate(c *fiber.Ctx) error {
 	}
 
 	// 2. Validate
-	if req.FullName == "" || req.ElectionID == 0 || req.PartyID == 0 {
-		return utils.Error(c, 400, "Full Name, Election, and Party are required")
+	if req.FullName == "" || req.ElectionID == 0 {
+		return utils.Error(c, 400, "Full Name and Election are required")
+	}
+
+	var partyID *uint
+	if req.PartyID != 0 {
+		var party models.Party
+		if err := database.PostgresDB.First(&party, req.PartyID).Error; err != nil {
+			return utils.Error(c, 404, "Party not found")
+		}
+		partyID = &party.ID
+	}
+
+	var election models.Election
+	if err := database.PostgresDB.First(&election, req.ElectionID).Error; err != nil {
+		return utils.Error(c, 404, "Election not found")
+	}
+	var voterID *uint
+	if req.VoterID != 0 {
+		voterID = &req.VoterID
+	}
+	if err := service.CheckNominationsOpen(election, time.Now()); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if err := service.CheckCandidateVoter(voterID); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if err := service.ValidateIndirectCandidate(election, voterID); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	gender, category, err := service.NormalizeCandidateProfile(req.Gender, req.Category)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if err := service.CheckCandidateReservation(election.Reservation, gender, category); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	symbol := ""
+	if req.Symbol != "" {
+		probe := models.Candidate{ElectionID: election.ID, PartyID: partyID, NominationStatus: service.NominationFiled}
+		if symbol, err = service.CheckSymbolChoice(probe, req.Symbol); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
 	}
 
 	// 3. File Upload Handling (Only Candidate Photo)
@@ -149,25 +247,116 @@ func CreateCandidate(c *fiber.Ctx) error {
 	// 3. Create Model
 	candidate := models.Candidate{
 		FullName:   req.FullName,
-		PartyID:    req.PartyID,
+		PartyID:    partyID,
 		ElectionID: req.ElectionID,
 		Bio:        req.Bio,
 		Photo:      candidatePhotoPath,
+		VoterID:    voterID,
+		Gender:     gender,
+		Category:   category,
+
+		Symbol: symbol,
+
+		Mobile:      req.Mobile,
+		AgentMobile: req.AgentMobile,
+
+		NominationStatus: service.NominationFiled,
+		MatchScanPending: true,
+		NameKey:          service.NormalizeCandidateName(req.FullName),
 	}
 
 	if err := database.PostgresDB.Create(&candidate).Error; err != nil {
 		return utils.Error(c, 500, "Failed to create candidate")
 	}
 
+	// Possible duplicates go to the review queue; they do not stop the filing.
+	// If the check fails the nomination stays pending until a rescan succeeds.
+	details := map[string]interface{}{
+		"election_id": candidate.ElectionID,
+		"name":        candidate.FullName,
+		"status":      candidate.NominationStatus,
+	}
+	if matches, err := service.DetectNominationMatches(candidate.ID); err != nil {
+		log.Printf("Duplicate check failed for nomination %d: %v", candidate.ID, err)
+		details["duplicate_check_error"] = err.Error()
+	} else {
+		candidate.MatchScanPending = false
+		details["duplicate_matches"] = len(matches)
+	}
+
+	logAdminAction(c, "FILE_NOMINATION", candidate.ID, details)
+
 	return utils.Success(c, candidate)
 }
 
 func ListCandidates(c *fiber.Ctx) error {
+	query := database.PostgresDB.Preload("Party")
+	if electionID := c.QueryInt("election_id"); electionID > 0 {
+		query = query.Where("election_id = ?", electionID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("nomination_status = ?", status)
+	}
+
 	var candidates []models.Candidate
-	database.PostgresDB.Preload("Party").Find(&candidates)
+	query.Find(&candidates)
 	return utils.Success(c, candidates)
 }
 
+type NominationStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// UpdateNominationStatus moves a nomination through scrutiny, acceptance,
+// rejection or withdrawal.
+func UpdateNominationStatus(c *fiber.Ctx) error {
+	var candidate models.Candidate
+	if err := database.PostgresDB.First(&candidate, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Candidate not found")
+	}
+
+	var req NominationStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	from, err := service.ChangeNominationStatus(&candidate, req.Status, req.Reason, time.Now())
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "NOMINATION_"+candidate.NominationStatus, candidate.ID, map[string]interface{}{
+		"election_id": candidate.ElectionID,
+		"from":        from,
+		"to":          candidate.NominationStatus,
+		"reason":      candidate.NominationReason,
+	})
+	return utils.Success(c, candidate)
+}
+
+// FinalizeNominationList publishes the final list of candidates of an election.
+func FinalizeNominationList(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	final, err := service.FinalizeNominations(uint(id), time.Now())
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	for _, cand := range final {
+		logAdminAction(c, "NOMINATION_FINAL", cand.ID, map[string]interface{}{
+			"election_id": cand.ElectionID,
+			"from":        service.NominationAccepted,
+			"to":          service.NominationFinal,
+		})
+	}
+	return utils.Success(c, final)
+}
+
 func UpdateCandidate(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var candidate models.Candidate
@@ -182,6 +371,10 @@ func UpdateCandidate(c *fiber.Ctx) error {
 			return utils.Error(c, 403, "Cannot modify candidate: Election is currently ACTIVE.")
 		}
 	}
+	if err := service.CheckBallotEditable(candidate.ElectionID); err != nil {
+		return utils.Error(c, 403, "Cannot modify candidate: "+err.Error())
+	}
+	fromElection := candidate.ElectionID
 
 	// Update fields
 	if val := c.FormValue("full_name"); val != "" {
@@ -197,9 +390,63 @@ func UpdateCandidate(c *fiber.Ctx) error {
 	}
 	if val := c.FormValue("party_id"); val != "" {
 		if id, err := utils.StringToUint(val); err == nil {
-			candidate.PartyID = id
+			if id == 0 {
+				candidate.PartyID = nil
+			} else if err := database.PostgresDB.First(&models.Party{}, id).Error; err != nil {
+				return utils.Error(c, 404, "Party not found")
+			} else {
+				candidate.PartyID = &id
+			}
 		}
 	}
+	if val := c.FormValue("voter_id"); val != "" {
+		if id, err := utils.StringToUint(val); err == nil {
+			candidate.VoterID = &id
+		}
+	}
+	if val := c.FormValue("mobile"); val != "" {
+		candidate.Mobile = val
+	}
+	if val := c.FormValue("agent_mobile"); val != "" {
+		candidate.AgentMobile = val
+	}
+
+	var target models.Election
+	if err := database.PostgresDB.First(&target, candidate.ElectionID).Error; err != nil {
+		return utils.Error(c, 404, "Election not found")
+	}
+	// Moving the nomination files it in the target election, which has to be
+	// open to it just as for a new filing.
+	if target.ID != fromElection {
+		if err := service.CheckNominationsOpen(target, time.Now()); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+		if err := service.CheckBallotEditable(target.ID); err != nil {
+			return utils.Error(c, 403, "Cannot move candidate: "+err.Error())
+		}
+	}
+	if err := service.CheckCandidateVoter(candidate.VoterID); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if err := service.ValidateIndirectCandidate(target, candidate.VoterID); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	gender, category := candidate.Gender, candidate.Category
+	if val := c.FormValue("gender"); val != "" {
+		gender = val
+	}
+	if val := c.FormValue("category"); val != "" {
+		category = val
+	}
+	gender, category, err := service.NormalizeCandidateProfile(gender, category)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	candidate.Gender, candidate.Category = gender, category
+	if err := service.CheckCandidateReservation(target.Reservation, candidate.Gender, candidate.Category); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
 
 	file, err := c.FormFile("photo")
 	if err == nil {
@@ -209,15 +456,44 @@ func UpdateCandidate(c *fiber.Ctx) error {
 			candidate.Photo = "/uploads/" + filename
 		}
 	}
+	if file, err := c.FormFile("affidavit"); err == nil {
+		if _, err := service.CheckCandidateDocument(service.DocumentAffidavit, file.Filename, file.Size); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+		// Affidavits are kept with the private candidate documents, never in
+		// the public uploads directory.
+		filename := fmt.Sprintf("affidavit_%d_%d%s", candidate.ID, time.Now().UnixNano(), filepath.Ext(file.Filename))
+		if err := c.SaveFile(file, service.CandidateDocumentPath(filename)); err != nil {
+			return utils.Error(c, 500, "Failed to save affidavit")
+		}
+		candidate.Affidavit = filename
+	}
+	if file, err := c.FormFile("affidavit_redacted"); err == nil {
+		if _, err := service.CheckCandidateDocument(service.DocumentAffidavitRedacted, file.Filename, file.Size); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+		filename := fmt.Sprintf("affidavit_redacted_%d_%d%s", candidate.ID, time.Now().UnixNano(), filepath.Ext(file.Filename))
+		if err := c.SaveFile(file, service.CandidateDocumentPath(filename)); err != nil {
+			return utils.Error(c, 500, "Failed to save redacted affidavit")
+		}
+		candidate.AffidavitRedacted = filename
+	}
 
+	candidate.MatchScanPending = true
+	candidate.NameKey = service.NormalizeCandidateName(candidate.FullName)
 	if err := database.PostgresDB.Save(&candidate).Error; err != nil {
 		return utils.Error(c, 500, "Failed to update candidate")
 	}
+	var details map[string]interface{}
+	if _, err := service.DetectNominationMatches(candidate.ID); err != nil {
+		log.Printf("Duplicate check failed for nomination %d: %v", candidate.ID, err)
+		details = map[string]interface{}{"duplicate_check_error": err.Error()}
+	}
 
 	// Audit
 	actorID := uint(c.Locals("user_id").(float64))
 	actorRole := c.Locals("role").(string)
-	service.LogAdminAction(actorID, actorRole, "UPDATE_CANDIDATE", candidate.ID, nil)
+	service.LogAdminAction(actorID, actorRole, "UPDATE_CANDIDATE", candidate.ID, details)
 
 	return utils.Success(c, "Candidate updated successfully")
 }
@@ -242,8 +518,18 @@ func DeleteCandidate(c *fiber.Ctx) error {
 			return utils.Error(c, 403, "Cannot delete candidate: Election is currently ACTIVE.")
 		}
 	}
+	if err := service.CheckBallotEditable(candidate.ElectionID); err != nil {
+		return utils.Error(c, 403, "Cannot delete candidate: "+err.Error())
+	}
 
-	if err := database.PostgresDB.Delete(&candidate).Error; err != nil {
+	// The disclosure goes with the candidate; its criminal cases cascade.
+	err := database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("candidate_id = ?", candidate.ID).Delete(&models.CandidateDisclosure{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&candidate).Error
+	})
+	if err != nil {
 		return utils.Error(c, 500, "Failed to delete candidate")
 	}
 
@@ -256,3 +542,88 @@ func DeleteCandidate(c *fiber.Ctx) error {
 
 	return utils.Success(c, "Candidate deleted successfully")
 }
+
+// --- SYMBOLS ---
+
+// ListSymbols returns the free symbol list; with ?election_id= it also shows
+// which symbols are already taken in that election.
+func ListSymbols(c *fiber.Ctx) error {
+	symbols, err := service.ListFreeSymbols(uint(c.QueryInt("election_id")))
+	if err != nil {
+		return utils.Error(c, 500, "Failed to fetch symbols")
+	}
+	return utils.Success(c, symbols)
+}
+
+func CreateSymbol(c *fiber.Ctx) error {
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		return utils.Error(c, 400, "Symbol name is required")
+	}
+	if service.IsReservedSymbol(name, 0) {
+		return utils.Error(c, 400, "This symbol is reserved for a recognised party")
+	}
+
+	symbol := models.FreeSymbol{Name: name}
+	if file, err := c.FormFile("image"); err == nil {
+		filename := fmt.Sprintf("symbol_%d%s", time.Now().UnixNano(), filepath.Ext(file.Filename))
+		savePath := filepath.Join("./uploads", filename)
+		if err := c.SaveFile(file, savePath); err == nil {
+			symbol.Image = "/uploads/" + filename
+		}
+	}
+
+	if err := database.PostgresDB.Create(&symbol).Error; err != nil {
+		return utils.Error(c, 500, "Failed to create symbol. Name might be duplicate.")
+	}
+
+	logAdminAction(c, "CREATE_SYMBOL", symbol.ID, map[string]interface{}{"name": symbol.Name})
+	return utils.Success(c, symbol)
+}
+
+func DeleteSymbol(c *fiber.Ctx) error {
+	var symbol models.FreeSymbol
+	if err := database.PostgresDB.First(&symbol, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Symbol not found")
+	}
+
+	var count int64
+	database.PostgresDB.Model(&models.Candidate{}).Where("LOWER(symbol) = LOWER(?)", symbol.Name).Count(&count)
+	if count > 0 {
+		return utils.Error(c, 403, "Cannot delete symbol: It has been allotted to candidates.")
+	}
+
+	if err := database.PostgresDB.Delete(&symbol).Error; err != nil {
+		return utils.Error(c, 500, "Failed to delete symbol")
+	}
+
+	logAdminAction(c, "DELETE_SYMBOL", symbol.ID, map[string]interface{}{"name": symbol.Name})
+	return utils.Success(c, "Symbol deleted successfully")
+}
+
+// AllotCandidateSymbol records the free symbol an independent has chosen.
+func AllotCandidateSymbol(c *fiber.Ctx) error {
+	var candidate models.Candidate
+	if err := database.PostgresDB.First(&candidate, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Candidate not found")
+	}
+
+	var req struct {
+		Symbol string `json:"symbol"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	previous := candidate.Symbol
+	if err := service.AllotSymbol(&candidate, req.Symbol); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "ALLOT_SYMBOL", candidate.ID, map[string]interface{}{
+		"election_id": candidate.ElectionID,
+		"from":        previous,
+		"to":          candidate.Symbol,
+	})
+	return utils.Success(c, candidate)
+}
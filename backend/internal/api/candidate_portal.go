@@ -0,0 +1,269 @@
+package api
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CandidateLoginReq struct {
+	CandidateID uint   `json:"candidate_id"`
+	Mobile      string `json:"mobile"`
+}
+
+type CandidateOTPVerifyReq struct {
+	CandidateID   uint   `json:"candidate_id"`
+	FirebaseToken string `json:"firebase_token"`
+}
+
+func CandidateLogin(c *fiber.Ctx) error {
+	if !service.CandidatePortalOpen() {
+		return utils.Error(c, 503, "The candidate portal is currently closed.")
+	}
+
+	var req CandidateLoginReq
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+	if req.CandidateID == 0 || req.Mobile == "" {
+		return utils.Error(c, 400, "Candidate ID and mobile are required")
+	}
+
+	mobile, err := service.InitiateCandidateLogin(req.CandidateID, req.Mobile)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, fiber.Map{
+		"message": "Candidate verified",
+		"phone":   mobile,
+	})
+}
+
+func VerifyCandidateOTP(c *fiber.Ctx) error {
+	if !service.CandidatePortalOpen() {
+		return utils.Error(c, 503, "The candidate portal is currently closed.")
+	}
+
+	var req CandidateOTPVerifyReq
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	token, err := service.VerifyCandidateFirebase(req.CandidateID, req.FirebaseToken)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, fiber.Map{"token": token})
+}
+
+// portalCandidate loads the candidate the token was issued for. Portal
+// handlers never take a candidate ID from the request.
+func portalCandidate(c *fiber.Ctx) (*models.Candidate, error) {
+	id, role := currentActor(c)
+	if role != "CANDIDATE" || id == 0 {
+		return nil, fiber.NewError(403, "Candidate portal access only")
+	}
+	var cand models.Candidate
+	if err := database.PostgresDB.Preload("Party").First(&cand, id).Error; err != nil {
+		return nil, fiber.NewError(404, "Candidate not found")
+	}
+	return &cand, nil
+}
+
+func portalError(c *fiber.Ctx, err error) error {
+	if fe, ok := err.(*fiber.Error); ok {
+		return utils.Error(c, fe.Code, fe.Message)
+	}
+	return utils.Error(c, 400, err.Error())
+}
+
+// GetCandidateProfile shows the candidate their record and nomination status.
+func GetCandidateProfile(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+
+	var election models.Election
+	database.PostgresDB.First(&election, cand.ElectionID)
+	election.Status = calculateStatus(election.StartDate, election.EndDate, election.IsActive)
+
+	return utils.Success(c, fiber.Map{
+		"candidate": cand,
+		"election": fiber.Map{
+			"id":                  election.ID,
+			"title":               election.Title,
+			"status":              election.Status,
+			"start_date":          election.StartDate,
+			"end_date":            election.EndDate,
+			"nomination_deadline": election.NominationDeadline,
+			"withdrawal_deadline": election.WithdrawalDeadline,
+			"ballot_frozen_at":    election.BallotFrozenAt,
+		},
+	})
+}
+
+func ListCandidateDocuments(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+
+	var docs []models.CandidateDocument
+	database.PostgresDB.Where("candidate_id = ?", cand.ID).Order("created_at desc").Find(&docs)
+	return utils.Success(c, docs)
+}
+
+// UploadCandidateDocument takes an affidavit, its redacted public copy, a
+// photo or a manifesto for review.
+func UploadCandidateDocument(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.Error(c, 400, "File is required")
+	}
+	kind, err := service.CheckCandidateDocument(c.FormValue("kind"), file.Filename, file.Size)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	filename := fmt.Sprintf("candidate_doc_%d_%d%s", cand.ID, time.Now().UnixNano(), filepath.Ext(file.Filename))
+	if err := c.SaveFile(file, service.CandidateDocumentPath(filename)); err != nil {
+		return utils.Error(c, 500, "Failed to save file")
+	}
+
+	actor, _ := c.Locals("portal_actor").(string)
+	doc, err := service.SubmitCandidateDocument(cand.ID, kind, file.Filename, filename, actor)
+	if err != nil {
+		return utils.Error(c, 500, err.Error())
+	}
+
+	logAdminAction(c, "UPLOAD_CANDIDATE_DOCUMENT", doc.ID, map[string]interface{}{
+		"candidate_id": cand.ID,
+		"kind":         doc.Kind,
+		"submitted_by": doc.SubmittedBy,
+	})
+	return utils.Success(c, doc)
+}
+
+// DownloadOwnDocument serves one of the candidate's own uploads.
+func DownloadOwnDocument(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+	var doc models.CandidateDocument
+	if err := database.PostgresDB.Where("id = ? AND candidate_id = ?", c.Params("docId"), cand.ID).First(&doc).Error; err != nil {
+		return utils.Error(c, 404, "Document not found")
+	}
+	return c.Download(service.CandidateDocumentPath(doc.Path), doc.FileName)
+}
+
+// GetCandidateCertifiedResult returns the declared result of the candidate's
+// election, as JSON or, with ?format=csv, as a downloadable result sheet.
+func GetCandidateCertifiedResult(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+
+	result, err := service.CertifiedResult(cand.ID)
+	if err != nil {
+		return utils.Error(c, 404, err.Error())
+	}
+	if c.Query("format") != "csv" {
+		return utils.Success(c, result)
+	}
+
+	var election models.Election
+	database.PostgresDB.First(&election, result.ElectionID)
+
+	b := &bytes.Buffer{}
+	w := csv.NewWriter(b)
+	w.Write([]string{"election_id", "election", "declared_at", "serial_no", "candidate", "party", "symbol", "votes", "vote_share", "rank", "elected"})
+	declaredAt := ""
+	if result.DeclaredAt != nil {
+		declaredAt = result.DeclaredAt.Format(time.RFC3339)
+	}
+	for _, e := range result.Entries {
+		w.Write([]string{
+			strconv.FormatUint(uint64(result.ElectionID), 10), election.Title, declaredAt,
+			strconv.Itoa(e.SerialNo), e.CandidateName, e.PartyName, e.Symbol,
+			strconv.FormatInt(e.VoteCount, 10), strconv.FormatFloat(e.VoteShare, 'f', 2, 64),
+			strconv.Itoa(e.Rank), strconv.FormatBool(e.IsElected),
+		})
+	}
+	w.Flush()
+
+	c.Set("Content-Type", "text/csv")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=certified_result_%d.csv", result.ElectionID))
+	return c.SendStream(bytes.NewReader(b.Bytes()))
+}
+
+// --- Admin review of portal uploads ---
+
+func ListPendingCandidateDocuments(c *fiber.Ctx) error {
+	query := database.PostgresDB.Model(&models.CandidateDocument{})
+	if status := c.Query("status", service.DocumentPending); status != "ALL" {
+		query = query.Where("status = ?", status)
+	}
+	if id := c.QueryInt("candidate_id"); id > 0 {
+		query = query.Where("candidate_id = ?", id)
+	}
+
+	var docs []models.CandidateDocument
+	if err := query.Order("created_at asc").Find(&docs).Error; err != nil {
+		return utils.Error(c, 500, "Failed to fetch documents")
+	}
+	return utils.Success(c, docs)
+}
+
+func DownloadCandidateDocument(c *fiber.Ctx) error {
+	var doc models.CandidateDocument
+	if err := database.PostgresDB.First(&doc, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Document not found")
+	}
+	return c.Download(service.CandidateDocumentPath(doc.Path), doc.FileName)
+}
+
+func ReviewCandidateDocument(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid document ID")
+	}
+
+	var req struct {
+		Approve bool   `json:"approve"`
+		Note    string `json:"note"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	actorID, _ := currentActor(c)
+	doc, err := service.ReviewCandidateDocument(uint(id), req.Approve, req.Note, actorID)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "REVIEW_CANDIDATE_DOCUMENT", doc.ID, map[string]interface{}{
+		"candidate_id": doc.CandidateID,
+		"kind":         doc.Kind,
+		"status":       doc.Status,
+		"note":         doc.ReviewNote,
+	})
+	return utils.Success(c, doc)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"E-voting/internal/database"
 	"E-voting/internal/models"
+	"E-voting/internal/service"
 	"E-voting/internal/utils"
 	"context"
 	"time"
@@ -21,7 +22,7 @@ func GetDashboardData(c *fiber.Ctx) error {
 	recentLogs := []models.AuditLog{}
 
 	database.PostgresDB.Model(&models.Voter{}).Count(&totalVoters)
-	database.PostgresDB.Model(&models.Vote{}).Count(&totalVotes)
+	totalVotes = service.TotalVotesCast()
 
 	database.PostgresDB.Model(&models.Candidate{}).Count(&totalCandidates)
 	database.PostgresDB.Model(&models.Election{}).Where("is_active = ?", true).Count(&activeElections)
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// --- Public candidate profiles ---
+
+func GetPublicCandidate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid candidate ID")
+	}
+	profile, err := service.PublicCandidate(uint(id))
+	if err != nil {
+		return utils.Error(c, 404, err.Error())
+	}
+	return utils.Success(c, profile)
+}
+
+func GetPublicElectionCandidates(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+	profiles, err := service.PublicElectionCandidates(uint(id))
+	if err != nil {
+		return utils.Error(c, 404, err.Error())
+	}
+	return utils.Success(c, profiles)
+}
+
+// GetPublicAffidavit serves the redacted affidavit behind a verified disclosure.
+func GetPublicAffidavit(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid candidate ID")
+	}
+	path, err := service.PublicAffidavit(uint(id))
+	if err != nil {
+		return utils.Error(c, 404, err.Error())
+	}
+	return c.Download(path, fmt.Sprintf("affidavit_%d.pdf", id))
+}
+
+// --- Candidate portal ---
+
+func GetOwnDisclosure(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+	d, err := service.GetDisclosure(cand.ID)
+	if err != nil {
+		return utils.Error(c, 404, err.Error())
+	}
+	return utils.Success(c, d)
+}
+
+func FileOwnDisclosure(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+	var req service.DisclosureInput
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	d, err := service.FileDisclosure(cand.ID, req)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	actor, _ := c.Locals("portal_actor").(string)
+	logAdminAction(c, "FILE_DISCLOSURE", cand.ID, map[string]interface{}{
+		"submitted_by":   actor,
+		"criminal_cases": len(d.CriminalCases),
+	})
+	return utils.Success(c, d)
+}
+
+// --- Admin ---
+
+func GetCandidateDisclosure(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid candidate ID")
+	}
+	d, err := service.GetDisclosure(uint(id))
+	if err != nil {
+		return utils.Error(c, 404, err.Error())
+	}
+	return utils.Success(c, d)
+}
+
+// FileCandidateDisclosure lets an admin enter a disclosure filed on paper.
+func FileCandidateDisclosure(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid candidate ID")
+	}
+	var req service.DisclosureInput
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	d, err := service.FileDisclosure(uint(id), req)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	logAdminAction(c, "FILE_DISCLOSURE", d.CandidateID, map[string]interface{}{
+		"submitted_by":   "ADMIN",
+		"criminal_cases": len(d.CriminalCases),
+	})
+	return utils.Success(c, d)
+}
+
+func VerifyCandidateDisclosure(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid candidate ID")
+	}
+	var req struct {
+		Approve bool   `json:"approve"`
+		Note    string `json:"note"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	actorID, _ := currentActor(c)
+	d, err := service.VerifyDisclosure(uint(id), req.Approve, req.Note, actorID)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	logAdminAction(c, "VERIFY_DISCLOSURE", d.CandidateID, map[string]interface{}{
+		"status": d.Status,
+		"note":   d.ReviewNote,
+	})
+	return utils.Success(c, d)
+}
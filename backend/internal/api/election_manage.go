@@ -2,9 +2,11 @@ package api
 
 import (
 	"E-voting/internal/database"
+	"E-voting/internal/events"
 	"E-voting/internal/models"
 	"E-voting/internal/service"
 	"E-voting/internal/utils"
+	"log"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -20,6 +22,23 @@ type CreateElectionRequest struct {
 	Block         string `json:"block"`
 	LocalBodyName string `json:"local_body_name"`
 	Ward          string `json:"ward"`
+	BallotType    string `json:"ballot_type"`
+	Seats         int    `json:"seats"`
+	MaxSelections int    `json:"max_selections"`
+	AllowNota     bool   `json:"allow_nota"`
+	Weighted      bool   `json:"weighted"`
+	Indirect      bool   `json:"indirect"`
+	Office        string `json:"office"`
+	OpenBallot    bool   `json:"open_ballot"`
+
+	// Reservation wins over the rotation entry of DelimitationCycle
+	Reservation       string `json:"reservation"`
+	DelimitationCycle string `json:"delimitation_cycle"`
+
+	NominationDeadline *time.Time `json:"nomination_deadline"`
+	WithdrawalDeadline *time.Time `json:"withdrawal_deadline"`
+
+	ExpenditureDeadline *time.Time `json:"expenditure_deadline"`
 }
 
 func CreateElection(c *fiber.Ctx) error {
@@ -47,6 +66,23 @@ func CreateElection(c *fiber.Ctx) error {
 		return utils.Error(c, 400, "End Date must be strictly after Start Date")
 	}
 
+	if req.BallotType == "" {
+		req.BallotType = service.BallotSingle
+	}
+	if !service.ValidBallotType(req.BallotType) {
+		return utils.Error(c, 400, "Ballot type must be SINGLE, RANKED, APPROVAL or REFERENDUM")
+	}
+	seats, maxSelections, err := service.NormalizeSeats(req.BallotType, req.Seats, req.MaxSelections)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if err := service.ValidateNota(req.BallotType, req.AllowNota); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if err := service.ValidateWeighted(req.BallotType, req.Weighted); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
 	// 3. Map to Model
 	election := models.Election{
 		Title:         req.Title,
@@ -60,11 +96,44 @@ func CreateElection(c *fiber.Ctx) error {
 		Ward:          req.Ward,
 		IsActive:      false,
 		Status:        calculateStatus(start, end, false),
+		BallotType:    req.BallotType,
+		Seats:         seats,
+		MaxSelections: maxSelections,
+		AllowNota:     req.AllowNota,
+		Weighted:      req.Weighted,
+		Indirect:      req.Indirect,
+		Office:        req.Office,
+		OpenBallot:    req.OpenBallot,
+		Round:         1,
+
+		DelimitationCycle:  req.DelimitationCycle,
+		NominationDeadline: req.NominationDeadline,
+		WithdrawalDeadline: req.WithdrawalDeadline,
+
+		ExpenditureDeadline: req.ExpenditureDeadline,
+	}
+	if err := service.ValidateIndirect(election); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if err := service.ValidateNominationDeadlines(election); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if err := service.ValidateExpenditureDeadline(election); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	if req.Reservation == "" && req.DelimitationCycle != "" {
+		if req.Reservation, err = service.LookupWardReservation(election); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+	}
+	if election.Reservation, err = service.NormalizeReservation(req.Reservation); err != nil {
+		return utils.Error(c, 400, err.Error())
 	}
 
-	isWardRequired := req.ElectionType == "Grama Panchayat" ||
+	isWardRequired := !req.Indirect && (req.ElectionType == "Grama Panchayat" ||
 		req.ElectionType == "Municipality" ||
-		req.ElectionType == "Municipal Corporation"
+		req.ElectionType == "Municipal Corporation")
 
 	if isWardRequired && req.Ward == "" {
 		return utils.Error(c, 400, "Ward number is required for "+req.ElectionType+" elections.")
@@ -76,8 +145,13 @@ func CreateElection(c *fiber.Ctx) error {
 
 	// 4. Audit Log
 	logAdminAction(c, "CREATE_ELECTION", election.ID, map[string]interface{}{
-		"title": election.Title,
-		"type":  election.ElectionType,
+		"title":       election.Title,
+		"type":        election.ElectionType,
+		"ballot":      election.BallotType,
+		"seats":       election.Seats,
+		"weighted":    election.Weighted,
+		"indirect":    election.Indirect,
+		"reservation": election.Reservation,
 	})
 
 	return utils.Success(c, "Election created successfully")
@@ -112,6 +186,22 @@ func UpdateElection(c *fiber.Ctx) error {
 		LocalBodyName string    `json:"local_body_name"`
 		Ward          string    `json:"ward"`
 		IsActive      bool      `json:"is_active"`
+		BallotType    string    `json:"ballot_type"`
+		Seats         int       `json:"seats"`
+		MaxSelections int       `json:"max_selections"`
+		AllowNota     *bool     `json:"allow_nota"`
+		Weighted      *bool     `json:"weighted"`
+		Office        string    `json:"office"`
+		OpenBallot    *bool     `json:"open_ballot"`
+
+		// Reservation is kept when absent; "" or GENERAL lifts it.
+		Reservation       *string `json:"reservation"`
+		DelimitationCycle string  `json:"delimitation_cycle"`
+
+		NominationDeadline *time.Time `json:"nomination_deadline"`
+		WithdrawalDeadline *time.Time `json:"withdrawal_deadline"`
+
+		ExpenditureDeadline *time.Time `json:"expenditure_deadline"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -126,15 +216,57 @@ func UpdateElection(c *fiber.Ctx) error {
 		return utils.Error(c, 403, "Cannot edit a completed election.")
 	}
 
-	isWardRequired := req.ElectionType == "Grama Panchayat" ||
+	isWardRequired := !election.Indirect && (req.ElectionType == "Grama Panchayat" ||
 		req.ElectionType == "Municipality" ||
-		req.ElectionType == "Municipal Corporation"
+		req.ElectionType == "Municipal Corporation")
 
 	if isWardRequired && req.Ward == "" {
 		return utils.Error(c, 400, "Ward number is required for "+req.ElectionType+" elections.")
 	}
 
+	ballotChanged := req.BallotType != "" && req.BallotType != election.BallotType
+	if ballotChanged {
+		if !service.ValidBallotType(req.BallotType) {
+			return utils.Error(c, 400, "Ballot type must be SINGLE, RANKED, APPROVAL or REFERENDUM")
+		}
+		election.BallotType = req.BallotType
+	}
+	if req.Seats == 0 {
+		req.Seats = election.Seats
+	}
+	if req.MaxSelections == 0 && !ballotChanged {
+		req.MaxSelections = election.MaxSelections
+	}
+	seats, maxSelections, err := service.NormalizeSeats(election.BallotType, req.Seats, req.MaxSelections)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	election.Seats = seats
+	election.MaxSelections = maxSelections
+
+	if req.AllowNota != nil {
+		election.AllowNota = *req.AllowNota
+	}
+	if err := service.ValidateNota(election.BallotType, election.AllowNota); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	// The frozen roll only applies to the ballot it was finalised for
+	if req.Weighted != nil && *req.Weighted != election.Weighted {
+		if election.RollFinalizedAt != nil {
+			return utils.Error(c, 403, "Cannot change weighted voting after the roll has been finalised")
+		}
+		election.Weighted = *req.Weighted
+	}
+	if election.RollFinalizedAt != nil && ballotChanged {
+		return utils.Error(c, 403, "Cannot change the ballot type after the roll has been finalised")
+	}
+	if err := service.ValidateWeighted(election.BallotType, election.Weighted); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
 	// Update Fields
+	datesChanged := !req.StartDate.Equal(election.StartDate) || !req.EndDate.Equal(election.EndDate)
 	election.Title = req.Title
 	election.Description = req.Description
 	election.StartDate = req.StartDate
@@ -144,8 +276,66 @@ func UpdateElection(c *fiber.Ctx) error {
 	election.Block = req.Block
 	election.LocalBodyName = req.LocalBodyName
 	election.Ward = req.Ward
+	if req.Office != "" {
+		election.Office = req.Office
+	}
+	if req.OpenBallot != nil {
+		election.OpenBallot = *req.OpenBallot
+	}
+
+	// Indirect is fixed at creation; its electorate hangs off the local body
+	if err := service.ValidateIndirect(election); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	if req.NominationDeadline != nil {
+		election.NominationDeadline = req.NominationDeadline
+	}
+	if req.WithdrawalDeadline != nil {
+		election.WithdrawalDeadline = req.WithdrawalDeadline
+	}
+	if req.ExpenditureDeadline != nil {
+		election.ExpenditureDeadline = req.ExpenditureDeadline
+	}
+	if err := service.ValidateNominationDeadlines(election); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if err := service.ValidateExpenditureDeadline(election); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	if req.DelimitationCycle != "" {
+		election.DelimitationCycle = req.DelimitationCycle
+	}
+	// An explicit reservation wins; otherwise a cycle brings in its rotation
+	// entry, and without either the seat keeps its reservation.
+	reservation := election.Reservation
+	switch {
+	case req.Reservation != nil:
+		reservation = *req.Reservation
+	case req.DelimitationCycle != "":
+		if reservation, err = service.LookupWardReservation(election); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+	}
+	if reservation, err = service.NormalizeReservation(reservation); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if reservation != election.Reservation {
+		if err := service.CheckReservationChange(election.ID, reservation); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+		election.Reservation = reservation
+	}
 
 	// Handle "Stop Permanently" or Pause from Update form
+	wasActive := election.IsActive
+	previousStatus := election.Status
+	if req.IsActive && !wasActive {
+		if err := service.CheckFinalList(election); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+	}
 	election.IsActive = req.IsActive
 
 	// Recalculate status string based on new dates/active state
@@ -155,7 +345,32 @@ func UpdateElection(c *fiber.Ctx) error {
 		return utils.Error(c, 500, "Failed to update election")
 	}
 
-	logAdminAction(c, "UPDATE_ELECTION", election.ID, nil)
+	// New dates can make other elections concurrent with this one, so its
+	// nominations are checked for duplicates again.
+	var details map[string]interface{}
+	if datesChanged {
+		if _, err := service.RescanElectionNominations(election.ID); err != nil {
+			log.Printf("Duplicate rescan failed for election %d: %v", election.ID, err)
+			details = map[string]interface{}{"duplicate_check_error": err.Error()}
+		}
+	}
+	logAdminAction(c, "UPDATE_ELECTION", election.ID, details)
+	if err := service.RefreshFeedRevision(election.ID); err != nil {
+		log.Printf("Failed to refresh feed revision of election %d: %v", election.ID, err)
+	}
+
+	if wasActive != election.IsActive {
+		actorID, actorRole := currentActor(c)
+		events.Publish(events.ElectionStateChanged{
+			ElectionID: election.ID,
+			Title:      election.Title,
+			Active:     election.IsActive,
+			From:       previousStatus,
+			To:         election.Status,
+			ActorID:    actorID,
+			ActorRole:  actorRole,
+		})
+	}
 	return utils.Success(c, "Election updated successfully")
 }
 
@@ -215,8 +430,15 @@ func ToggleElectionStatus(c *fiber.Ctx) error {
 		if time.Now().After(election.EndDate) {
 			return utils.Error(c, 400, "Cannot resume an election that has already ended.")
 		}
+		if election.Weighted && election.RollFinalizedAt == nil {
+			return utils.Error(c, 400, "Finalise the roll before opening a weighted election.")
+		}
+		if err := service.CheckFinalList(election); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
 	}
 
+	previousStatus := election.Status
 	election.IsActive = req.Status
 	election.Status = calculateStatus(election.StartDate, election.EndDate, election.IsActive)
 
@@ -224,15 +446,42 @@ func ToggleElectionStatus(c *fiber.Ctx) error {
 		return utils.Error(c, 500, "Failed to update status")
 	}
 
-	action := "PAUSE_ELECTION"
-	if req.Status {
-		action = "RESUME_ELECTION"
-	}
-	logAdminAction(c, action, election.ID, nil)
+	actorID, actorRole := currentActor(c)
+	events.Publish(events.ElectionStateChanged{
+		ElectionID: election.ID,
+		Title:      election.Title,
+		Active:     election.IsActive,
+		From:       previousStatus,
+		To:         election.Status,
+		ActorID:    actorID,
+		ActorRole:  actorRole,
+	})
 
 	return utils.Success(c, "Election status updated")
 }
 
+// FinalizeElectionRoll freezes the vote weights of a weighted election.
+func FinalizeElectionRoll(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	summary, err := service.FinalizeWeightedRoll(uint(id))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "FINALIZE_ROLL", summary.ElectionID, map[string]interface{}{
+		"voters":        summary.Voters,
+		"total_weight":  summary.TotalWeight,
+		"capped":        summary.Capped,
+		"capped_voters": summary.CappedVoters,
+		"max_weight":    summary.MaxWeight,
+	})
+	return utils.Success(c, summary)
+}
+
 func ToggleElectionPublish(c *fiber.Ctx) error {
 	var req struct {
 		ElectionID uint `json:"election_id"`
@@ -243,10 +492,24 @@ func ToggleElectionPublish(c *fiber.Ctx) error {
 		return utils.Error(c, 400, "Invalid request")
 	}
 
-	if err := database.PostgresDB.Model(&models.Election{}).Where("id = ?", req.ElectionID).Update("is_published", req.Publish).Error; err != nil {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, req.ElectionID).Error; err != nil {
+		return utils.Error(c, 404, "Election not found")
+	}
+
+	if err := database.PostgresDB.Model(&election).Update("is_published", req.Publish).Error; err != nil {
 		return utils.Error(c, 500, "Failed to update publish status")
 	}
 
+	actorID, actorRole := currentActor(c)
+	events.Publish(events.ResultsPublished{
+		ElectionID: election.ID,
+		Title:      election.Title,
+		Published:  req.Publish,
+		ActorID:    actorID,
+		ActorRole:  actorRole,
+	})
+
 	return utils.Success(c, "Election publish status updated")
 }
 
@@ -277,6 +540,13 @@ func logAdminAction(c *fiber.Ctx, action string, targetID uint, details map[stri
 	}
 }
 
+// currentActor returns the authenticated user's ID and role from the request context
+func currentActor(c *fiber.Ctx) (uint, string) {
+	userIDFloat, _ := c.Locals("user_id").(float64)
+	role, _ := c.Locals("role").(string)
+	return uint(userIDFloat), role
+}
+
 func GetPublishedElections(c *fiber.Ctx) error {
 	var elections []models.Election
 	if err := database.PostgresDB.Where("is_published = ?", true).Order("end_date desc").Find(&elections).Error; err != nil {
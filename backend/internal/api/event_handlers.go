@@ -0,0 +1,37 @@
+package api
+
+import (
+	"E-voting/internal/events"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterEventHandlers relays domain events to connected WebSocket clients.
+func RegisterEventHandlers() {
+	events.SubscribeAsync(events.NameVoteCast, func(e events.Event) {
+		v := e.(events.VoteCast)
+		BroadcastVoteUpdate(v.ElectionID, v.ElectionTitle)
+	})
+
+	events.SubscribeAsync(events.NameElectionStateChanged, func(e events.Event) {
+		ev := e.(events.ElectionStateChanged)
+		hub.Publish(TopicAdminAlerts, fiber.Map{
+			"type":        "ELECTION_STATE",
+			"election_id": ev.ElectionID,
+			"election":    ev.Title,
+			"status":      ev.To,
+		})
+		hub.Publish(StreamTopic(ev.ElectionID), fiber.Map{"type": "ELECTION_STATE", "status": ev.To})
+	})
+
+	events.SubscribeAsync(events.NameResultsPublished, func(e events.Event) {
+		ev := e.(events.ResultsPublished)
+		hub.Publish(TopicAdminAlerts, fiber.Map{
+			"type":        "RESULTS_PUBLISHED",
+			"election_id": ev.ElectionID,
+			"election":    ev.Title,
+			"published":   ev.Published,
+		})
+		hub.Publish(StreamTopic(ev.ElectionID), fiber.Map{"type": "RESULTS_PUBLISHED", "published": ev.Published})
+	})
+}
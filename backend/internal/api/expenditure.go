@@ -0,0 +1,244 @@
+package api
+
+import (
+	"E-voting/internal/models"
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// --- Limits ---
+
+func ListExpenditureLimits(c *fiber.Ctx) error {
+	limits, err := service.ListExpenditureLimits()
+	if err != nil {
+		return utils.Error(c, 500, "Failed to fetch limits")
+	}
+	return utils.Success(c, limits)
+}
+
+func SetExpenditureLimit(c *fiber.Ctx) error {
+	var req models.ExpenditureLimit
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+	limit, err := service.SetExpenditureLimit(req.ElectionType, req.Amount)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	logAdminAction(c, "SET_EXPENDITURE_LIMIT", limit.ID, map[string]interface{}{
+		"election_type": limit.ElectionType,
+		"amount":        limit.Amount,
+	})
+	return utils.Success(c, limit)
+}
+
+// --- Statements ---
+
+func expenditureItemRequest(c *fiber.Ctx) (models.ExpenditureItem, error) {
+	var item models.ExpenditureItem
+	if err := c.BodyParser(&item); err != nil {
+		return item, fiber.NewError(400, "Invalid request")
+	}
+	return item, nil
+}
+
+func GetOwnExpenditure(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+	stmt, err := service.GetExpenditureStatement(cand.ID)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, stmt)
+}
+
+func AddOwnExpenditureItem(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+	item, err := expenditureItemRequest(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+	stmt, err := service.AddExpenditureItem(cand.ID, item)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, stmt)
+}
+
+func RemoveOwnExpenditureItem(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+	itemID, err := c.ParamsInt("itemId")
+	if err != nil || itemID <= 0 {
+		return utils.Error(c, 400, "Invalid item ID")
+	}
+	stmt, err := service.RemoveExpenditureItem(cand.ID, uint(itemID))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, stmt)
+}
+
+func FileOwnExpenditure(c *fiber.Ctx) error {
+	cand, err := portalCandidate(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+	actor, _ := c.Locals("portal_actor").(string)
+	stmt, err := service.FileExpenditure(cand.ID, actor, time.Now())
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	logAdminAction(c, "FILE_EXPENDITURE", cand.ID, map[string]interface{}{
+		"filed_by": stmt.FiledBy,
+		"total":    stmt.Total,
+	})
+	return utils.Success(c, stmt)
+}
+
+// Admins enter statements filed on paper.
+
+func GetCandidateExpenditure(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid candidate ID")
+	}
+	stmt, err := service.GetExpenditureStatement(uint(id))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, stmt)
+}
+
+func AddCandidateExpenditureItem(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid candidate ID")
+	}
+	item, err := expenditureItemRequest(c)
+	if err != nil {
+		return portalError(c, err)
+	}
+	stmt, err := service.AddExpenditureItem(uint(id), item)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, stmt)
+}
+
+func RemoveCandidateExpenditureItem(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid candidate ID")
+	}
+	itemID, err := c.ParamsInt("itemId")
+	if err != nil || itemID <= 0 {
+		return utils.Error(c, 400, "Invalid item ID")
+	}
+	stmt, err := service.RemoveExpenditureItem(uint(id), uint(itemID))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, stmt)
+}
+
+func FileCandidateExpenditure(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid candidate ID")
+	}
+	stmt, err := service.FileExpenditure(uint(id), "ADMIN", time.Now())
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	logAdminAction(c, "FILE_EXPENDITURE", stmt.CandidateID, map[string]interface{}{
+		"filed_by": stmt.FiledBy,
+		"total":    stmt.Total,
+	})
+	return utils.Success(c, stmt)
+}
+
+// --- Reports ---
+
+func sendExpenditureCSV(c *fiber.Ctx, name string, rows []service.ExpenditureSummary) error {
+	b := &bytes.Buffer{}
+	w := csv.NewWriter(b)
+	w.Write([]string{"election_id", "election", "election_type", "candidate_id", "candidate", "party", "total", "limit", "filed_at", "deadline", "over_limit", "not_filed", "late_filed"})
+	stamp := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	for _, s := range rows {
+		w.Write([]string{
+			strconv.FormatUint(uint64(s.ElectionID), 10), s.ElectionTitle, s.ElectionType,
+			strconv.FormatUint(uint64(s.CandidateID), 10), s.CandidateName, s.PartyName,
+			strconv.FormatInt(s.Total, 10), strconv.FormatInt(s.Limit, 10),
+			stamp(s.FiledAt), stamp(s.Deadline),
+			strconv.FormatBool(s.OverLimit), strconv.FormatBool(s.NotFiled), strconv.FormatBool(s.LateFiled),
+		})
+	}
+	w.Flush()
+
+	c.Set("Content-Type", "text/csv")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", name))
+	return c.SendStream(bytes.NewReader(b.Bytes()))
+}
+
+func GetExpenditureReport(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+	report, err := service.ExpenditureReport(uint(id), time.Now())
+	if err != nil {
+		return utils.Error(c, 404, err.Error())
+	}
+	if c.Query("format") == "csv" {
+		return sendExpenditureCSV(c, fmt.Sprintf("expenditure_%d", id), report)
+	}
+	return utils.Success(c, report)
+}
+
+// GetExpenditureFlags lists overspending, non-filing and late-filing
+// candidates, optionally by ?election_type and ?district.
+func GetExpenditureFlags(c *fiber.Ctx) error {
+	flags, err := service.ExpenditureFlags(service.ExpenditureFilter{
+		ElectionType: c.Query("election_type"),
+		District:     c.Query("district"),
+	}, time.Now())
+	if err != nil {
+		return utils.Error(c, 500, "Failed to build report")
+	}
+	if c.Query("format") == "csv" {
+		return sendExpenditureCSV(c, "expenditure_flags", flags)
+	}
+	return utils.Success(c, flags)
+}
+
+func GetPublicExpenditure(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+	summary, err := service.PublicExpenditureSummary(uint(id), time.Now())
+	if err != nil {
+		return utils.Error(c, 404, err.Error())
+	}
+	return utils.Success(c, summary)
+}
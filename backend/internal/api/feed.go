@@ -0,0 +1,197 @@
+package api
+
+import (
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const feedMaxAge = 10
+
+var electionCSVHeader = []string{
+	"schema_version", "seq", "election_id", "election_title", "election_type", "district",
+	"local_body_name", "ward", "result_status", "total_votes",
+	"candidate_id", "candidate_name", "party", "votes", "vote_share", "rank", "elected",
+}
+
+// feedFormat picks json, csv or xml from ?format= or, failing that, the Accept header.
+func feedFormat(c *fiber.Ctx) (string, bool) {
+	if f := strings.ToLower(c.Query("format")); f != "" {
+		return f, f == "json" || f == "csv" || f == "xml"
+	}
+	switch c.Accepts(fiber.MIMEApplicationJSON, "text/csv", fiber.MIMEApplicationXML) {
+	case "text/csv":
+		return "csv", true
+	case fiber.MIMEApplicationXML:
+		return "xml", true
+	}
+	return "json", true
+}
+
+func feedContentType(format string) string {
+	switch format {
+	case "csv":
+		return "text/csv; charset=utf-8"
+	case "xml":
+		return fiber.MIMEApplicationXMLCharsetUTF8
+	}
+	return fiber.MIMEApplicationJSONCharsetUTF8
+}
+
+// sendFeed serves a cached document when no feed revision has moved and
+// otherwise builds, encodes and caches it. Only documents that were found
+// are cached, so made-up names cannot fill the cache.
+func sendFeed(c *fiber.Ctx, key string, build func() (interface{}, error), toCSV func(interface{}) ([][]string, error)) error {
+	format, ok := feedFormat(c)
+	if !ok {
+		return utils.Error(c, 400, "Unsupported format. Use json, csv or xml")
+	}
+
+	c.Set("X-Feed-Schema-Version", service.FeedSchemaVersion)
+	c.Vary("Accept")
+
+	cacheKey := "feed:" + key + ":" + format
+	stamp := service.FeedStamp()
+	if body, etag, _, hit := service.GetCachedResults(cacheKey, stamp); hit {
+		return utils.SendWithETag(c, body, etag, feedContentType(format), feedMaxAge)
+	}
+
+	doc, err := build()
+	if errors.Is(err, service.ErrElectionFeedNotFound) || errors.Is(err, service.ErrLocalBodyFeedNotFound) {
+		return utils.Error(c, 404, err.Error())
+	}
+	if err != nil {
+		return utils.Error(c, 500, err.Error())
+	}
+
+	var body []byte
+	switch format {
+	case "csv":
+		rows, err := toCSV(doc)
+		if err != nil {
+			return utils.Error(c, 500, "Failed to encode feed")
+		}
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.WriteAll(rows); err != nil {
+			return utils.Error(c, 500, "Failed to encode feed")
+		}
+		body = buf.Bytes()
+	case "xml":
+		out, err := xml.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return utils.Error(c, 500, "Failed to encode feed")
+		}
+		body = append([]byte(xml.Header), out...)
+	default:
+		if body, err = json.Marshal(doc); err != nil {
+			return utils.Error(c, 500, "Failed to encode feed")
+		}
+	}
+
+	etag, _ := service.StoreCachedResults(cacheKey, stamp, body)
+	return utils.SendWithETag(c, body, etag, feedContentType(format), feedMaxAge)
+}
+
+func GetFeedManifest(c *fiber.Ctx) error {
+	return sendFeed(c, "manifest",
+		func() (interface{}, error) {
+			m, err := service.BuildFeedManifest()
+			if err != nil {
+				return nil, err
+			}
+			return m, nil
+		},
+		func(doc interface{}) ([][]string, error) {
+			m := doc.(*service.FeedManifest)
+			rows := [][]string{{"schema_version", "election_id", "title", "election_type", "district", "local_body_name", "ward", "result_status", "seq", "updated_at", "url"}}
+			for _, e := range m.Elections {
+				rows = append(rows, []string{
+					m.SchemaVersion, strconv.FormatUint(uint64(e.ID), 10), e.Title, e.ElectionType, e.District,
+					e.LocalBodyName, e.Ward, e.ResultStatus, strconv.FormatInt(e.Seq, 10),
+					e.UpdatedAt.Format(time.RFC3339), e.URL,
+				})
+			}
+			return rows, nil
+		})
+}
+
+func GetElectionFeed(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	return sendFeed(c, fmt.Sprintf("election:%d", id),
+		func() (interface{}, error) {
+			doc, err := service.BuildElectionFeed(uint(id))
+			if err != nil {
+				return nil, err
+			}
+			return doc, nil
+		},
+		func(doc interface{}) ([][]string, error) {
+			d := doc.(*service.ElectionFeed)
+			rows := [][]string{electionCSVHeader}
+			return append(rows, electionCSVRows(d.SchemaVersion, d.Seq, d.Election, d.Result)...), nil
+		})
+}
+
+func GetLocalBodyFeed(c *fiber.Ctx) error {
+	district := strings.TrimSpace(c.Query("district"))
+	name := strings.TrimSpace(c.Query("name"))
+	electionType := strings.TrimSpace(c.Query("election_type"))
+	if district == "" || name == "" {
+		return utils.Error(c, 400, "district and name are required")
+	}
+
+	return sendFeed(c, "local-body:"+district+"|"+name+"|"+electionType,
+		func() (interface{}, error) {
+			doc, err := service.BuildLocalBodyFeed(district, name, electionType)
+			if err != nil {
+				return nil, err
+			}
+			return doc, nil
+		},
+		func(doc interface{}) ([][]string, error) {
+			d := doc.(*service.LocalBodyFeed)
+			rows := [][]string{electionCSVHeader}
+			for _, w := range d.Wards {
+				rows = append(rows, electionCSVRows(d.SchemaVersion, w.Seq, w.Election, w.Result)...)
+			}
+			return rows, nil
+		})
+}
+
+func electionCSVRows(schema string, seq int64, e service.FeedElection, r service.FeedResult) [][]string {
+	var rows [][]string
+	for _, cand := range r.Candidates {
+		rows = append(rows, []string{
+			schema, strconv.FormatInt(seq, 10), strconv.FormatUint(uint64(e.ID), 10), e.Title, e.ElectionType,
+			e.District, e.LocalBodyName, e.Ward, r.Status, strconv.FormatInt(r.TotalVotes, 10),
+			strconv.FormatUint(uint64(cand.CandidateID), 10), cand.Name, cand.Party,
+			strconv.FormatInt(cand.Votes, 10), strconv.FormatFloat(cand.VoteShare, 'f', 2, 64),
+			strconv.Itoa(cand.Rank), strconv.FormatBool(cand.Elected),
+		})
+	}
+	if r.Nota != nil {
+		rows = append(rows, []string{
+			schema, strconv.FormatInt(seq, 10), strconv.FormatUint(uint64(e.ID), 10), e.Title, e.ElectionType,
+			e.District, e.LocalBodyName, e.Ward, r.Status, strconv.FormatInt(r.TotalVotes, 10),
+			strconv.FormatUint(uint64(service.NotaCandidateID), 10), service.NotaLabel, "NOTA",
+			strconv.FormatInt(r.Nota.Votes, 10), strconv.FormatFloat(r.Nota.VoteShare, 'f', 2, 64),
+			"", "false",
+		})
+	}
+	return rows
+}
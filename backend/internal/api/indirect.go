@@ -0,0 +1,85 @@
+package api
+
+import (
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BuildElectorate seats the declared ward winners as the electorate of an indirect election.
+func BuildElectorate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	electors, err := service.BuildIndirectElectorate(uint(id))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "BUILD_ELECTORATE", uint(id), map[string]interface{}{"members": len(electors)})
+	return utils.Success(c, electors)
+}
+
+func GetElectorate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	electors, err := service.ListIndirectElectorate(uint(id))
+	if err != nil {
+		return utils.Error(c, 500, "Failed to fetch electorate")
+	}
+	return utils.Success(c, electors)
+}
+
+func StartSecondRound(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	var req struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+	start, err := time.Parse(time.RFC3339, req.StartDate)
+	if err != nil {
+		return utils.Error(c, 400, "Invalid Start Date format")
+	}
+	end, err := time.Parse(time.RFC3339, req.EndDate)
+	if err != nil {
+		return utils.Error(c, 400, "Invalid End Date format")
+	}
+
+	next, err := service.StartSecondRound(uint(id), start, end)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "START_SECOND_ROUND", next.ID, map[string]interface{}{
+		"previous_round": id,
+		"round":          next.Round,
+	})
+	return utils.Success(c, next)
+}
+
+func GetOpenBallots(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	entries, err := service.ListOpenBallots(uint(id))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, entries)
+}
@@ -30,3 +30,21 @@ func ManualRetryVotes(c *fiber.Ctx) error {
 		"details":        logs,
 	})
 }
+
+// ManualRebuildTallies recomputes the live tally tables from the votes table
+func ManualRebuildTallies(c *fiber.Ctx) error {
+	count, err := service.RebuildTallies()
+	if err != nil {
+		return utils.Error(c, 500, "Failed to rebuild tallies")
+	}
+	service.RefreshFeedRevisions()
+
+	actorID := uint(c.Locals("user_id").(float64))
+	actorRole := c.Locals("role").(string)
+	service.LogAdminAction(actorID, actorRole, "REBUILD_TALLIES", 0, map[string]interface{}{"elections": count})
+
+	return utils.Success(c, fiber.Map{
+		"message":         "Tally rebuild completed",
+		"elections_count": count,
+	})
+}
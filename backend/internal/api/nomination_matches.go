@@ -0,0 +1,65 @@
+package api
+
+import (
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListNominationMatches is the duplicate review queue, open matches by
+// default. ?status=ALL shows reviewed matches too.
+func ListNominationMatches(c *fiber.Ctx) error {
+	matches, err := service.ListNominationMatches(c.Query("status", service.MatchOpen), uint(c.QueryInt("election_id")))
+	if err != nil {
+		return utils.Error(c, 500, "Failed to fetch matches")
+	}
+	return utils.Success(c, matches)
+}
+
+func ReviewNominationMatch(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid match ID")
+	}
+	var req struct {
+		Decision string `json:"decision"`
+		Note     string `json:"note"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	actorID, _ := currentActor(c)
+	m, err := service.ReviewNominationMatch(uint(id), req.Decision, req.Note, actorID, time.Now())
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "REVIEW_NOMINATION_MATCH", m.ID, map[string]interface{}{
+		"candidate_id":         m.CandidateID,
+		"matched_candidate_id": m.MatchedCandidateID,
+		"rule":                 m.Rule,
+		"decision":             m.Status,
+		"note":                 m.ReviewNote,
+	})
+	return utils.Success(c, m)
+}
+
+// ScanNominationMatches re-runs the duplicate checks over an election's
+// nominations.
+func ScanNominationMatches(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+	found, err := service.ScanElectionNominations(uint(id))
+	if err != nil {
+		return utils.Error(c, 500, err.Error())
+	}
+	logAdminAction(c, "SCAN_NOMINATION_MATCHES", uint(id), map[string]interface{}{
+		"matches": found,
+	})
+	return utils.Success(c, fiber.Map{"matches": found})
+}
@@ -1,62 +1,401 @@
 package api
 
 import (
+	"E-voting/internal/config"
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/pubsub"
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
 )
 
-// Store active connections
-var (
-	adminClients = make(map[*websocket.Conn]bool)
-	clientsMux   sync.Mutex
+const (
+	TopicAdminAlerts = "admin:alerts"
+	TopicAuditFeed   = "audit:feed"
+
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = (wsPongWait * 9) / 10
+	wsSendBuffer = 64
+	wsMaxMessage = 1024
 )
 
-// WebSocket Handler for Admin Dashboard
+func TurnoutTopic(electionID uint) string {
+	return fmt.Sprintf("election:%d:turnout", electionID)
+}
+
+// StreamTopic carries changes to an election's status or result. It only
+// wakes SSE streams; WebSocket clients cannot join it.
+func StreamTopic(electionID uint) string {
+	return fmt.Sprintf("election:%d:stream", electionID)
+}
+
+type wsClient struct {
+	conn     *websocket.Conn
+	send     chan []byte
+	quit     chan struct{}
+	quitOnce sync.Once
+	userID   uint
+	role     string
+	perms    map[string]bool
+	topics   map[string]bool
+}
+
+func (cl *wsClient) can(perm string) bool {
+	return cl.role == "SUPER_ADMIN" || cl.perms["all"] || cl.perms[perm]
+}
+
+// canJoin gates topic subscriptions by the permissions resolved at upgrade time.
+func (cl *wsClient) canJoin(topic string) bool {
+	switch {
+	case topic == TopicAdminAlerts:
+		return cl.role != "VOTER"
+	case topic == TopicAuditFeed:
+		return cl.role == "SUPER_ADMIN"
+	case strings.HasPrefix(topic, "election:") && strings.HasSuffix(topic, ":turnout"):
+		return cl.role != "VOTER" && (cl.can("view_results") || cl.can("manage_elections"))
+	}
+	return false
+}
+
+func (cl *wsClient) stop() {
+	cl.quitOnce.Do(func() { close(cl.quit) })
+}
+
+// Hub tracks connected clients and their topic subscriptions. Each client has
+// its own buffered queue and writer goroutine, so publishing never blocks on I/O.
+type Hub struct {
+	mu       sync.RWMutex
+	clients  map[*wsClient]bool
+	watchers map[string]map[chan struct{}]bool
+	broker   pubsub.Broker
+}
+
+var hub = &Hub{clients: make(map[*wsClient]bool), watchers: make(map[string]map[chan struct{}]bool)}
+
+func (h *Hub) register(cl *wsClient) {
+	h.mu.Lock()
+	h.clients[cl] = true
+	h.mu.Unlock()
+}
+
+func (h *Hub) unregister(cl *wsClient) {
+	h.mu.Lock()
+	if _, ok := h.clients[cl]; ok {
+		delete(h.clients, cl)
+		cl.stop()
+	}
+	h.mu.Unlock()
+}
+
+// watch returns a channel signalled whenever a message on one of the topics
+// reaches this replica, and a function to stop watching. Signals coalesce:
+// a watcher that has not drained its channel is not signalled twice.
+func (h *Hub) watch(topics ...string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	for _, t := range topics {
+		if h.watchers[t] == nil {
+			h.watchers[t] = make(map[chan struct{}]bool)
+		}
+		h.watchers[t][ch] = true
+	}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		for _, t := range topics {
+			delete(h.watchers[t], ch)
+			if len(h.watchers[t]) == 0 {
+				delete(h.watchers, t)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *Hub) subscribe(cl *wsClient, topic string, on bool) {
+	h.mu.Lock()
+	if on {
+		cl.topics[topic] = true
+	} else {
+		delete(cl.topics, topic)
+	}
+	h.mu.Unlock()
+}
+
+// Publish sends a message through the realtime broker so that clients on
+// every replica receive it. Without a broker it is delivered locally.
+func (h *Hub) Publish(topic string, payload fiber.Map) {
+	payload["topic"] = topic
+	msg, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("WebSocket encode error: %v", err)
+		return
+	}
+
+	if h.broker != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := h.broker.Publish(ctx, topic, msg)
+		if err == nil {
+			return
+		}
+		log.Printf("Realtime broker publish failed, delivering locally: %v", err)
+	}
+	h.deliver(topic, msg)
+}
+
+// deliver queues a message for every local subscriber of the topic. Clients
+// whose queue is full are dropped instead of slowing down everyone else.
+func (h *Hub) deliver(topic string, msg []byte) {
+	var slow []*wsClient
+	h.mu.RLock()
+	for ch := range h.watchers[topic] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	for cl := range h.clients {
+		if !cl.topics[topic] {
+			continue
+		}
+		select {
+		case cl.send <- msg:
+		default:
+			slow = append(slow, cl)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, cl := range slow {
+		log.Printf("WebSocket client %d too slow, dropping", cl.userID)
+		h.unregister(cl)
+	}
+}
+
+// InitRealtime connects the hub to the cross-replica broker named in REALTIME_BROKER.
+func InitRealtime() {
+	var broker pubsub.Broker
+	switch config.Config.Realtime.Broker {
+	case "memory":
+		broker = pubsub.NewMemoryBroker()
+	default:
+		broker = pubsub.NewPostgresBroker(database.PostgresDSN(), database.PostgresDB)
+	}
+
+	broker.Subscribe(func(e pubsub.Event) {
+		hub.deliver(e.Topic, e.Payload)
+	})
+	hub.broker = broker
+	log.Printf(" Realtime broker: %s (instance %s)", config.Config.Realtime.Broker, pubsub.InstanceID)
+}
+
+// WebSocketUpgrade authenticates the JWT (query `token` or Authorization header)
+// before the connection is upgraded.
+func WebSocketUpgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	tokenStr := c.Query("token")
+	if tokenStr == "" {
+		tokenStr = strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+	}
+	if tokenStr == "" {
+		return utils.Error(c, 401, "Missing token")
+	}
+
+	claims, err := utils.ParseJWT(tokenStr)
+	if err != nil {
+		return utils.Error(c, 401, "Invalid token")
+	}
+
+	idFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return utils.Error(c, 401, "Invalid user ID in token")
+	}
+	role, _ := claims["role"].(string)
+
+	// Candidate portal tokens carry a candidate ID, which must never be
+	// looked up as an admin.
+	if role == "CANDIDATE" {
+		return utils.Error(c, 403, "Candidate portal tokens cannot open the realtime socket")
+	}
+
+	perms := ""
+	if role != "VOTER" && role != "SUPER_ADMIN" {
+		var admin models.Admin
+		if err := database.PostgresDB.Preload("Roles").First(&admin, uint(idFloat)).Error; err != nil {
+			return utils.Error(c, 401, "Admin account not found")
+		}
+		if !admin.IsActive {
+			return utils.Error(c, 403, "Account has been deactivated")
+		}
+		if admin.IsSuper {
+			role = "SUPER_ADMIN"
+		}
+		perms = getAggregatedPermissions(admin.Roles)
+	}
+
+	c.Locals("user_id", idFloat)
+	c.Locals("role", role)
+	c.Locals("permissions", perms)
+	return c.Next()
+}
+
+// WebSocket Handler for live updates
 func WebSocketHandler(c *websocket.Conn) {
-	// Register the client
-	clientsMux.Lock()
-	adminClients[c] = true
-	clientsMux.Unlock()
+	userID, _ := c.Locals("user_id").(float64)
+	role, _ := c.Locals("role").(string)
+	permStr, _ := c.Locals("permissions").(string)
+
+	cl := &wsClient{
+		conn:   c,
+		send:   make(chan []byte, wsSendBuffer),
+		quit:   make(chan struct{}),
+		userID: uint(userID),
+		role:   role,
+		perms:  make(map[string]bool),
+		topics: make(map[string]bool),
+	}
+	for _, p := range strings.Split(permStr, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			cl.perms[p] = true
+		}
+	}
+
+	// Admin dashboards receive alerts without having to ask for them.
+	if cl.canJoin(TopicAdminAlerts) {
+		cl.topics[TopicAdminAlerts] = true
+	}
 
-	log.Println("Admin connected to WebSocket")
+	hub.register(cl)
+	log.Printf("WebSocket client %d (%s) connected", cl.userID, cl.role)
+
+	done := make(chan struct{})
+	go cl.writePump(done)
 
 	defer func() {
-		// Unregister on close
-		clientsMux.Lock()
-		delete(adminClients, c)
-		clientsMux.Unlock()
-		c.Close()
-		log.Println("Admin disconnected from WebSocket")
+		hub.unregister(cl)
+		<-done
+		log.Printf("WebSocket client %d disconnected", cl.userID)
 	}()
 
+	cl.readPump()
+}
+
+func (cl *wsClient) readPump() {
+	cl.conn.SetReadLimit(wsMaxMessage)
+	_ = cl.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	cl.conn.SetPongHandler(func(string) error {
+		return cl.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
 	for {
-		// Keep the connection alive.
-		// If ReadMessage fails (connection closed), break the loop.
-		_, _, err := c.ReadMessage()
+		_, data, err := cl.conn.ReadMessage()
 		if err != nil {
-			break
+			return
+		}
+
+		var msg struct {
+			Action string `json:"action"`
+			Topic  string `json:"topic"`
 		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			cl.reply(fiber.Map{"type": "ERROR", "message": "Invalid message"})
+			continue
+		}
+
+		switch msg.Action {
+		case "subscribe":
+			if !cl.canJoin(msg.Topic) {
+				cl.reply(fiber.Map{"type": "ERROR", "message": "Not allowed to join " + msg.Topic})
+				continue
+			}
+			hub.subscribe(cl, msg.Topic, true)
+			cl.reply(fiber.Map{"type": "SUBSCRIBED", "topic": msg.Topic})
+		case "unsubscribe":
+			hub.subscribe(cl, msg.Topic, false)
+			cl.reply(fiber.Map{"type": "UNSUBSCRIBED", "topic": msg.Topic})
+		case "ping":
+			cl.reply(fiber.Map{"type": "PONG"})
+		default:
+			cl.reply(fiber.Map{"type": "ERROR", "message": "Unknown action"})
+		}
+	}
+}
+
+func (cl *wsClient) reply(payload interface{}) {
+	msg, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	select {
+	case cl.send <- msg:
+	case <-cl.quit:
+	default:
 	}
 }
 
-// Broadcast function to notify all connected admins
-func BroadcastVoteUpdate(electionTitle string) {
-	clientsMux.Lock()
-	defer clientsMux.Unlock()
+func (cl *wsClient) writePump(done chan struct{}) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		cl.conn.Close()
+		close(done)
+	}()
+
+	for {
+		select {
+		case msg := <-cl.send:
+			_ = cl.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := cl.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-cl.quit:
+			_ = cl.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			_ = cl.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
+		case <-ticker.C:
+			_ = cl.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := cl.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
 
-	message := map[string]string{
+// Broadcast function to notify connected admins of a new vote
+func BroadcastVoteUpdate(electionID uint, electionTitle string) {
+	hub.Publish(TopicAdminAlerts, fiber.Map{
 		"type":     "VOTE_CAST",
 		"message":  "New vote received",
 		"election": electionTitle,
-	}
+	})
 
-	for client := range adminClients {
-		if err := client.WriteJSON(message); err != nil {
-			log.Printf("WebSocket write error: %v", err)
-			client.Close()
-			delete(adminClients, client)
-		}
+	var tally models.ElectionTally
+	database.PostgresDB.Where("election_id = ?", electionID).First(&tally)
+	hub.Publish(TurnoutTopic(electionID), fiber.Map{
+		"type":        "TURNOUT",
+		"election_id": electionID,
+		"votes_cast":  tally.TotalVotes,
+	})
+}
+
+func init() {
+	service.OnAuditLogged = func(entry models.AuditLog) {
+		hub.Publish(TopicAuditFeed, fiber.Map{"type": "AUDIT_LOG", "entry": entry})
 	}
 }
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type BallotQuestionRequest struct {
+	Position         int      `json:"position"`
+	Text             string   `json:"text"`
+	Kind             string   `json:"kind"`
+	MaxChoices       int      `json:"max_choices"`
+	QuorumPercent    float64  `json:"quorum_percent"`
+	ThresholdPercent float64  `json:"threshold_percent"`
+	Options          []string `json:"options"` // MULTIPLE_CHOICE only; YES_NO gets Yes/No
+}
+
+func ListBallotQuestions(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	questions, err := service.LoadQuestions(uint(id))
+	if err != nil {
+		return utils.Error(c, 500, "Failed to fetch questions")
+	}
+	return utils.Success(c, questions)
+}
+
+func CreateBallotQuestion(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	var req BallotQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	if err := service.CheckQuestionsEditable(uint(id)); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	q := models.BallotQuestion{
+		ElectionID:       uint(id),
+		Position:         req.Position,
+		Text:             req.Text,
+		Kind:             req.Kind,
+		MaxChoices:       req.MaxChoices,
+		QuorumPercent:    req.QuorumPercent,
+		ThresholdPercent: req.ThresholdPercent,
+	}
+	if err := service.NormalizeQuestion(&q, req.Options); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if q.Position == 0 {
+		var count int64
+		database.PostgresDB.Model(&models.BallotQuestion{}).Where("election_id = ?", q.ElectionID).Count(&count)
+		q.Position = int(count) + 1
+	}
+
+	if err := database.PostgresDB.Create(&q).Error; err != nil {
+		return utils.Error(c, 500, "Failed to create question")
+	}
+
+	logAdminAction(c, "CREATE_BALLOT_QUESTION", q.ID, map[string]interface{}{
+		"election_id": q.ElectionID,
+		"kind":        q.Kind,
+		"text":        q.Text,
+	})
+	return utils.Success(c, q)
+}
+
+func UpdateBallotQuestion(c *fiber.Ctx) error {
+	var q models.BallotQuestion
+	if err := database.PostgresDB.First(&q, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Question not found")
+	}
+
+	var req BallotQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	if err := service.CheckQuestionsEditable(q.ElectionID); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	q.Text = req.Text
+	q.Kind = req.Kind
+	q.MaxChoices = req.MaxChoices
+	q.QuorumPercent = req.QuorumPercent
+	q.ThresholdPercent = req.ThresholdPercent
+	if req.Position > 0 {
+		q.Position = req.Position
+	}
+	if err := service.NormalizeQuestion(&q, req.Options); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	// Options are replaced wholesale; no ballot references them yet.
+	err := database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("question_id = ?", q.ID).Delete(&models.BallotOption{}).Error; err != nil {
+			return err
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&q).Error
+	})
+	if err != nil {
+		return utils.Error(c, 500, "Failed to update question")
+	}
+
+	logAdminAction(c, "UPDATE_BALLOT_QUESTION", q.ID, map[string]interface{}{
+		"election_id": q.ElectionID,
+		"kind":        q.Kind,
+		"text":        q.Text,
+	})
+	return utils.Success(c, q)
+}
+
+func DeleteBallotQuestion(c *fiber.Ctx) error {
+	var q models.BallotQuestion
+	if err := database.PostgresDB.First(&q, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Question not found")
+	}
+
+	if err := service.CheckQuestionsEditable(q.ElectionID); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	err := database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("question_id = ?", q.ID).Delete(&models.BallotOption{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&q).Error
+	})
+	if err != nil {
+		return utils.Error(c, 500, "Failed to delete question")
+	}
+
+	logAdminAction(c, "DELETE_BALLOT_QUESTION", q.ID, map[string]interface{}{"election_id": q.ElectionID})
+	return utils.Success(c, "Question deleted")
+}
+
+// GetVoterQuestions is the referendum counterpart of GetVoterCandidates.
+func GetVoterQuestions(c *fiber.Ctx) error {
+	voterIDFloat, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return utils.Error(c, 401, "Unauthorized")
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	var participationCount int64
+	database.PostgresDB.Model(&models.ElectionParticipation{}).
+		Where("voter_id = ? AND election_id = ?", uint(voterIDFloat), id).
+		Count(&participationCount)
+	if participationCount > 0 {
+		return utils.Error(c, 403, "Access Denied: You have already voted in this election.")
+	}
+
+	questions, err := service.LoadQuestions(uint(id))
+	if err != nil {
+		return utils.Error(c, 500, "Failed to fetch questions")
+	}
+	return utils.Success(c, questions)
+}
+
+// GetReferendumResult reports the live per-question count to staff.
+func GetReferendumResult(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	result, err := service.ComputeReferendumResult(uint(id))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, result)
+}
+
+func GetPublicReferendumResult(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	var election models.Election
+	if err := database.PostgresDB.First(&election, id).Error; err != nil {
+		return utils.Error(c, 404, "Election not found")
+	}
+	if !election.IsPublished && !canViewUnpublishedResults(c) {
+		return utils.Error(c, 403, "Results have not been published yet.")
+	}
+
+	result, err := service.ComputeReferendumResult(election.ID)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, result)
+}
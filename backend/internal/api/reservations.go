@@ -0,0 +1,59 @@
+package api
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImportReservations loads the ward reservation rotation of a delimitation cycle from CSV.
+func ImportReservations(c *fiber.Ctx) error {
+	cycle := c.FormValue("cycle")
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.Error(c, 400, "CSV file is required")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return utils.Error(c, 500, "Failed to open file")
+	}
+	defer f.Close()
+
+	imported, skipped, err := service.ImportWardReservations(cycle, f)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	logAdminAction(c, "IMPORT_RESERVATIONS", 0, map[string]interface{}{
+		"cycle":    cycle,
+		"imported": imported,
+		"skipped":  len(skipped),
+	})
+	return utils.Success(c, fiber.Map{
+		"imported": imported,
+		"skipped":  skipped,
+	})
+}
+
+func ListReservations(c *fiber.Ctx) error {
+	query := database.PostgresDB.Model(&models.WardReservation{})
+	if cycle := c.Query("cycle"); cycle != "" {
+		query = query.Where("cycle = ?", cycle)
+	}
+	if district := c.Query("district"); district != "" {
+		query = query.Where("district = ?", district)
+	}
+	if name := c.Query("local_body_name"); name != "" {
+		query = query.Where("local_body_name = ?", name)
+	}
+
+	var rows []models.WardReservation
+	if err := query.Order("cycle desc, district, local_body_name, ward").Find(&rows).Error; err != nil {
+		return utils.Error(c, 500, "Failed to fetch reservations")
+	}
+	return utils.Success(c, rows)
+}
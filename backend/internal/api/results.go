@@ -3,7 +3,12 @@ package api
 import (
 	"E-voting/internal/database"
 	"E-voting/internal/models"
+	"E-voting/internal/service"
 	"E-voting/internal/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -22,27 +27,47 @@ func hasViewResultsPermission(userID uint) bool {
 	return false
 }
 
-func GetElectionResults(c *fiber.Ctx) error {
-	electionID := c.QueryInt("election_id")
-
+func canViewUnpublishedResults(c *fiber.Ctx) bool {
 	userIDFloat := c.Locals("user_id")
 	userRole, _ := c.Locals("role").(string)
 
-	canViewUnpublished := false
 	if userRole == "SUPER_ADMIN" {
-		canViewUnpublished = true
-	} else if userIDFloat != nil {
-		if hasViewResultsPermission(uint(userIDFloat.(float64))) {
-			canViewUnpublished = true
-		}
+		return true
+	}
+	if userRole != "VOTER" && userIDFloat != nil {
+		return hasViewResultsPermission(uint(userIDFloat.(float64)))
 	}
+	return false
+}
 
+func GetElectionResults(c *fiber.Ctx) error {
+	electionID := c.QueryInt("election_id")
+
+	canViewUnpublished := canViewUnpublishedResults(c)
+	if electionID < 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	// Only published results are cached. The stamp is read from the database
+	// and moves when an election is (un)published, so a replica that missed
+	// the change never serves a stale or withdrawn result.
+	cacheKey := fmt.Sprintf("results:%d", electionID)
+	stamp := ""
 	if electionID > 0 {
-		var election models.Election
-		if err := database.PostgresDB.First(&election, electionID).Error; err == nil {
-			if !canViewUnpublished && !election.IsPublished {
-				return utils.Error(c, 403, "Results have not been published yet.")
-			}
+		var published, found bool
+		stamp, published, found = service.ElectionResultsStamp(uint(electionID))
+		if !found {
+			return utils.Error(c, 404, "Election not found")
+		}
+		if !canViewUnpublished && !published {
+			return utils.Error(c, 403, "Results have not been published yet.")
+		}
+	} else {
+		stamp = service.ResultsStamp()
+	}
+	if !canViewUnpublished {
+		if body, etag, maxAge, ok := service.GetCachedResults(cacheKey, stamp); ok {
+			return utils.SendWithETag(c, body, etag, fiber.MIMEApplicationJSON, maxAge)
 		}
 	}
 
@@ -51,10 +76,21 @@ func GetElectionResults(c *fiber.Ctx) error {
 		ElectionID          uint   `json:"election_id"`
 		ElectionTitle       string `json:"election_title"`
 		ElectionDescription string `json:"election_description"`
+		Reservation         string `json:"reservation"`
+		CandidateID         uint   `json:"candidate_id"`
 		CandidateName       string `json:"candidate_name"`
 		PartyName           string `json:"party_name"`
+		Independent         bool   `json:"independent"`
+		Alliance            string `json:"alliance"`
+		SerialNo            int    `json:"serial_no"`
+		Symbol              string `json:"symbol"`
 		VoteCount           int64  `json:"vote_count"`
+		Weighted            bool   `json:"weighted"`
+		WeightedVotes       int64  `json:"weighted_votes"`
 		PartyLogo           string `json:"party_logo"`
+		ResultStatus        string `json:"result_status"`
+		IsElected           bool   `json:"is_elected"`
+		IsNota              bool   `json:"is_nota"`
 	}
 
 	var results []Result
@@ -64,15 +100,29 @@ func GetElectionResults(c *fiber.Ctx) error {
 			candidates.election_id, 
 			elections.title as election_title, 
 			elections.description as election_description,
+			elections.reservation,
+			candidates.id as candidate_id,
 			candidates.full_name as candidate_name, 
 			COALESCE(parties.name, 'Independent') as party_name, 
+			candidates.party_id IS NULL as independent,
+			COALESCE(alliances.name, '') as alliance,
+			candidates.serial_no,
+			candidates.symbol,
 			COALESCE(parties.logo, '') as party_logo, 
-			COALESCE(COUNT(votes.id), 0) as vote_count
+			COALESCE(candidate_tallies.vote_count, 0) as vote_count,
+			elections.weighted,
+			COALESCE(candidate_tallies.weighted_count, 0) as weighted_votes,
+			COALESCE(election_results.status, 'PROVISIONAL') as result_status,
+			COALESCE(election_result_entries.is_elected, false) as is_elected
 		`).
 		Joins("LEFT JOIN parties ON parties.id = candidates.party_id").
 		Joins("JOIN elections ON elections.id = candidates.election_id").
-		Joins("LEFT JOIN votes ON votes.candidate_id = candidates.id AND votes.election_id = candidates.election_id")
+		Joins(service.AllianceJoin).
+		Joins("LEFT JOIN candidate_tallies ON candidate_tallies.candidate_id = candidates.id AND candidate_tallies.election_id = candidates.election_id").
+		Joins("LEFT JOIN election_results ON election_results.election_id = candidates.election_id").
+		Joins("LEFT JOIN election_result_entries ON election_result_entries.result_id = election_results.id AND election_result_entries.candidate_id = candidates.id")
 
+	query = query.Where("candidates.nomination_status = ?", service.NominationFinal)
 	if electionID > 0 {
 		query = query.Where("candidates.election_id = ?", electionID)
 	}
@@ -81,21 +131,145 @@ func GetElectionResults(c *fiber.Ctx) error {
 		query = query.Where("elections.is_published = ?", true)
 	}
 
-	err := query.Group(`
-			candidates.id, 
-			candidates.election_id, 
-			elections.title, 
-			elections.description, 
-			candidates.full_name, 
-			parties.name, 
-			parties.logo
-		`).
-		Order("candidates.election_id DESC, vote_count DESC").
+	err := query.
+		Order("candidates.election_id DESC").
+		Order("candidates.serial_no ASC").
+		Order("CASE WHEN elections.weighted THEN COALESCE(candidate_tallies.weighted_count, 0) ELSE COALESCE(candidate_tallies.vote_count, 0) END DESC").
 		Scan(&results).Error
 
 	if err != nil {
 		return utils.Error(c, 500, "Failed to calculate results")
 	}
 
-	return utils.Success(c, results)
+	// NOTA is listed after the candidates of its election and is never elected
+	var nota []Result
+	notaQuery := database.PostgresDB.Table("candidate_tallies").
+		Select(`
+			candidate_tallies.election_id,
+			elections.title as election_title,
+			elections.description as election_description,
+			elections.reservation,
+			candidate_tallies.candidate_id,
+			? as candidate_name,
+			'NOTA' as party_name,
+			candidate_tallies.vote_count,
+			elections.weighted,
+			candidate_tallies.weighted_count as weighted_votes,
+			COALESCE(election_results.status, 'PROVISIONAL') as result_status,
+			true as is_nota
+		`, service.NotaLabel).
+		Joins("JOIN elections ON elections.id = candidate_tallies.election_id").
+		Joins("LEFT JOIN election_results ON election_results.election_id = candidate_tallies.election_id").
+		Where("candidate_tallies.candidate_id = ?", service.NotaCandidateID)
+	if electionID > 0 {
+		notaQuery = notaQuery.Where("candidate_tallies.election_id = ?", electionID)
+	}
+	if !canViewUnpublished {
+		notaQuery = notaQuery.Where("elections.is_published = ?", true)
+	}
+	if err := notaQuery.Scan(&nota).Error; err != nil {
+		return utils.Error(c, 500, "Failed to calculate results")
+	}
+	if len(nota) > 0 {
+		results = append(results, nota...)
+		sort.SliceStable(results, func(i, j int) bool { return results[i].ElectionID > results[j].ElectionID })
+	}
+
+	if canViewUnpublished {
+		return utils.Success(c, results)
+	}
+
+	body, err := json.Marshal(fiber.Map{"success": true, "data": results})
+	if err != nil {
+		return utils.Error(c, 500, "Failed to encode results")
+	}
+	etag, maxAge := service.StoreCachedResults(cacheKey, stamp, body)
+	return utils.SendWithETag(c, body, etag, fiber.MIMEApplicationJSON, maxAge)
+}
+
+// GetElectionOutcome computes the live outcome (winners, margin, ties) without storing it.
+func GetElectionOutcome(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	result, err := service.ComputeElectionResult(uint(id))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, result)
+}
+
+func DeclareElectionResult(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	actorID := uint(c.Locals("user_id").(float64))
+	actorRole := c.Locals("role").(string)
+
+	result, err := service.DeclareElectionResult(uint(id), actorID, actorRole)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	service.InvalidateResultsCache()
+	if err := service.RefreshFeedRevision(uint(id)); err != nil {
+		log.Printf("Failed to refresh feed revision of election %d: %v", id, err)
+	}
+	hub.Publish(StreamTopic(uint(id)), fiber.Map{"type": "RESULT_DECLARED"})
+	return utils.Success(c, result)
+}
+
+func RecordTieBreak(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	var req struct {
+		Method             string `json:"method"`
+		WinnerCandidateIDs []uint `json:"winner_candidate_ids"`
+		Notes              string `json:"notes"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request")
+	}
+
+	actorID := uint(c.Locals("user_id").(float64))
+	actorRole := c.Locals("role").(string)
+
+	tb, err := service.RecordTieBreak(uint(id), req.Method, req.WinnerCandidateIDs, req.Notes, actorID, actorRole)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	service.InvalidateResultsCache()
+	if err := service.RefreshFeedRevision(uint(id)); err != nil {
+		log.Printf("Failed to refresh feed revision of election %d: %v", id, err)
+	}
+	hub.Publish(StreamTopic(uint(id)), fiber.Map{"type": "TIE_BREAK"})
+	return utils.Success(c, tb)
+}
+
+// GetPublicElectionOutcome serves only declared outcomes of published elections.
+func GetPublicElectionOutcome(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	var election models.Election
+	if err := database.PostgresDB.First(&election, id).Error; err != nil {
+		return utils.Error(c, 404, "Election not found")
+	}
+	if !election.IsPublished && !canViewUnpublishedResults(c) {
+		return utils.Error(c, 403, "Results have not been published yet.")
+	}
+
+	result, err := service.GetDeclaredResult(election.ID)
+	if err != nil {
+		return utils.Error(c, 404, "Result has not been declared yet")
+	}
+	return utils.Success(c, result)
 }
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetResultRollups aggregates ward results to local body, block or district level.
+func GetResultRollups(c *fiber.Ctx) error {
+	filter := service.RollupFilter{
+		ElectionType:       c.Query("election_type"),
+		District:           c.Query("district"),
+		Block:              c.Query("block"),
+		LocalBodyName:      c.Query("local_body_name"),
+		Year:               c.QueryInt("year"),
+		IncludeUnpublished: canViewUnpublishedResults(c),
+	}
+
+	rollups, err := service.BuildRollups(c.Params("level"), filter)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	return utils.Success(c, rollups)
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"E-voting/internal/database"
 	"E-voting/internal/middleware"
+	"E-voting/internal/models"
 	"E-voting/internal/service"
 	"E-voting/internal/utils"
 
@@ -15,14 +17,7 @@ func RegisterRoutes(app *fiber.App) {
 		return utils.Success(c, service.HealthCheck())
 	})
 
-	app.Use("/ws", func(c *fiber.Ctx) error {
-		if websocket.IsWebSocketUpgrade(c) {
-			c.Locals("allowed", true)
-			return c.Next()
-		}
-		return fiber.ErrUpgradeRequired
-
-	})
+	app.Use("/ws", WebSocketUpgrade)
 
 	app.Get("/ws/notifications", websocket.New(WebSocketHandler))
 
@@ -30,8 +25,23 @@ func RegisterRoutes(app *fiber.App) {
 	public := app.Group("/api/public")
 	public.Get("/elections", GetPublishedElections)
 	public.Get("/results", GetElectionResults)
+	public.Get("/elections/:id/outcome", GetPublicElectionOutcome)
+	public.Get("/elections/:id/referendum", GetPublicReferendumResult)
+	public.Get("/rollups/:level", GetResultRollups)
+	public.Get("/alliances", ListAlliances)
+	public.Get("/candidates/:id", GetPublicCandidate)
+	public.Get("/candidates/:id/affidavit", GetPublicAffidavit)
+	public.Get("/elections/:id/candidates", GetPublicElectionCandidates)
+	public.Get("/elections/:id/expenditure", GetPublicExpenditure)
+	public.Get("/elections/:id/stream", StreamElection)
 	public.Get("/check-status/:voterId", CheckVoterStatus)
 
+	// Media results feed (versioned, see docs/media-feed.md)
+	feed := public.Group("/feed/v1")
+	feed.Get("/manifest", GetFeedManifest)
+	feed.Get("/elections/:id", GetElectionFeed)
+	feed.Get("/local-bodies", GetLocalBodyFeed)
+
 	// --- API ROUTES ---
 
 	// 1. Auth Routes (Public)
@@ -41,13 +51,30 @@ func RegisterRoutes(app *fiber.App) {
 	auth.Post("/voter/login", VoterLogin)
 	auth.Post("/voter/verify-otp", VerifyOTP)
 	auth.Post("/voter/register", RegisterVoter)
+	auth.Post("/candidate/login", CandidateLogin)
+	auth.Post("/candidate/verify-otp", VerifyCandidateOTP)
 
 	// Voter App Routes
 	voterApp := app.Group("/api/voter", middleware.PermissionMiddleware(""))
 	voterApp.Get("/elections", GetVoterElections)
 	voterApp.Get("/elections/:id/candidates", GetVoterCandidates)
+	voterApp.Get("/elections/:id/questions", GetVoterQuestions)
 	voterApp.Post("/vote", CastVote)
 
+	// Candidate portal (candidates and their agents, scoped to their own record)
+	candidatePortal := app.Group("/api/candidate", middleware.PermissionMiddleware("CANDIDATE"))
+	candidatePortal.Get("/me", GetCandidateProfile)
+	candidatePortal.Get("/documents", ListCandidateDocuments)
+	candidatePortal.Post("/documents", UploadCandidateDocument)
+	candidatePortal.Get("/documents/:docId/file", DownloadOwnDocument)
+	candidatePortal.Get("/result", GetCandidateCertifiedResult)
+	candidatePortal.Get("/disclosure", GetOwnDisclosure)
+	candidatePortal.Put("/disclosure", FileOwnDisclosure)
+	candidatePortal.Get("/expenditure", GetOwnExpenditure)
+	candidatePortal.Post("/expenditure/items", AddOwnExpenditureItem)
+	candidatePortal.Delete("/expenditure/items/:itemId", RemoveOwnExpenditureItem)
+	candidatePortal.Post("/expenditure/file", FileOwnExpenditure)
+
 	common := app.Group("/api/common")
 	common.Get("/kerala-data", GetReferenceData)
 
@@ -63,9 +90,12 @@ func RegisterRoutes(app *fiber.App) {
 	adminAPI.Get("/elections", ListElections) // Viewing elections is open to all staff
 	adminAPI.Get("/config", GetSystemSettings)
 	adminAPI.Get("/election-results", GetElectionResults)
+	adminAPI.Get("/rollups/:level", GetResultRollups)
+	adminAPI.Get("/analytics/turnout/:id", middleware.PermissionMiddleware("view_results"), GetTurnoutAnalytics)
 
 	adminAPI.Post("/maintenance/sync-elections", ManualSyncElections)
 	adminAPI.Post("/maintenance/retry-votes", ManualRetryVotes)
+	adminAPI.Post("/maintenance/rebuild-tallies", middleware.PermissionMiddleware("SUPER_ADMIN"), ManualRebuildTallies)
 
 	// --- SPECIFIC PERMISSIONS APPLIED PER ROUTE ---
 
@@ -91,11 +121,41 @@ func RegisterRoutes(app *fiber.App) {
 	adminAPI.Put("/parties/:id", middleware.PermissionMiddleware("manage_parties"), UpdateParty)
 	adminAPI.Delete("/parties/:id", middleware.PermissionMiddleware("manage_parties"), DeleteParty)
 
+	// Alliances / fronts (manage_parties)
+	adminAPI.Get("/alliances", middleware.PermissionMiddleware("manage_parties"), ListAlliances)
+	adminAPI.Post("/alliances", middleware.PermissionMiddleware("manage_parties"), CreateAlliance)
+	adminAPI.Put("/alliances/:id", middleware.PermissionMiddleware("manage_parties"), UpdateAlliance)
+	adminAPI.Post("/alliances/:id/members", middleware.PermissionMiddleware("manage_parties"), AddAllianceMember)
+	adminAPI.Post("/alliance-members/:id/end", middleware.PermissionMiddleware("manage_parties"), EndAllianceMembership)
+
 	// Candidates (manage_candidates)
 	adminAPI.Post("/candidates", middleware.PermissionMiddleware("manage_candidates"), CreateCandidate)
 	adminAPI.Get("/candidates", middleware.PermissionMiddleware("manage_candidates"), ListCandidates)
 	adminAPI.Put("/candidates/:id", middleware.PermissionMiddleware("manage_candidates"), UpdateCandidate)
 	adminAPI.Delete("/candidates/:id", middleware.PermissionMiddleware("manage_candidates"), DeleteCandidate)
+	adminAPI.Put("/candidates/:id/nomination", middleware.PermissionMiddleware("manage_candidates"), UpdateNominationStatus)
+	adminAPI.Put("/candidates/:id/symbol", middleware.PermissionMiddleware("manage_candidates"), AllotCandidateSymbol)
+	adminAPI.Get("/symbols", middleware.PermissionMiddleware("manage_candidates"), ListSymbols)
+	adminAPI.Post("/symbols", middleware.PermissionMiddleware("manage_candidates"), CreateSymbol)
+	adminAPI.Delete("/symbols/:id", middleware.PermissionMiddleware("manage_candidates"), DeleteSymbol)
+	adminAPI.Get("/candidates/:id/disclosure", middleware.PermissionMiddleware("manage_candidates"), GetCandidateDisclosure)
+	adminAPI.Put("/candidates/:id/disclosure", middleware.PermissionMiddleware("manage_candidates"), FileCandidateDisclosure)
+	adminAPI.Put("/candidates/:id/disclosure/verify", middleware.PermissionMiddleware("manage_candidates"), VerifyCandidateDisclosure)
+	adminAPI.Get("/candidates/:id/expenditure", middleware.PermissionMiddleware("manage_candidates"), GetCandidateExpenditure)
+	adminAPI.Post("/candidates/:id/expenditure/items", middleware.PermissionMiddleware("manage_candidates"), AddCandidateExpenditureItem)
+	adminAPI.Delete("/candidates/:id/expenditure/items/:itemId", middleware.PermissionMiddleware("manage_candidates"), RemoveCandidateExpenditureItem)
+	adminAPI.Post("/candidates/:id/expenditure/file", middleware.PermissionMiddleware("manage_candidates"), FileCandidateExpenditure)
+	adminAPI.Get("/expenditure-limits", middleware.PermissionMiddleware("manage_candidates"), ListExpenditureLimits)
+	adminAPI.Put("/expenditure-limits", middleware.PermissionMiddleware("manage_candidates"), SetExpenditureLimit)
+	adminAPI.Get("/elections/:id/expenditure", middleware.PermissionMiddleware("manage_candidates"), GetExpenditureReport)
+	adminAPI.Get("/expenditure/flags", middleware.PermissionMiddleware("manage_candidates"), GetExpenditureFlags)
+	adminAPI.Get("/nomination-matches", middleware.PermissionMiddleware("manage_candidates"), ListNominationMatches)
+	adminAPI.Put("/nomination-matches/:id/review", middleware.PermissionMiddleware("manage_candidates"), ReviewNominationMatch)
+	adminAPI.Post("/elections/:id/nomination-matches/scan", middleware.PermissionMiddleware("manage_candidates"), ScanNominationMatches)
+	adminAPI.Get("/candidate-documents", middleware.PermissionMiddleware("manage_candidates"), ListPendingCandidateDocuments)
+	adminAPI.Get("/candidate-documents/:id/file", middleware.PermissionMiddleware("manage_candidates"), DownloadCandidateDocument)
+	adminAPI.Put("/candidate-documents/:id/review", middleware.PermissionMiddleware("manage_candidates"), ReviewCandidateDocument)
+	adminAPI.Post("/elections/:id/nominations/finalize", middleware.PermissionMiddleware("manage_candidates"), FinalizeNominationList)
 
 	// Elections (manage_elections)
 	adminAPI.Post("/elections", middleware.PermissionMiddleware("manage_elections"), CreateElection)
@@ -103,6 +163,29 @@ func RegisterRoutes(app *fiber.App) {
 	adminAPI.Delete("/elections/:id", middleware.PermissionMiddleware("manage_elections"), DeleteElection)
 	adminAPI.Post("/elections/status", middleware.PermissionMiddleware("manage_elections"), ToggleElectionStatus)
 	adminAPI.Post("/elections/publish", middleware.PermissionMiddleware("manage_elections"), ToggleElectionPublish)
+	adminAPI.Post("/elections/:id/roll/finalize", middleware.PermissionMiddleware("manage_elections"), FinalizeElectionRoll)
+
+	// Ward reservation rotation (per delimitation cycle)
+	adminAPI.Get("/reservations", middleware.PermissionMiddleware("manage_elections"), ListReservations)
+	adminAPI.Post("/reservations/import", middleware.PermissionMiddleware("manage_elections"), ImportReservations)
+
+	// Indirect elections (president / chairperson)
+	adminAPI.Get("/elections/:id/electorate", middleware.PermissionMiddleware("manage_elections"), GetElectorate)
+	adminAPI.Post("/elections/:id/electorate", middleware.PermissionMiddleware("manage_elections"), BuildElectorate)
+	adminAPI.Post("/elections/:id/second-round", middleware.PermissionMiddleware("manage_elections"), StartSecondRound)
+	adminAPI.Get("/elections/:id/open-ballots", middleware.PermissionMiddleware("view_results"), GetOpenBallots)
+
+	// Referendum questions (manage_elections)
+	adminAPI.Get("/elections/:id/questions", middleware.PermissionMiddleware("manage_elections"), ListBallotQuestions)
+	adminAPI.Post("/elections/:id/questions", middleware.PermissionMiddleware("manage_elections"), CreateBallotQuestion)
+	adminAPI.Put("/questions/:id", middleware.PermissionMiddleware("manage_elections"), UpdateBallotQuestion)
+	adminAPI.Delete("/questions/:id", middleware.PermissionMiddleware("manage_elections"), DeleteBallotQuestion)
+
+	// Results & Tie Resolution
+	adminAPI.Get("/elections/:id/outcome", middleware.PermissionMiddleware("view_results"), GetElectionOutcome)
+	adminAPI.Get("/elections/:id/referendum", middleware.PermissionMiddleware("view_results"), GetReferendumResult)
+	adminAPI.Post("/elections/:id/tie-break", middleware.PermissionMiddleware("manage_elections"), RecordTieBreak)
+	adminAPI.Post("/elections/:id/declare", middleware.PermissionMiddleware("manage_elections"), DeclareElectionResult)
 
 	// Staff & Role Management (manage_admins)
 	// These use a different prefix (/api/auth/admin), so they were likely fine, but good to be safe.
@@ -122,6 +205,16 @@ func RegisterRoutes(app *fiber.App) {
 	superAdminLegacy.Post("/unblock", UnblockSubAdmin)
 	superAdminLegacy.Post("/update-role", UpdateAdminRoleHandler)
 
+	// Outbound Webhooks (SUPER_ADMIN)
+	webhooks := app.Group("/api/admin/webhooks", middleware.PermissionMiddleware("SUPER_ADMIN"))
+	webhooks.Get("/", ListWebhooks)
+	webhooks.Post("/", CreateWebhook)
+	webhooks.Put("/:id", UpdateWebhook)
+	webhooks.Delete("/:id", DeleteWebhook)
+	webhooks.Post("/:id/ping", PingWebhook)
+	webhooks.Get("/:id/deliveries", ListWebhookDeliveries)
+	webhooks.Post("/deliveries/:id/redeliver", RedeliverWebhook)
+
 	// Audit Logs (SUPER_ADMIN)
 	app.Get("/api/audit/logs", middleware.PermissionMiddleware("SUPER_ADMIN"), GetAuditLogs)
 	app.Post("/api/admin/config", middleware.PermissionMiddleware("SUPER_ADMIN"), UpdateSystemSettings)
@@ -137,12 +230,23 @@ func RegisterRoutes(app *fiber.App) {
 			return utils.Error(c, 500, "Blockchain read error")
 		}
 
-		return utils.Success(c, fiber.Map{
+		resp := fiber.Map{
 			"source":              "Ethereum Blockchain",
 			"election_id":         elecID,
 			"candidate_id":        candID,
 			"verified_vote_count": count,
-		})
+		}
+		// Weighted ballots are anchored once each with their weight, so the
+		// chain holds both the ballot count and the weighted count.
+		var election models.Election
+		if err := database.PostgresDB.Select("weighted").First(&election, elecID).Error; err == nil && election.Weighted {
+			weighted, err := service.GetWeightedVotesFromChain(uint(elecID), uint(candID))
+			if err != nil {
+				return utils.Error(c, 500, "Blockchain read error")
+			}
+			resp["verified_weighted_count"] = weighted
+		}
+		return utils.Success(c, resp)
 	})
 
 	bc.Get("/tx/:hash", func(c *fiber.Ctx) error {
@@ -16,6 +16,12 @@ func InitializeDefaults() {
 		{Key: "support_email", Value: "support@evoting.com", Description: "Contact email for voters", Type: "text", Category: "General"},
 		{Key: "allow_voter_registration", Value: "true", Description: "Allow new voters to register", Type: "boolean", Category: "Features"},
 		{Key: "maintenance_mode", Value: "false", Description: "Enable maintenance mode (voters cannot login)", Type: "boolean", Category: "System"},
+		{Key: "turnout_min_cell_size", Value: "10", Description: "Smallest turnout count reported per hour or area", Type: "number", Category: "Security"},
+		{Key: "webhook_max_attempts", Value: "6", Description: "Delivery attempts before a webhook is marked failed", Type: "number", Category: "System"},
+		{Key: "max_vote_weight", Value: "100", Description: "Highest vote weight a voter can hold in a weighted election", Type: "number", Category: "Features"},
+		{Key: "candidate_portal_enabled", Value: "true", Description: "Allow candidates and their agents to sign in to the candidate portal", Type: "boolean", Category: "Features"},
+		{Key: "expenditure_filing_days", Value: "30", Description: "Days after the declaration of results within which candidates file expenditure accounts", Type: "number", Category: "Features"},
+		{Key: "results_cache_ttl", Value: "5", Description: "Seconds a public results response may be served from cache", Type: "number", Category: "System"},
 
 		{
 			Key:         "otp_validity_duration",
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	sseTick      = 2 * time.Second
+	sseHeartbeat = 15 * time.Second
+	sseMaxLife   = 30 * time.Minute
+)
+
+// streamState is encoded as the SSE event ID ("<turnout>.<results>") so a
+// reconnecting client only receives what changed since its Last-Event-ID.
+type streamState struct {
+	Turnout int64
+	Results int64
+	Status  string
+}
+
+func (s streamState) id() string {
+	return fmt.Sprintf("%d.%d", s.Turnout, s.Results)
+}
+
+func parseStreamState(lastID string) streamState {
+	var s streamState
+	parts := strings.SplitN(lastID, ".", 2)
+	if len(parts) == 2 {
+		s.Turnout, _ = strconv.ParseInt(parts[0], 10, 64)
+		s.Results, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+	return s
+}
+
+// StreamElection pushes live turnout while polling is open and the result once
+// the election is published, as Server-Sent Events.
+func StreamElection(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid election ID")
+	}
+
+	var election models.Election
+	if err := database.PostgresDB.First(&election, id).Error; err != nil {
+		return utils.Error(c, 404, "Election not found")
+	}
+
+	canViewUnpublished := canViewUnpublishedResults(c)
+	last := parseStreamState(c.Get("Last-Event-ID"))
+
+	var eligible int64
+	service.EligibleVoters(database.PostgresDB.Model(&models.Voter{}), election).
+		Where("voters.is_verified = ? AND voters.is_blocked = ?", true, false).
+		Count(&eligible)
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	electionID := election.ID
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The stream only reads the database when the hub reports a vote, a
+		// state change or a result for this election, or when polling opens or
+		// closes on the clock.
+		wake, stop := hub.watch(TurnoutTopic(electionID), StreamTopic(electionID))
+		defer stop()
+
+		fmt.Fprintf(w, "retry: %d\n\n", 3000)
+		if w.Flush() != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(sseHeartbeat)
+		defer heartbeat.Stop()
+		expire := time.NewTimer(sseMaxLife)
+		defer expire.Stop()
+		boundary := time.NewTimer(sseMaxLife)
+		defer boundary.Stop()
+		var lastCheck time.Time
+
+		for {
+			var e models.Election
+			if err := database.PostgresDB.First(&e, electionID).Error; err != nil {
+				writeSSE(w, "", "error", fiber.Map{"message": "Election not found"})
+				w.Flush()
+				return
+			}
+
+			var tally models.ElectionTally
+			database.PostgresDB.Where("election_id = ?", electionID).First(&tally)
+
+			now := time.Now()
+			lastCheck = now
+			current := streamState{
+				Turnout: last.Turnout,
+				Results: last.Results,
+				Status:  calculateStatus(e.StartDate, e.EndDate, e.IsActive),
+			}
+			pollingOpen := e.IsActive && now.After(e.StartDate) && now.Before(e.EndDate)
+
+			sendStatus := current.Status != last.Status
+			sendTurnout := pollingOpen && tally.Version != last.Turnout
+			if sendTurnout {
+				current.Turnout = tally.Version
+			}
+			sendResults := false
+			if e.IsPublished || canViewUnpublished {
+				current.Results = streamResultsVersion(electionID, tally.Version)
+				sendResults = current.Results != last.Results
+			}
+
+			if sendStatus {
+				writeSSE(w, current.id(), "status", fiber.Map{"election_id": electionID, "status": current.Status})
+			}
+			if sendTurnout {
+				writeSSE(w, current.id(), "turnout", fiber.Map{
+					"election_id":     electionID,
+					"votes_cast":      tally.TotalVotes,
+					"eligible":        eligible,
+					"turnout_percent": turnoutPercent(tally.TotalVotes, eligible),
+				})
+			}
+			if sendResults {
+				writeSSE(w, current.id(), "results", streamResults(electionID))
+			}
+			if (sendStatus || sendTurnout || sendResults) && w.Flush() != nil {
+				return
+			}
+			last = current
+
+			if !boundary.Stop() {
+				select {
+				case <-boundary.C:
+				default:
+				}
+			}
+			boundary.Reset(nextBoundary(now, e.StartDate, e.EndDate))
+
+		wait:
+			for {
+				select {
+				case <-wake:
+					// A busy election wakes the stream on every vote; read at
+					// most once per sseTick.
+					if d := sseTick - time.Since(lastCheck); d > 0 {
+						time.Sleep(d)
+					}
+					break wait
+				case <-boundary.C:
+					break wait
+				case <-heartbeat.C:
+					fmt.Fprint(w, ": heartbeat\n\n")
+					if w.Flush() != nil {
+						return
+					}
+				case <-expire.C:
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// nextBoundary is how long until polling opens or closes, or sseMaxLife when
+// both have passed. A second is added so the status has flipped on waking.
+func nextBoundary(now, start, end time.Time) time.Duration {
+	for _, t := range []time.Time{start, end} {
+		if t.After(now) {
+			return t.Sub(now) + time.Second
+		}
+	}
+	return sseMaxLife
+}
+
+// streamResultsVersion changes when the declared result or, before a
+// declaration, the live tally changes.
+func streamResultsVersion(electionID uint, tallyVersion int64) int64 {
+	var result models.ElectionResult
+	if err := database.PostgresDB.Select("declared_at").Where("election_id = ?", electionID).First(&result).Error; err == nil && result.DeclaredAt != nil {
+		return result.DeclaredAt.Unix()
+	}
+	return tallyVersion + 1
+}
+
+// streamResults returns the declared result when there is one, otherwise the live computation.
+func streamResults(electionID uint) interface{} {
+	if declared, err := service.GetDeclaredResult(electionID); err == nil {
+		return declared
+	}
+	live, err := service.ComputeElectionResult(electionID)
+	if err != nil {
+		return fiber.Map{"election_id": electionID, "message": err.Error()}
+	}
+	return live
+}
+
+func writeSSE(w *bufio.Writer, id, event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+// turnoutPercent is the share of eligible voters who voted, rounded to two
+// decimals.
+func turnoutPercent(votes, eligible int64) float64 {
+	if eligible == 0 {
+		return 0
+	}
+	return math.Round(float64(votes)/float64(eligible)*10000) / 100
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTurnoutPercent(t *testing.T) {
+	tests := []struct {
+		votes, eligible int64
+		want            float64
+	}{
+		{0, 0, 0},
+		{1, 3, 33.33},
+		{2, 3, 66.67},
+		{1, 8, 12.5},
+		{7, 7, 100},
+	}
+	for _, tt := range tests {
+		if got := turnoutPercent(tt.votes, tt.eligible); got != tt.want {
+			t.Errorf("turnoutPercent(%d, %d) = %v, want %v", tt.votes, tt.eligible, got, tt.want)
+		}
+	}
+}
+
+func TestNextBoundary(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       time.Duration
+	}{
+		{"before polling opens", now.Add(time.Hour), now.Add(2 * time.Hour), time.Hour + time.Second},
+		{"while polling is open", now.Add(-time.Hour), now.Add(time.Minute), time.Minute + time.Second},
+		{"after polling closed", now.Add(-2 * time.Hour), now.Add(-time.Hour), sseMaxLife},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextBoundary(now, tt.start, tt.end); got != tt.want {
+				t.Errorf("nextBoundary = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,21 +2,26 @@ package api
 
 import (
 	"E-voting/internal/database"
+	"E-voting/internal/events"
 	"E-voting/internal/models"
 	"E-voting/internal/service"
 	"E-voting/internal/utils"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type VoteRequest struct {
-	CandidateID uint `json:"candidate_id"`
-	ElectionID  uint `json:"election_id"`
+	CandidateID  uint   `json:"candidate_id"`
+	ElectionID   uint   `json:"election_id"`
+	CandidateIDs []uint `json:"candidate_ids"` // APPROVAL ballots, up to MaxSelections
+	Preferences  []uint `json:"preferences"`   // RANKED ballots, most preferred first
+	Nota         bool   `json:"nota"`          // None of the Above, if the election offers it
+
+	Answers []service.AnswerInput `json:"answers"` // REFERENDUM ballots
 }
 
 type ElectionWithStatus struct {
@@ -58,42 +63,7 @@ func GetVoterElections(c *fiber.Ctx) error {
 	var eligibleElections []ElectionWithStatus
 
 	for _, e := range allElections {
-		isEligible := false
-
-		// Rule 1: District Match is always required (foundation)
-		if e.District == voter.District {
-			switch e.ElectionType {
-			case "District Panchayat":
-				isEligible = true
-
-			case "Block Panchayat":
-				if e.Block == voter.Block {
-					isEligible = true
-				}
-
-			case "Grama Panchayat":
-				if e.Block == voter.Block &&
-					e.LocalBodyName == voter.Panchayath &&
-					e.Ward == voter.Ward { // Strict Ward Match
-					isEligible = true
-				}
-
-			case "Municipality", "Municipal Corporation":
-				if e.LocalBodyName == voter.Panchayath &&
-					e.Ward == voter.Ward { // Strict Ward Match
-					isEligible = true
-				}
-			}
-		}
-
-		// Rule 2: Specific Ward Restriction (Optional)
-		if isEligible && e.Ward != "" {
-			if e.Ward != voter.Ward {
-				isEligible = false
-			}
-		}
-
-		if isEligible {
+		if service.IsVoterEligible(e, voter) {
 			eligibleElections = append(eligibleElections, ElectionWithStatus{
 				Election: e,
 				HasVoted: participationMap[e.ID],
@@ -124,15 +94,39 @@ func GetVoterCandidates(c *fiber.Ctx) error {
 		return utils.Error(c, 403, "Access Denied: You have already voted in this election.")
 	}
 
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return utils.Error(c, 404, "Election not found")
+	}
+
 	// 2. Fetch Candidates
 	var candidates []models.Candidate
 	if err := database.PostgresDB.
 		Preload("Party").
-		Where("election_id = ?", electionID).
+		Where("election_id = ? AND nomination_status = ?", election.ID, service.NominationFinal).
+		Order("serial_no asc, id asc").
 		Find(&candidates).Error; err != nil {
 		return utils.Error(c, 500, "Failed to fetch candidates")
 	}
-	return utils.Success(c, candidates)
+	for i := range candidates {
+		candidates[i].Mobile, candidates[i].AgentMobile = "", ""
+	}
+
+	// NOTA always comes last on the ballot
+	if election.AllowNota {
+		candidates = append(candidates, service.NotaCandidate(election.ID))
+	}
+
+	// The ballot states the seat's reservation alongside the candidates.
+	reservation := election.Reservation
+	if reservation == "" {
+		reservation = service.ReservationGeneral
+	}
+	return utils.Success(c, fiber.Map{
+		"reservation":       reservation,
+		"reservation_label": service.ReservationLabel(reservation),
+		"candidates":        candidates,
+	})
 }
 
 func CastVote(c *fiber.Ctx) error {
@@ -173,6 +167,13 @@ func CastVote(c *fiber.Ctx) error {
 	if !voter.IsVerified {
 		return utils.Error(c, 403, "Your account has not been verified by an admin yet.")
 	}
+	if !service.IsVoterEligible(election, voter) {
+		return utils.Error(c, 403, "You are not on the electoral roll for this election")
+	}
+	weight, err := service.VoteWeight(election, voter.ID)
+	if err != nil {
+		return utils.Error(c, 403, err.Error())
+	}
 
 	var existingParticipation int64
 	database.PostgresDB.Model(&models.ElectionParticipation{}).
@@ -188,15 +189,34 @@ func CastVote(c *fiber.Ctx) error {
 		return utils.Error(c, 400, "You have already voted in this election")
 	}
 
+	// A referendum ballot carries answers instead of candidates
+	var choices []uint
+	var answers []models.BallotAnswer
+	if election.BallotType == service.BallotReferendum {
+		answers, err = service.ValidateAnswers(election, req.Answers)
+		choices = []uint{0}
+	} else {
+		choices, err = service.ValidateBallot(election, service.BallotInput{
+			CandidateID:  req.CandidateID,
+			CandidateIDs: req.CandidateIDs,
+			Preferences:  req.Preferences,
+			Nota:         req.Nota,
+		})
+	}
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
 	// 4. Record Vote
 	voteHash := sha256.Sum256([]byte(fmt.Sprintf("%d-%d-%d", voter.ID, req.ElectionID, time.Now().UnixNano())))
 	voteHashStr := hex.EncodeToString(voteHash[:])
 
 	vote := models.Vote{
 		ElectionID:  req.ElectionID,
-		CandidateID: req.CandidateID,
+		CandidateID: choices[0],
 		VoteHash:    voteHashStr,
 		Timestamp:   time.Now(),
+		Weight:      weight,
 	}
 
 	participation := models.ElectionParticipation{
@@ -217,38 +237,78 @@ func CastVote(c *fiber.Ctx) error {
 		return utils.Error(c, 500, "Failed to record participation")
 	}
 
-	tx.Commit()
-
-	go BroadcastVoteUpdate(election.Title)
-
-	go func(eID, cID, vID uint, vHash string) {
-		txHash, err := service.CastVoteOnChain(eID, cID, vID)
-		if err != nil {
-			log.Printf("CRITICAL: Blockchain write failed for VoteHash %s. Error: %v", vHash, err)
-
-			service.SyncElectionsLogic()
+	// An open ballot is recorded against the member who cast it
+	if election.OpenBallot {
+		record := models.OpenBallotRecord{
+			ElectionID:  election.ID,
+			VoterID:     voter.ID,
+			CandidateID: choices[0],
+			Timestamp:   vote.Timestamp,
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			tx.Rollback()
+			return utils.Error(c, 500, "Failed to record open ballot")
+		}
+	}
 
-			log.Printf(" [Auto-Fix] Retrying Vote for %s...", vHash)
-			txHash, err = service.CastVoteOnChain(eID, cID, vID)
+	if election.BallotType == service.BallotRanked || election.BallotType == service.BallotApproval {
+		selections := make([]models.VoteSelection, len(choices))
+		for i, id := range choices {
+			selections[i] = models.VoteSelection{VoteID: vote.ID, ElectionID: vote.ElectionID, CandidateID: id, Rank: i + 1}
+		}
+		if err := tx.Create(&selections).Error; err != nil {
+			tx.Rollback()
+			return utils.Error(c, 500, "Failed to record selections")
+		}
+	}
 
-			if err != nil {
-				log.Printf(" [Auto-Fix] Critical Failure: Retry also failed. Error: %v", err)
-				return
-			}
+	if len(answers) > 0 {
+		for i := range answers {
+			answers[i].VoteID = vote.ID
+		}
+		if err := tx.Create(&answers).Error; err != nil {
+			tx.Rollback()
+			return utils.Error(c, 500, "Failed to record answers")
+		}
+		if err := service.RecordAnswerTally(tx, answers); err != nil {
+			tx.Rollback()
+			return utils.Error(c, 500, "Failed to update tally")
 		}
+	}
 
-		log.Printf(" !!! Vote written to blockchain! Tx: %s", txHash)
-		result := database.PostgresDB.Model(&models.Vote{}).
-			Where("vote_hash = ?", vHash).
-			Update("blockchain_tx", txHash)
+	// Ranked elections tally first preferences; the runoff is counted from the selections.
+	tallied := choices[:1]
+	switch election.BallotType {
+	case service.BallotApproval:
+		tallied = choices
+	case service.BallotReferendum:
+		tallied = nil
+	}
+	if err := service.RecordVoteTally(tx, req.ElectionID, weight, tallied...); err != nil {
+		tx.Rollback()
+		return utils.Error(c, 500, "Failed to update tally")
+	}
 
-		if result.Error != nil {
-			log.Printf("ERROR: Failed to update DB with TxHash %s: %v", txHash, result.Error)
-		} else {
-			log.Printf("SUCCESS: Database updated with TxHash: %s", txHash)
-		}
+	if err := tx.Commit().Error; err != nil {
+		return utils.Error(c, 500, "Failed to cast vote")
+	}
 
-	}(req.ElectionID, req.CandidateID, voterID, voteHashStr)
+	cast := events.VoteCast{
+		ElectionID:    req.ElectionID,
+		ElectionTitle: election.Title,
+		CandidateID:   vote.CandidateID,
+		VoterID:       voterID,
+		VoteHash:      voteHashStr,
+		At:            vote.Timestamp,
+		Weight:        weight,
+	}
+	if election.BallotType == service.BallotApproval {
+		cast.CandidateIDs = choices
+	}
+	for _, a := range answers {
+		cast.OptionIDs = append(cast.OptionIDs, a.OptionID)
+	}
+	events.Publish(cast)
 
 	return utils.Success(c, fiber.Map{
 		"message":           "Vote cast successfully",
@@ -2,6 +2,7 @@ package api
 
 import (
 	"E-voting/internal/database"
+	"E-voting/internal/events"
 	"E-voting/internal/models"
 	"E-voting/internal/repository"
 	"E-voting/internal/service"
@@ -9,6 +10,8 @@ import (
 	"bytes"
 	"encoding/csv"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -22,6 +25,9 @@ type RegisterVoterRequest struct {
 	Block      string `json:"block"`
 	Panchayath string `json:"panchayath"`
 	Ward       string `json:"ward"`
+
+	// Shares is only applied by the admin UpdateVoter endpoint.
+	Shares *int64 `json:"shares"`
 }
 
 type VoterStatusReq struct {
@@ -121,6 +127,12 @@ func UpdateVoter(c *fiber.Ctx) error {
 	if req.Ward != "" {
 		voter.Ward = req.Ward
 	}
+	if req.Shares != nil {
+		if *req.Shares < 0 {
+			return utils.Error(c, 400, "Shares cannot be negative")
+		}
+		voter.Shares = *req.Shares
+	}
 
 	if err := database.PostgresDB.Save(&voter).Error; err != nil {
 		return utils.Error(c, 500, "Failed to update voter")
@@ -195,10 +207,12 @@ func VerifyVoter(c *fiber.Ctx) error {
 		return utils.Error(c, 500, "Failed to verify voter")
 	}
 
-	// Log Action
-	actorIDFloat, _ := c.Locals("user_id").(float64)
-	actorRole, _ := c.Locals("role").(string)
-	service.LogAdminAction(uint(actorIDFloat), actorRole, "VERIFY_VOTER", req.VoterID, nil)
+	actorID, actorRole := currentActor(c)
+	events.Publish(events.VoterVerified{
+		VoterID:   req.VoterID,
+		ActorID:   actorID,
+		ActorRole: actorRole,
+	})
 
 	return utils.Success(c, "Voter verified successfully")
 }
@@ -276,7 +290,7 @@ func ImportVotersCSV(c *fiber.Ctx) error {
 	successCount := 0
 	failCount := 0
 
-	// Assuming Header Row: Full Name, Mobile, Aadhaar Number
+	// Assuming Header Row: Full Name, Mobile, Aadhaar Number[, Shares]
 	for i, record := range records {
 		if i == 0 {
 			continue // Skip Header
@@ -304,6 +318,14 @@ func ImportVotersCSV(c *fiber.Ctx) error {
 			Mobile:        mobile,
 			AadhaarNumber: aadhaar,
 		}
+		if len(record) > 3 && strings.TrimSpace(record[3]) != "" {
+			shares, err := strconv.ParseInt(strings.TrimSpace(record[3]), 10, 64)
+			if err != nil || shares < 0 {
+				failCount++
+				continue
+			}
+			voter.Shares = shares
+		}
 
 		if err := repository.CreateVoter(voter); err == nil {
 			successCount++
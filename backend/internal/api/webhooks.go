@@ -0,0 +1,198 @@
+package api
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/service"
+	"E-voting/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookRequest struct {
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	Events       []string `json:"events"`
+	IsActive     *bool    `json:"is_active"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+func ListWebhooks(c *fiber.Ctx) error {
+	var subs []models.WebhookSubscription
+	if err := database.PostgresDB.Order("created_at desc").Find(&subs).Error; err != nil {
+		return utils.Error(c, 500, "Failed to fetch webhooks")
+	}
+	return utils.Success(c, fiber.Map{
+		"webhooks":         subs,
+		"available_events": service.WebhookEvents,
+	})
+}
+
+// CreateWebhook registers a subscription. The signing secret is only ever
+// returned here and when it is rotated.
+func CreateWebhook(c *fiber.Ctx) error {
+	var req WebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request body")
+	}
+
+	if req.Name == "" {
+		return utils.Error(c, 400, "Webhook name is required")
+	}
+	if err := service.ValidateWebhookURL(req.URL); err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	filter, err := service.NormalizeWebhookEvents(req.Events)
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+
+	secret, err := utils.RandomToken(32)
+	if err != nil {
+		return utils.Error(c, 500, "Failed to generate secret")
+	}
+
+	actorID, _ := currentActor(c)
+	sub := models.WebhookSubscription{
+		Name:      req.Name,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    filter,
+		IsActive:  req.IsActive == nil || *req.IsActive,
+		CreatedBy: actorID,
+	}
+	if err := database.PostgresDB.Create(&sub).Error; err != nil {
+		return utils.Error(c, 500, "Failed to create webhook")
+	}
+
+	logAdminAction(c, "CREATE_WEBHOOK", sub.ID, map[string]interface{}{"url": sub.URL, "events": sub.Events})
+	return utils.Success(c, fiber.Map{
+		"webhook": sub,
+		"secret":  secret,
+	})
+}
+
+func UpdateWebhook(c *fiber.Ctx) error {
+	var sub models.WebhookSubscription
+	if err := database.PostgresDB.First(&sub, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Webhook not found")
+	}
+
+	var req WebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Error(c, 400, "Invalid request body")
+	}
+
+	if req.Name != "" {
+		sub.Name = req.Name
+	}
+	if req.URL != "" {
+		if err := service.ValidateWebhookURL(req.URL); err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+		sub.URL = req.URL
+	}
+	if req.Events != nil {
+		filter, err := service.NormalizeWebhookEvents(req.Events)
+		if err != nil {
+			return utils.Error(c, 400, err.Error())
+		}
+		sub.Events = filter
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+
+	response := fiber.Map{"webhook": &sub}
+	if req.RotateSecret {
+		secret, err := utils.RandomToken(32)
+		if err != nil {
+			return utils.Error(c, 500, "Failed to generate secret")
+		}
+		sub.Secret = secret
+		response["secret"] = secret
+	}
+
+	if err := database.PostgresDB.Save(&sub).Error; err != nil {
+		return utils.Error(c, 500, "Failed to update webhook")
+	}
+
+	logAdminAction(c, "UPDATE_WEBHOOK", sub.ID, map[string]interface{}{
+		"url":            sub.URL,
+		"events":         sub.Events,
+		"is_active":      sub.IsActive,
+		"secret_rotated": req.RotateSecret,
+	})
+	return utils.Success(c, response)
+}
+
+func DeleteWebhook(c *fiber.Ctx) error {
+	var sub models.WebhookSubscription
+	if err := database.PostgresDB.First(&sub, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Webhook not found")
+	}
+
+	if err := database.PostgresDB.Delete(&sub).Error; err != nil {
+		return utils.Error(c, 500, "Failed to delete webhook")
+	}
+
+	logAdminAction(c, "DELETE_WEBHOOK", sub.ID, map[string]interface{}{"url": sub.URL})
+	return utils.Success(c, "Webhook deleted successfully")
+}
+
+// PingWebhook sends a signed test event and reports how the receiver answered.
+func PingWebhook(c *fiber.Ctx) error {
+	var sub models.WebhookSubscription
+	if err := database.PostgresDB.First(&sub, c.Params("id")).Error; err != nil {
+		return utils.Error(c, 404, "Webhook not found")
+	}
+
+	delivery, err := service.SendWebhookPing(sub)
+	if err != nil {
+		return utils.Error(c, 500, err.Error())
+	}
+	if delivery == nil {
+		return utils.Error(c, 409, "Ping is already being delivered")
+	}
+	return utils.Success(c, delivery)
+}
+
+func ListWebhookDeliveries(c *fiber.Ctx) error {
+	query := database.PostgresDB.Where("subscription_id = ?", c.Params("id"))
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("created_at desc").Limit(limit).Find(&deliveries).Error; err != nil {
+		return utils.Error(c, 500, "Failed to fetch deliveries")
+	}
+	return utils.Success(c, deliveries)
+}
+
+func RedeliverWebhook(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return utils.Error(c, 400, "Invalid delivery ID")
+	}
+
+	delivery, err := service.RedeliverWebhook(uint(id))
+	if err != nil {
+		return utils.Error(c, 400, err.Error())
+	}
+	if delivery == nil {
+		return utils.Error(c, 409, "Redelivery is already in progress")
+	}
+
+	logAdminAction(c, "REDELIVER_WEBHOOK", delivery.SubscriptionID, map[string]interface{}{
+		"delivery_id": id,
+		"event":       delivery.Event,
+		"status":      delivery.Status,
+	})
+	return utils.Success(c, delivery)
+}
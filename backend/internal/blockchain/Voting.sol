@@ -13,12 +13,14 @@ contract VotingSystem {
     // Mappings
     mapping(uint256 => Election) public elections; // Manage election state
     mapping(uint256 => mapping(uint256 => uint256)) public voteCounts; // Election -> Candidate -> Count
+    mapping(uint256 => mapping(uint256 => uint256)) public weightedCounts; // Election -> Candidate -> Sum of weights
     mapping(uint256 => mapping(uint256 => bool)) public hasVoted; // Election -> VoterID -> Status
 
     // Events
     // NOTE: Removed voterId from event to ensure SECRET BALLOT. 
     // We only log that a vote happened for auditability, not WHO voted for WHOM.
     event VoteCasted(uint256 indexed electionId, uint256 indexed candidateId);
+    event WeightedVoteCasted(uint256 indexed electionId, uint256 indexed candidateId, uint256 weight);
     event ElectionCreated(uint256 indexed electionId, uint256 startTime, uint256 endTime);
 
     // Modifier to ensure only the backend/admin can interact with sensitive functions
@@ -47,6 +49,21 @@ contract VotingSystem {
 
     // 2. Secure Voting: Only the backend (owner) can call this after verifying JWT/Auth
     function castVote(uint256 _electionId, uint256 _candidateId, uint256 _voterId) public onlyOwner {
+        recordVote(_electionId, _candidateId, _voterId, 1);
+
+        // D. Emit Event (Anonymized)
+        emit VoteCasted(_electionId, _candidateId);
+    }
+
+    // 2b. Weighted Voting: the ballot counts once in voteCounts and by its
+    // weight in weightedCounts, so both totals can be checked on chain.
+    function castWeightedVote(uint256 _electionId, uint256 _candidateId, uint256 _voterId, uint256 _weight) public onlyOwner {
+        require(_weight > 0, "Weight must be positive");
+        recordVote(_electionId, _candidateId, _voterId, _weight);
+        emit WeightedVoteCasted(_electionId, _candidateId, _weight);
+    }
+
+    function recordVote(uint256 _electionId, uint256 _candidateId, uint256 _voterId, uint256 _weight) internal {
         // A. Election Validity Checks
         require(elections[_electionId].exists, "Election does not exist");
         require(block.timestamp >= elections[_electionId].startTime, "Election has not started");
@@ -57,16 +74,18 @@ contract VotingSystem {
 
         // C. Record the Vote (Immutable)
         voteCounts[_electionId][_candidateId] += 1;
+        weightedCounts[_electionId][_candidateId] += _weight;
         hasVoted[_electionId][_voterId] = true;
-
-        // D. Emit Event (Anonymized)
-        emit VoteCasted(_electionId, _candidateId);
     }
 
     // 3. Public Verification: Anyone can check the results
     function getVotes(uint256 _electionId, uint256 _candidateId) public view returns (uint256) {
         return voteCounts[_electionId][_candidateId];
     }
+
+    function getWeightedVotes(uint256 _electionId, uint256 _candidateId) public view returns (uint256) {
+        return weightedCounts[_electionId][_candidateId];
+    }
     
     // Helper to check if a user has voted (for frontend UI)
     function checkHasVoted(uint256 _electionId, uint256 _voterId) public view returns (bool) {
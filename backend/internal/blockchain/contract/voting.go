@@ -25,7 +25,7 @@ var (
 )
 
 // VotingSystemABI is the input ABI used to generate the binding from.
-const VotingSystemABI = `[{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_startTime","type":"uint256"},{"internalType":"uint256","name":"_endTime","type":"uint256"}],"name":"createElection","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_candidateId","type":"uint256"},{"internalType":"uint256","name":"_voterId","type":"uint256"}],"name":"castVote","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_candidateId","type":"uint256"}],"name":"getVotes","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_voterId","type":"uint256"}],"name":"checkHasVoted","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"owner","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"uint256","name":"","type":"uint256"}],"name":"elections","outputs":[{"internalType":"uint256","name":"startTime","type":"uint256"},{"internalType":"uint256","name":"endTime","type":"uint256"},{"internalType":"bool","name":"exists","type":"bool"}],"stateMutability":"view","type":"function"}]`
+const VotingSystemABI = `[{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_startTime","type":"uint256"},{"internalType":"uint256","name":"_endTime","type":"uint256"}],"name":"createElection","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_candidateId","type":"uint256"},{"internalType":"uint256","name":"_voterId","type":"uint256"}],"name":"castVote","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_candidateId","type":"uint256"},{"internalType":"uint256","name":"_voterId","type":"uint256"},{"internalType":"uint256","name":"_weight","type":"uint256"}],"name":"castWeightedVote","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_candidateId","type":"uint256"}],"name":"getWeightedVotes","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_candidateId","type":"uint256"}],"name":"getVotes","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"uint256","name":"_electionId","type":"uint256"},{"internalType":"uint256","name":"_voterId","type":"uint256"}],"name":"checkHasVoted","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"owner","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"uint256","name":"","type":"uint256"}],"name":"elections","outputs":[{"internalType":"uint256","name":"startTime","type":"uint256"},{"internalType":"uint256","name":"endTime","type":"uint256"},{"internalType":"bool","name":"exists","type":"bool"}],"stateMutability":"view","type":"function"}]`
 
 // VotingSystem is an auto generated Go binding around an Ethereum contract.
 type VotingSystem struct {
@@ -77,6 +77,11 @@ func (_VotingSystem *VotingSystemTransactor) CastVote(opts *bind.TransactOpts, _
 	return _VotingSystem.contract.Transact(opts, "castVote", _electionId, _candidateId, _voterId)
 }
 
+// CastWeightedVote is a paid mutator transaction binding the contract method "castWeightedVote"
+func (_VotingSystem *VotingSystemTransactor) CastWeightedVote(opts *bind.TransactOpts, _electionId *big.Int, _candidateId *big.Int, _voterId *big.Int, _weight *big.Int) (*types.Transaction, error) {
+	return _VotingSystem.contract.Transact(opts, "castWeightedVote", _electionId, _candidateId, _voterId, _weight)
+}
+
 // GetVotes is a free data retrieval call binding the contract method "getVotes"
 func (_VotingSystem *VotingSystemCaller) GetVotes(opts *bind.CallOpts, _electionId *big.Int, _candidateId *big.Int) (*big.Int, error) {
 	var out []interface{}
@@ -89,3 +94,16 @@ func (_VotingSystem *VotingSystemCaller) GetVotes(opts *bind.CallOpts, _election
 	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
 	return out0, err
 }
+
+// GetWeightedVotes is a free data retrieval call binding the contract method "getWeightedVotes"
+func (_VotingSystem *VotingSystemCaller) GetWeightedVotes(opts *bind.CallOpts, _electionId *big.Int, _candidateId *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _VotingSystem.contract.Call(opts, &out, "getWeightedVotes", _electionId, _candidateId)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+	return out0, err
+}
@@ -34,6 +34,9 @@ type AppConfig struct {
 		Email    string
 		Password string
 	}
+	Realtime struct {
+		Broker string
+	}
 }
 
 var Config AppConfig
@@ -74,6 +77,8 @@ func LoadConfig() {
 	Config.SMTP.Email = os.Getenv("SMTP_EMAIL")
 	Config.SMTP.Password = os.Getenv("SMTP_PASSWORD")
 
+	Config.Realtime.Broker = ifnD(os.Getenv("REALTIME_BROKER"), "postgres")
+
 	if Config.SMTP.Host == "" || Config.SMTP.Port == "" {
 		log.Println("CRITICAL ERROR: SMTP Configuration is missing. Check .env file.")
 	} else {
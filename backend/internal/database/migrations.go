@@ -0,0 +1,65 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// schemaMigration records a one-off data migration that has been applied.
+type schemaMigration struct {
+	Version   string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+type migration struct {
+	Version string
+	Up      func(tx *gorm.DB) error
+}
+
+// migrations run once each, in order, after AutoMigrate. Append new entries;
+// never edit or reorder applied ones.
+var migrations = []migration{
+	{
+		// Independents used to be filed under a stand-in "Independent" party.
+		Version: "20261019_independents_without_party",
+		Up: func(tx *gorm.DB) error {
+			stmts := []string{
+				"ALTER TABLE candidates ALTER COLUMN party_id DROP NOT NULL",
+				"UPDATE candidates SET party_id = NULL WHERE party_id IN (SELECT id FROM parties WHERE LOWER(name) IN ('independent', 'independents', 'ind'))",
+				"UPDATE election_result_entries SET independent = true WHERE LOWER(party_name) IN ('independent', 'independents', 'ind')",
+			}
+			for _, s := range stmts {
+				if err := tx.Exec(s).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+func runMigrations(db *gorm.DB) {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		log.Fatal("Failed to create schema_migrations:", err)
+	}
+
+	for _, m := range migrations {
+		var count int64
+		db.Model(&schemaMigration{}).Where("version = ?", m.Version).Count(&count)
+		if count > 0 {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			log.Fatalf("Migration %s failed: %v", m.Version, err)
+		}
+		log.Printf(" Applied migration %s", m.Version)
+	}
+}
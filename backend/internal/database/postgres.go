@@ -13,10 +13,10 @@ import (
 
 var PostgresDB *gorm.DB
 
-func ConnectPostgres() {
+func PostgresDSN() string {
 	cfg := config.Config.Postgres
 
-	dsn := fmt.Sprintf(
+	return fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
 		cfg.Host,
 		cfg.User,
@@ -24,6 +24,10 @@ func ConnectPostgres() {
 		cfg.DBName,
 		cfg.Port,
 	)
+}
+
+func ConnectPostgres() {
+	dsn := PostgresDSN()
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
@@ -37,9 +41,23 @@ func ConnectPostgres() {
 	PostgresDB = db
 	if err := db.AutoMigrate(&models.Role{},
 		&models.Admin{}, &models.Voter{},
-		&models.Party{}, &models.Candidate{},
+		&models.Party{}, &models.Candidate{}, &models.FreeSymbol{},
+		&models.CandidateDocument{}, &models.CandidateDisclosure{}, &models.CriminalCase{},
+		&models.NominationMatch{},
+		&models.ExpenditureLimit{}, &models.ExpenditureStatement{}, &models.ExpenditureItem{},
 		&models.Vote{}, &models.Election{},
-		&models.SystemSetting{}, &models.ElectionParticipation{}); err != nil {
+		&models.SystemSetting{}, &models.ElectionParticipation{},
+		&models.ElectionResult{}, &models.ElectionResultEntry{},
+		&models.TieBreak{}, &models.CandidateTally{},
+		&models.ElectionTally{}, &models.WebhookSubscription{},
+		&models.WebhookDelivery{}, &models.TurnoutMilestone{},
+		&models.ResultFeedRevision{}, &models.VoteSelection{},
+		&models.BallotQuestion{}, &models.BallotOption{},
+		&models.BallotAnswer{}, &models.OptionTally{},
+		&models.QuestionTally{}, &models.RollEntry{},
+		&models.IndirectElector{}, &models.OpenBallotRecord{},
+		&models.WardReservation{}, &models.Alliance{},
+		&models.AllianceMembership{}); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
@@ -52,5 +70,7 @@ func ConnectPostgres() {
 		}
 	}
 
+	runMigrations(db)
+
 	log.Println(" PostgreSQL connected & Migrated")
 }
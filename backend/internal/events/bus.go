@@ -0,0 +1,111 @@
+package events
+
+import (
+	"log"
+	"sync"
+)
+
+type Handler func(Event)
+
+// Bus dispatches events to subscribers. Synchronous handlers run in the
+// publisher's goroutine before Publish returns; asynchronous handlers each get
+// their own goroutine. An empty event name subscribes to every event.
+type Bus struct {
+	mu    sync.RWMutex
+	sync  map[string][]Handler
+	async map[string][]Handler
+	wg    sync.WaitGroup
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		sync:  make(map[string][]Handler),
+		async: make(map[string][]Handler),
+	}
+}
+
+func (b *Bus) Subscribe(name string, h Handler) {
+	b.mu.Lock()
+	b.sync[name] = append(b.sync[name], h)
+	b.mu.Unlock()
+}
+
+func (b *Bus) SubscribeAsync(name string, h Handler) {
+	b.mu.Lock()
+	b.async[name] = append(b.async[name], h)
+	b.mu.Unlock()
+}
+
+func (b *Bus) Publish(e Event) {
+	name := e.EventName()
+
+	b.mu.RLock()
+	syncHandlers := append(append([]Handler{}, b.sync[name]...), b.sync[""]...)
+	asyncHandlers := append(append([]Handler{}, b.async[name]...), b.async[""]...)
+	b.mu.RUnlock()
+
+	for _, h := range syncHandlers {
+		run(name, h, e)
+	}
+
+	for _, h := range asyncHandlers {
+		b.wg.Add(1)
+		go func(h Handler) {
+			defer b.wg.Done()
+			run(name, h, e)
+		}(h)
+	}
+}
+
+// Wait blocks until every asynchronous handler started so far has finished.
+func (b *Bus) Wait() {
+	b.wg.Wait()
+}
+
+// run keeps one failing subscriber from taking down the publisher or its siblings.
+func run(name string, h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event handler for %s panicked: %v", name, r)
+		}
+	}()
+	h(e)
+}
+
+// Default is the process-wide bus used by handlers and services.
+var Default = NewBus()
+
+func Publish(e Event)                       { Default.Publish(e) }
+func Subscribe(name string, h Handler)      { Default.Subscribe(name, h) }
+func SubscribeAsync(name string, h Handler) { Default.SubscribeAsync(name, h) }
+
+// Recorder captures published events so tests can assert on what was emitted.
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Attach records every event published on the bus.
+func (r *Recorder) Attach(b *Bus) {
+	b.Subscribe("", func(e Event) {
+		r.mu.Lock()
+		r.events = append(r.events, e)
+		r.mu.Unlock()
+	})
+}
+
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event{}, r.events...)
+}
+
+func (r *Recorder) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, len(r.events))
+	for i, e := range r.events {
+		names[i] = e.EventName()
+	}
+	return names
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestBusDispatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		subscribe string
+		async     bool
+		publish   []Event
+		want      []string
+	}{
+		{
+			name:      "sync handler sees its event",
+			subscribe: NameVoteCast,
+			publish:   []Event{VoteCast{ElectionID: 1}},
+			want:      []string{NameVoteCast},
+		},
+		{
+			name:      "handler ignores other events",
+			subscribe: NameVoteCast,
+			publish:   []Event{ResultsPublished{ElectionID: 1}, VoteCast{ElectionID: 1}},
+			want:      []string{NameVoteCast},
+		},
+		{
+			name:      "empty name subscribes to everything",
+			subscribe: "",
+			publish:   []Event{ResultsPublished{ElectionID: 1}, VoteCast{ElectionID: 1}},
+			want:      []string{NameResultsPublished, NameVoteCast},
+		},
+		{
+			name:      "async handler has run after Wait",
+			subscribe: NameResultsPublished,
+			async:     true,
+			publish:   []Event{ResultsPublished{ElectionID: 1}, ResultsPublished{ElectionID: 2}},
+			want:      []string{NameResultsPublished, NameResultsPublished},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBus()
+			var mu sync.Mutex
+			got := []string{}
+			h := func(e Event) {
+				mu.Lock()
+				got = append(got, e.EventName())
+				mu.Unlock()
+			}
+			if tt.async {
+				b.SubscribeAsync(tt.subscribe, h)
+			} else {
+				b.Subscribe(tt.subscribe, h)
+			}
+
+			for _, e := range tt.publish {
+				b.Publish(e)
+			}
+			b.Wait()
+
+			if tt.async {
+				sort.Strings(got)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("handled %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBusRecoversFromPanickingHandler(t *testing.T) {
+	b := NewBus()
+	b.Subscribe(NameVoteCast, func(Event) { panic("boom") })
+	b.SubscribeAsync(NameVoteCast, func(Event) { panic("boom") })
+	var rec Recorder
+	rec.Attach(b)
+
+	b.Publish(VoteCast{ElectionID: 1})
+	b.Wait()
+
+	if got := rec.Names(); !reflect.DeepEqual(got, []string{NameVoteCast}) {
+		t.Errorf("recorded %v after a panicking sibling, want [%s]", got, NameVoteCast)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	b := NewBus()
+	var rec Recorder
+	rec.Attach(b)
+
+	published := []Event{
+		ElectionStateChanged{ElectionID: 3, To: "ONGOING"},
+		VoteCast{ElectionID: 3, CandidateID: 7},
+		ResultsPublished{ElectionID: 3, Published: true},
+	}
+	for _, e := range published {
+		b.Publish(e)
+	}
+
+	if got := rec.Events(); !reflect.DeepEqual(got, published) {
+		t.Errorf("events = %+v, want %+v", got, published)
+	}
+	want := []string{NameElectionStateChanged, NameVoteCast, NameResultsPublished}
+	if got := rec.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("names = %v, want %v", got, want)
+	}
+
+	// Events returns a copy, so callers cannot disturb the record.
+	rec.Events()[0] = nil
+	if rec.Events()[0] == nil {
+		t.Error("Events exposed the recorder's backing slice")
+	}
+}
@@ -0,0 +1,80 @@
+package events
+
+import "time"
+
+// Event is a domain fact published on the bus after the state change is committed.
+type Event interface {
+	EventName() string
+}
+
+const (
+	NameVoteCast             = "VoteCast"
+	NameElectionStateChanged = "ElectionStateChanged"
+	NameVoterVerified        = "VoterVerified"
+	NameResultsPublished     = "ResultsPublished"
+	NameAdminLoggedIn        = "AdminLoggedIn"
+	NameTurnoutMilestone     = "TurnoutMilestone"
+)
+
+// VoteCast carries the voter only so the chain write can derive its anonymised
+// voter hash. Subscribers must never persist VoterID next to CandidateID.
+// Approval ballots also list every selection in CandidateIDs and referendum
+// ballots every marked option in OptionIDs. Weight is above 1 only in
+// weighted elections.
+type VoteCast struct {
+	ElectionID    uint
+	ElectionTitle string
+	CandidateID   uint
+	CandidateIDs  []uint
+	OptionIDs     []uint
+	Weight        int64
+	VoterID       uint
+	VoteHash      string
+	At            time.Time
+}
+
+type ElectionStateChanged struct {
+	ElectionID uint
+	Title      string
+	Active     bool
+	From       string
+	To         string
+	ActorID    uint
+	ActorRole  string
+}
+
+type VoterVerified struct {
+	VoterID   uint
+	ActorID   uint
+	ActorRole string
+}
+
+type ResultsPublished struct {
+	ElectionID uint
+	Title      string
+	Published  bool
+	ActorID    uint
+	ActorRole  string
+}
+
+type AdminLoggedIn struct {
+	AdminID uint
+	Email   string
+	IsSuper bool
+	At      time.Time
+}
+
+type TurnoutMilestone struct {
+	ElectionID uint
+	Title      string
+	Percent    int
+	VotesCast  int64
+	Eligible   int64
+}
+
+func (VoteCast) EventName() string             { return NameVoteCast }
+func (ElectionStateChanged) EventName() string { return NameElectionStateChanged }
+func (VoterVerified) EventName() string        { return NameVoterVerified }
+func (ResultsPublished) EventName() string     { return NameResultsPublished }
+func (AdminLoggedIn) EventName() string        { return NameAdminLoggedIn }
+func (TurnoutMilestone) EventName() string     { return NameTurnoutMilestone }
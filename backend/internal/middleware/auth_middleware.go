@@ -1,15 +1,14 @@
 package middleware
 
 import (
-	"E-voting/internal/config"
 	"E-voting/internal/database"
 	"E-voting/internal/models"
+	"E-voting/internal/repository"
 	"E-voting/internal/utils"
 	"fmt"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 func PermissionMiddleware(requiredPermission string) fiber.Handler {
@@ -20,19 +19,11 @@ func PermissionMiddleware(requiredPermission string) fiber.Handler {
 		}
 
 		tokenStr := strings.Replace(auth, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			return []byte(config.Config.JWTSecret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := utils.ParseJWT(tokenStr)
+		if err != nil {
 			return utils.Error(c, 401, "Invalid token")
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return utils.Error(c, 401, "Invalid token claims")
-		}
-
 		var userID uint
 		if idFloat, ok := claims["user_id"].(float64); ok {
 			userID = uint(idFloat)
@@ -45,6 +36,20 @@ func PermissionMiddleware(requiredPermission string) fiber.Handler {
 		c.Locals("user_id", float64(userID))
 		c.Locals("role", role)
 
+		// 0. Candidate portal: candidate tokens only open candidate routes,
+		// and candidate routes only take candidate tokens
+		if role == "CANDIDATE" || requiredPermission == "CANDIDATE" {
+			if role != "CANDIDATE" || requiredPermission != "CANDIDATE" {
+				return utils.Error(c, 403, "Candidate portal access only")
+			}
+			// Switching the portal off also shuts out tokens already issued.
+			if repository.GetSettingValue("candidate_portal_enabled") == "false" {
+				return utils.Error(c, 503, "The candidate portal is currently closed.")
+			}
+			c.Locals("portal_actor", claims["permissions"])
+			return c.Next()
+		}
+
 		// 1. Super Admin bypass
 		if role == "SUPER_ADMIN" {
 			return c.Next()
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Alliance is a political front such as LDF, UDF or NDA.
+type Alliance struct {
+	BaseModel
+	Name     string `gorm:"uniqueIndex;not null" json:"name"`
+	FullName string `json:"full_name"`
+
+	Memberships []AllianceMembership `gorm:"foreignKey:AllianceID" json:"memberships,omitempty"`
+}
+
+// AllianceMembership puts a party in a front from ValidFrom until ValidTo
+// (open-ended when nil). Memberships are never rewritten: a party switching
+// fronts ends its current membership and starts a new one, so every election
+// is counted with the fronts as they stood on its start date.
+type AllianceMembership struct {
+	BaseModel
+	AllianceID uint       `gorm:"index;not null" json:"alliance_id"`
+	PartyID    uint       `gorm:"index;not null" json:"party_id"`
+	Party      Party      `gorm:"foreignKey:PartyID" json:"party"`
+	ValidFrom  time.Time  `gorm:"not null" json:"valid_from"`
+	ValidTo    *time.Time `json:"valid_to"`
+}
@@ -1,17 +1,107 @@
 package models
 
+import "time"
+
 type Party struct {
 	BaseModel
 	Name string `gorm:"uniqueIndex;not null" json:"name"`
 	Logo string `json:"logo"`
+
+	// National and state parties are listed first on the ballot and their
+	// candidates stand on the party's reserved Symbol.
+	Recognition string `gorm:"default:'REGISTERED'" json:"recognition"` // NATIONAL, STATE, REGISTERED
+	Symbol      string `json:"symbol"`
+}
+
+// FreeSymbol is a symbol independents and candidates of unrecognised parties
+// may choose from.
+type FreeSymbol struct {
+	ID    uint   `gorm:"primaryKey" json:"id"`
+	Name  string `gorm:"uniqueIndex;not null" json:"name"`
+	Image string `json:"image"`
 }
 
 type Candidate struct {
 	BaseModel
 	FullName   string `gorm:"not null" json:"full_name"`
 	ElectionID uint   `gorm:"not null"`
-	PartyID    uint   `gorm:"not null" json:"party_id"`
-	Party      Party  `gorm:"foreignKey:PartyID" json:"party"`
 	Bio        string `json:"bio"`
 	Photo      string `json:"photo"`
+
+	// Set when an admin approves the candidate's uploaded documents.
+	// AffidavitRedacted is the copy, without the home address, that the
+	// public can download once the disclosure is verified.
+	Affidavit         string `json:"affidavit,omitempty"`
+	AffidavitRedacted string `json:"affidavit_redacted,omitempty"`
+	Manifesto         string `json:"manifesto,omitempty"`
+
+	// Independents have no party and stand on a symbol from the free list.
+	PartyID *uint  `gorm:"index" json:"party_id"`
+	Party   *Party `gorm:"foreignKey:PartyID" json:"party"`
+
+	// VoterID links the candidate to their own voter registration, which is
+	// how ward winners take their seat in an indirect election.
+	VoterID *uint `gorm:"index" json:"voter_id"`
+
+	// Checked against the election's reservation category.
+	Gender   string `json:"gender"`   // FEMALE, MALE, OTHER
+	Category string `json:"category"` // GENERAL, OBC, SC, ST
+
+	// Nomination lifecycle: FILED, UNDER_SCRUTINY, ACCEPTED or REJECTED,
+	// then WITHDRAWN or FINAL. Only FINAL candidates appear on the ballot;
+	// candidates added before the workflow existed are FINAL.
+	NominationStatus    string     `gorm:"default:'FINAL';not null" json:"nomination_status"`
+	NominationReason    string     `json:"nomination_reason,omitempty"`
+	NominationUpdatedAt *time.Time `json:"nomination_updated_at"`
+
+	// MatchScanPending holds the nomination back from acceptance until the
+	// duplicate check has run against its current details.
+	MatchScanPending bool `gorm:"default:false;not null" json:"match_scan_pending"`
+
+	// NameKey is FullName normalised for the duplicate check, which looks
+	// nominations up by it.
+	NameKey string `gorm:"index" json:"-"`
+
+	// Phones the candidate and their agent sign in to the candidate portal with.
+	Mobile      string `json:"mobile,omitempty"`
+	AgentMobile string `json:"agent_mobile,omitempty"`
+
+	// Frozen with the final list; SerialNo 0 means no ballot has been generated.
+	SerialNo int    `json:"serial_no"`
+	Symbol   string `json:"symbol"`
+
+	IsNota bool `gorm:"-" json:"is_nota,omitempty"`
+}
+
+// CandidateDocument is a file submitted through the candidate portal. It only
+// takes effect once an admin approves it.
+type CandidateDocument struct {
+	BaseModel
+	CandidateID uint       `gorm:"index;not null" json:"candidate_id"`
+	Kind        string     `gorm:"not null" json:"kind"`                     // AFFIDAVIT, AFFIDAVIT_REDACTED, PHOTO, MANIFESTO
+	Status      string     `gorm:"default:'PENDING';not null" json:"status"` // PENDING, APPROVED, REJECTED
+	Path        string     `gorm:"not null" json:"path"`
+	FileName    string     `json:"file_name"`
+	SubmittedBy string     `json:"submitted_by"` // CANDIDATE or AGENT
+	ReviewNote  string     `json:"review_note,omitempty"`
+	ReviewedBy  uint       `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// NominationMatch pairs two nominations that appear to be the same person
+// standing twice in one election or in elections held at the same time.
+// CandidateID is always the lower of the two IDs.
+type NominationMatch struct {
+	BaseModel
+	CandidateID        uint       `gorm:"uniqueIndex:idx_nomination_match;not null" json:"candidate_id"`
+	MatchedCandidateID uint       `gorm:"uniqueIndex:idx_nomination_match;not null" json:"matched_candidate_id"`
+	Rule               string     `gorm:"not null" json:"rule"`                        // SAME_VOTER, SAME_NAME_DISTRICT, SAME_NAME
+	Scope              string     `gorm:"not null" json:"scope"`                       // SAME_ELECTION, CONCURRENT
+	Status             string     `gorm:"default:'OPEN';not null;index" json:"status"` // OPEN, CONFIRMED, DISMISSED
+	ReviewNote         string     `json:"review_note,omitempty"`
+	ReviewedBy         uint       `json:"reviewed_by,omitempty"`
+	ReviewedAt         *time.Time `json:"reviewed_at,omitempty"`
+
+	Candidate        *Candidate `gorm:"foreignKey:CandidateID;constraint:OnDelete:CASCADE" json:"candidate,omitempty"`
+	MatchedCandidate *Candidate `gorm:"foreignKey:MatchedCandidateID;constraint:OnDelete:CASCADE" json:"matched_candidate,omitempty"`
 }
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// CandidateDisclosure is the sworn declaration a candidate files with their
+// nomination. It is published on the candidate's public profile once an admin
+// has checked it against the affidavit.
+type CandidateDisclosure struct {
+	BaseModel
+	CandidateID uint `gorm:"uniqueIndex;not null" json:"candidate_id"`
+
+	Education  string `json:"education"`
+	Profession string `json:"profession"`
+
+	// Amounts in rupees, including those of the spouse and dependants.
+	MovableAssets   int64 `json:"movable_assets"`
+	ImmovableAssets int64 `json:"immovable_assets"`
+	Liabilities     int64 `json:"liabilities"`
+
+	// Never published.
+	Address string `json:"address"`
+
+	CriminalCases []CriminalCase `gorm:"foreignKey:DisclosureID;constraint:OnDelete:CASCADE" json:"criminal_cases"`
+
+	Status     string     `gorm:"default:'PENDING';not null" json:"status"` // PENDING, VERIFIED, REJECTED
+	ReviewNote string     `json:"review_note,omitempty"`
+	VerifiedBy uint       `json:"verified_by,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+type CriminalCase struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	DisclosureID uint   `gorm:"index;not null" json:"-"`
+	CaseNumber   string `gorm:"not null" json:"case_number"`
+	Court        string `gorm:"not null" json:"court"`
+	Sections     string `json:"sections"`
+	Status       string `gorm:"not null" json:"status"` // PENDING, CONVICTED
+	Description  string `json:"description"`
+}
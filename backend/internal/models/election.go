@@ -16,7 +16,62 @@ type Election struct {
 	LocalBodyName string `json:"local_body_name"`
 	Ward          string `json:"ward"`
 
+	// Reservation restricts who may stand (GENERAL, WOMEN, SC, SC_WOMEN, ST,
+	// ST_WOMEN), usually taken from the ward rotation of DelimitationCycle.
+	Reservation       string `gorm:"default:'GENERAL'" json:"reservation"`
+	DelimitationCycle string `json:"delimitation_cycle"`
+
+	// Nominations close at NominationDeadline and accepted candidates may
+	// withdraw until WithdrawalDeadline; either may be left open.
+	NominationDeadline *time.Time `json:"nomination_deadline"`
+	WithdrawalDeadline *time.Time `json:"withdrawal_deadline"`
+	BallotFrozenAt     *time.Time `json:"ballot_frozen_at"`
+
+	// Contesting candidates file expenditure accounts by ExpenditureDeadline,
+	// or, when it is not set, a fixed number of days after the declaration.
+	ExpenditureDeadline *time.Time `json:"expenditure_deadline"`
+
 	IsActive    bool   `gorm:"default:false" json:"is_active"`
 	IsPublished bool   `gorm:"default:false" json:"is_published"`
 	Status      string `gorm:"default:'UPCOMING'" json:"status"`
+
+	BallotType    string `gorm:"default:'SINGLE'" json:"ballot_type"` // SINGLE, RANKED, APPROVAL, REFERENDUM
+	Seats         int    `gorm:"default:1" json:"seats"`
+	MaxSelections int    `gorm:"default:1" json:"max_selections"`
+	AllowNota     bool   `gorm:"default:false" json:"allow_nota"`
+
+	// Weighted elections count each ballot by the voter's frozen roll weight.
+	Weighted        bool       `gorm:"default:false" json:"weighted"`
+	RollFinalizedAt *time.Time `json:"roll_finalized_at"`
+
+	// Indirect elections choose a local body's president or chairperson
+	// (Office) from its elected ward members. A second round, if needed,
+	// is a new election pointing back at the previous one.
+	Indirect        bool   `gorm:"default:false" json:"indirect"`
+	Office          string `json:"office"`
+	OpenBallot      bool   `gorm:"default:false" json:"open_ballot"`
+	Round           int    `gorm:"default:1" json:"round"`
+	PreviousRoundID *uint  `json:"previous_round_id"`
+}
+
+// WardReservation is one row of an imported reservation rotation: the
+// category a ward (or, with an empty Ward, the head of the local body) is
+// reserved for during a delimitation cycle.
+type WardReservation struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	Cycle         string `gorm:"uniqueIndex:idx_ward_reservation;not null" json:"cycle"`
+	District      string `gorm:"uniqueIndex:idx_ward_reservation;not null" json:"district"`
+	ElectionType  string `gorm:"uniqueIndex:idx_ward_reservation;not null" json:"election_type"`
+	LocalBodyName string `gorm:"uniqueIndex:idx_ward_reservation;not null" json:"local_body_name"`
+	Ward          string `gorm:"uniqueIndex:idx_ward_reservation" json:"ward"`
+	Reservation   string `gorm:"not null" json:"reservation"`
+}
+
+// RollEntry is a voter's weight in a weighted election, frozen when the roll
+// is finalised so later share transfers cannot change it.
+type RollEntry struct {
+	ElectionID uint  `gorm:"primaryKey;autoIncrement:false" json:"election_id"`
+	VoterID    uint  `gorm:"primaryKey;autoIncrement:false" json:"voter_id"`
+	Shares     int64 `gorm:"not null" json:"shares"`
+	Weight     int64 `gorm:"not null" json:"weight"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ExpenditureLimit is the spending cap of a candidate in one type of election,
+// in rupees.
+type ExpenditureLimit struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	ElectionType string `gorm:"uniqueIndex;not null" json:"election_type"`
+	Amount       int64  `gorm:"not null" json:"amount"`
+}
+
+// ExpenditureStatement is a candidate's account of election expenses. Items
+// can be added until it is filed.
+type ExpenditureStatement struct {
+	BaseModel
+	CandidateID uint              `gorm:"uniqueIndex;not null" json:"candidate_id"`
+	Items       []ExpenditureItem `gorm:"foreignKey:StatementID;constraint:OnDelete:CASCADE" json:"items"`
+	Total       int64             `json:"total"`
+	FiledAt     *time.Time        `json:"filed_at"`
+	FiledBy     string            `json:"filed_by,omitempty"` // CANDIDATE, AGENT or ADMIN
+}
+
+type ExpenditureItem struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	StatementID uint      `gorm:"index;not null" json:"-"`
+	Date        time.Time `gorm:"not null" json:"date"`
+	Category    string    `gorm:"not null" json:"category"` // PUBLICITY, MEETINGS, VEHICLES, STAFF, OTHER
+	Description string    `json:"description"`
+	PaidTo      string    `json:"paid_to"`
+	Amount      int64     `gorm:"not null" json:"amount"`
+}
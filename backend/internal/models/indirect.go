@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// IndirectElector is an elected ward member entitled to vote in an indirect
+// election, recorded from the declared result of their ward election.
+type IndirectElector struct {
+	ElectionID     uint   `gorm:"primaryKey;autoIncrement:false" json:"election_id"`
+	VoterID        uint   `gorm:"primaryKey;autoIncrement:false" json:"voter_id"`
+	WardElectionID uint   `gorm:"not null" json:"ward_election_id"`
+	CandidateID    uint   `gorm:"not null" json:"candidate_id"`
+	Ward           string `json:"ward"`
+	FullName       string `json:"full_name"`
+}
+
+// OpenBallotRecord is kept only for open-ballot indirect elections, where
+// how each member voted is part of the record.
+type OpenBallotRecord struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ElectionID  uint      `gorm:"index;not null" json:"election_id"`
+	VoterID     uint      `gorm:"not null" json:"voter_id"`
+	CandidateID uint      `gorm:"not null" json:"candidate_id"`
+	Timestamp   time.Time `json:"timestamp"`
+}
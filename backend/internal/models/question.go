@@ -0,0 +1,49 @@
+package models
+
+// BallotQuestion is a referendum question on a REFERENDUM election's ballot.
+// QuorumPercent is the turnout (of the eligible electorate) needed for the
+// outcome to count. ThresholdPercent is the share of the question's answers the
+// leading option needs; 0 means it only has to lead.
+type BallotQuestion struct {
+	BaseModel
+	ElectionID       uint           `gorm:"index;not null" json:"election_id"`
+	Position         int            `gorm:"not null;default:1" json:"position"`
+	Text             string         `gorm:"not null" json:"text"`
+	Kind             string         `gorm:"not null;default:'YES_NO'" json:"kind"` // YES_NO, MULTIPLE_CHOICE
+	MaxChoices       int            `gorm:"default:1" json:"max_choices"`
+	QuorumPercent    float64        `gorm:"default:0" json:"quorum_percent"`
+	ThresholdPercent float64        `gorm:"default:0" json:"threshold_percent"`
+	Options          []BallotOption `gorm:"foreignKey:QuestionID" json:"options"`
+}
+
+type BallotOption struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	QuestionID uint   `gorm:"index;not null" json:"question_id"`
+	Position   int    `gorm:"not null" json:"position"`
+	Label      string `gorm:"not null" json:"label"`
+}
+
+// BallotAnswer is one option marked on a referendum ballot. Like candidate
+// selections it is tied to the anonymous Vote, never to the voter.
+type BallotAnswer struct {
+	ID           uint `gorm:"primaryKey"`
+	VoteID       uint `gorm:"index;not null"`
+	ElectionID   uint `gorm:"index;not null"`
+	QuestionID   uint `gorm:"index;not null"`
+	OptionID     uint `gorm:"not null"`
+	BlockchainTx string
+}
+
+// OptionTally and QuestionTally are kept in the vote transaction alongside the
+// candidate tallies. Answered counts the ballots that answered the question.
+type OptionTally struct {
+	QuestionID uint  `gorm:"primaryKey;autoIncrement:false" json:"question_id"`
+	OptionID   uint  `gorm:"primaryKey;autoIncrement:false" json:"option_id"`
+	VoteCount  int64 `gorm:"not null;default:0" json:"vote_count"`
+}
+
+type QuestionTally struct {
+	QuestionID uint  `gorm:"primaryKey;autoIncrement:false" json:"question_id"`
+	ElectionID uint  `gorm:"index;not null" json:"election_id"`
+	Answered   int64 `gorm:"not null;default:0" json:"answered"`
+}
@@ -0,0 +1,122 @@
+package models
+
+import "time"
+
+type ElectionResult struct {
+	BaseModel
+	ElectionID uint       `gorm:"uniqueIndex;not null" json:"election_id"`
+	Seats      int        `gorm:"default:1" json:"seats"`
+	TotalVotes int64      `json:"total_votes"`
+	Margin     int64      `json:"margin"`
+	DecidedBy  string     `json:"decided_by,omitempty"` // VOTES, or the tie-break method when Margin is 0 by a draw
+	IsTie      bool       `gorm:"default:false" json:"is_tie"`
+	Status     string     `gorm:"default:'PROVISIONAL'" json:"status"` // PROVISIONAL, TIE_PENDING, DECLARED
+	DeclaredBy uint       `json:"declared_by"`
+	DeclaredAt *time.Time `json:"declared_at"`
+
+	// NOTA is counted and reported but never ranked against the candidates.
+	NotaOffered bool    `gorm:"default:false" json:"nota_offered"`
+	NotaVotes   int64   `json:"nota_votes"`
+	NotaShare   float64 `json:"nota_share"`
+	NotaNote    string  `gorm:"-" json:"nota_note,omitempty"`
+
+	// In a weighted election the vote counts are weighted and Headcount is
+	// the number of ballots behind them.
+	Weighted  bool  `gorm:"default:false" json:"weighted"`
+	Headcount int64 `json:"headcount"`
+
+	Entries  []ElectionResultEntry `gorm:"foreignKey:ResultID" json:"entries"`
+	Rounds   []RunoffRound         `gorm:"serializer:json;type:text" json:"rounds,omitempty"`
+	TieBreak *TieBreak             `gorm:"-" json:"tie_break,omitempty"`
+	// TieBreaks lists every tie-break applied, in the order they were used.
+	TieBreaks []TieBreak `gorm:"-" json:"tie_breaks,omitempty"`
+
+	// PendingTie lists the candidates a tie-break has to decide between and
+	// TieSeats how many of them it keeps.
+	PendingTie []uint `gorm:"-" json:"pending_tie,omitempty"`
+	TieSeats   int    `gorm:"-" json:"-"`
+
+	// NeedsSecondRound is set on the first round of an indirect election
+	// when no candidate holds a majority of the votes cast.
+	NeedsSecondRound bool `gorm:"-" json:"needs_second_round,omitempty"`
+
+	Reservation string `gorm:"-" json:"reservation,omitempty"`
+
+	Alliances []AllianceTotal `gorm:"-" json:"alliances,omitempty"`
+}
+
+// AllianceTotal is a front's share of one election result, counted with the
+// membership in force when the election started.
+type AllianceTotal struct {
+	Alliance  string  `json:"alliance"`
+	Votes     int64   `json:"votes"`
+	VoteShare float64 `json:"vote_share"`
+	SeatsWon  int     `json:"seats_won"`
+}
+
+// RunoffRound is one count of an instant-runoff election. Transfers show where
+// the ballots of the candidates eliminated in this round went; To 0 means the
+// ballot had no further preference and is exhausted.
+type RunoffRound struct {
+	Round      int              `json:"round"`
+	Counts     []RunoffCount    `json:"counts"`
+	Continuing int64            `json:"continuing"`
+	Exhausted  int64            `json:"exhausted"`
+	Eliminated []uint           `json:"eliminated,omitempty"`
+	Elected    []uint           `json:"elected,omitempty"`
+	Transfers  []RunoffTransfer `json:"transfers,omitempty"`
+}
+
+type RunoffCount struct {
+	CandidateID uint  `json:"candidate_id"`
+	Votes       int64 `json:"votes"`
+}
+
+type RunoffTransfer struct {
+	From  uint  `json:"from"`
+	To    uint  `json:"to"`
+	Votes int64 `json:"votes"`
+}
+
+type ElectionResultEntry struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	ResultID      uint    `gorm:"index;not null" json:"-"`
+	CandidateID   uint    `gorm:"not null" json:"candidate_id"`
+	CandidateName string  `json:"candidate_name"`
+	PartyName     string  `json:"party_name"`
+	Independent   bool    `json:"independent"`
+	Alliance      string  `json:"alliance"`
+	SerialNo      int     `json:"serial_no"`
+	Symbol        string  `json:"symbol"`
+	VoteCount     int64   `json:"vote_count"`
+	VoteShare     float64 `json:"vote_share"`
+	Headcount     int64   `json:"headcount"`
+	Rank          int     `json:"rank"`
+	IsElected     bool    `gorm:"default:false" json:"is_elected"`
+	WonByTieBreak bool    `gorm:"default:false" json:"won_by_tie_break"`
+}
+
+// TieBreak records how a tie was resolved by the returning officer. An
+// election can hold one per tied set: a ranked count may need a draw in more
+// than one round.
+type TieBreak struct {
+	BaseModel
+	ElectionID         uint   `gorm:"uniqueIndex:idx_tie_break;not null" json:"election_id"`
+	Method             string `gorm:"not null" json:"method"` // DRAW_OF_LOTS, RANDOM_DRAW
+	TiedCandidateIDs   string `gorm:"uniqueIndex:idx_tie_break;not null" json:"tied_candidate_ids"`
+	WinnerCandidateIDs string `gorm:"not null" json:"winner_candidate_ids"`
+	DrawOrder          string `json:"draw_order,omitempty"`
+	Notes              string `json:"notes"`
+	RecordedBy         uint   `json:"recorded_by"`
+}
+
+// ResultFeedRevision numbers the published media feed documents of an election.
+// Seq only moves when the document content (Digest) actually changes.
+// ResultStatus is kept alongside so the manifest needs no result count.
+type ResultFeedRevision struct {
+	ElectionID   uint   `gorm:"primaryKey;autoIncrement:false" json:"election_id"`
+	Seq          int64  `gorm:"not null;default:1" json:"seq"`
+	Digest       string `gorm:"not null" json:"-"`
+	ResultStatus string `gorm:"not null;default:''" json:"result_status"`
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// CandidateTally and ElectionTally are maintained in the same transaction as
+// each vote so results never have to count the votes table. The counts are
+// headcounts; the weighted columns add up the vote weights alongside them.
+type CandidateTally struct {
+	ElectionID    uint  `gorm:"primaryKey;autoIncrement:false" json:"election_id"`
+	CandidateID   uint  `gorm:"primaryKey;autoIncrement:false" json:"candidate_id"`
+	VoteCount     int64 `gorm:"not null;default:0" json:"vote_count"`
+	WeightedCount int64 `gorm:"not null;default:0" json:"weighted_count"`
+	UpdatedAt     time.Time
+}
+
+type ElectionTally struct {
+	ElectionID    uint  `gorm:"primaryKey;autoIncrement:false" json:"election_id"`
+	TotalVotes    int64 `gorm:"not null;default:0" json:"total_votes"`
+	WeightedTotal int64 `gorm:"not null;default:0" json:"weighted_total"`
+	Version       int64 `gorm:"not null;default:0" json:"version"`
+	UpdatedAt     time.Time
+}
@@ -9,6 +9,9 @@ type Vote struct {
 	VoteHash     string `gorm:"uniqueIndex;not null"`
 	BlockchainTx string
 	Timestamp    time.Time
+
+	// Weight is 1 except in weighted elections.
+	Weight int64 `gorm:"not null;default:1"`
 }
 
 type ElectionParticipation struct {
@@ -17,3 +20,16 @@ type ElectionParticipation struct {
 	ElectionID uint `gorm:"uniqueIndex:idx_voter_election"`
 	Timestamp  time.Time
 }
+
+// VoteSelection holds the candidates marked on a ballot that carries more than
+// a single choice. Rank is the preference order on a ranked ballot and the
+// marking order on an approval ballot, where each selection is anchored on
+// chain on its own.
+type VoteSelection struct {
+	ID           uint `gorm:"primaryKey"`
+	VoteID       uint `gorm:"index;not null"`
+	ElectionID   uint `gorm:"index;not null"`
+	CandidateID  uint `gorm:"not null"`
+	Rank         int  `gorm:"not null"`
+	BlockchainTx string
+}
@@ -20,6 +20,10 @@ type Voter struct {
 	Panchayath string `json:"Panchayath"`
 	Ward       string `json:"Ward"`
 
+	// Shares is the holding imported with the roll; weighted elections
+	// freeze it into each voter's vote weight when the roll is finalised.
+	Shares int64 `gorm:"default:0" json:"Shares"`
+
 	CurrentOTP   string         `json:"-"`
 	OTPExpiresAt time.Time      `json:"-"`
 	IsVerified   bool           `gorm:"default:false" json:"IsVerified"`
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// WebhookSubscription is an external endpoint that is pushed matching events.
+// Events is a comma separated list of event names, or "*" for all of them.
+type WebhookSubscription struct {
+	BaseModel
+	Name      string `gorm:"not null" json:"name"`
+	URL       string `gorm:"not null" json:"url"`
+	Secret    string `gorm:"not null" json:"-"`
+	Events    string `gorm:"not null;default:'*'" json:"events"`
+	IsActive  bool   `gorm:"default:true" json:"is_active"`
+	CreatedBy uint   `json:"created_by"`
+}
+
+type WebhookDelivery struct {
+	BaseModel
+	SubscriptionID uint       `gorm:"index;not null" json:"subscription_id"`
+	EventID        string     `gorm:"index;not null" json:"event_id"`
+	Event          string     `gorm:"not null" json:"event"`
+	Payload        string     `gorm:"type:text" json:"payload"`
+	Status         string     `gorm:"index;default:'PENDING'" json:"status"` // PENDING, DELIVERED, FAILED
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  *time.Time `gorm:"index" json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	RedeliveryOf   *uint      `json:"redelivery_of,omitempty"`
+}
+
+// TurnoutMilestone marks a turnout threshold already announced for an election,
+// so each one is only pushed once across restarts and replicas.
+type TurnoutMilestone struct {
+	ElectionID uint `gorm:"primaryKey;autoIncrement:false" json:"election_id"`
+	Percent    int  `gorm:"primaryKey;autoIncrement:false" json:"percent"`
+	ReachedAt  time.Time
+}
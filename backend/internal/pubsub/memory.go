@@ -0,0 +1,64 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// MemoryBroker delivers events within a single process, in publish order.
+// It backs single-replica setups and tests.
+type MemoryBroker struct {
+	seq    *sequencer
+	pubMu  sync.Mutex
+	queue  chan Event
+	once   sync.Once
+	closed chan struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	b := &MemoryBroker{
+		seq:    newSequencer(),
+		queue:  make(chan Event, 256),
+		closed: make(chan struct{}),
+	}
+	go b.loop()
+	return b
+}
+
+func (b *MemoryBroker) loop() {
+	for {
+		select {
+		case e := <-b.queue:
+			b.seq.dispatch(e)
+		case <-b.closed:
+			return
+		}
+	}
+}
+
+// Publish is serialised so events are queued in the order they were stamped;
+// otherwise the sequencer would drop the earlier one as stale.
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+
+	e := b.seq.stamp(topic, payload)
+	select {
+	case b.queue <- e:
+		return nil
+	case <-b.closed:
+		return errors.New("broker closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *MemoryBroker) Subscribe(handler func(Event)) {
+	b.seq.subscribe(handler)
+}
+
+func (b *MemoryBroker) Close() error {
+	b.once.Do(func() { close(b.closed) })
+	return nil
+}
@@ -0,0 +1,109 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	pgChannel       = "evoting_realtime"
+	pgMaxPayload    = 7900 // NOTIFY payloads are capped at 8000 bytes
+	pgReconnectWait = 2 * time.Second
+)
+
+// PostgresBroker fans events out to every replica through LISTEN/NOTIFY. The
+// listener holds its own connection; notifications go through the shared pool.
+type PostgresBroker struct {
+	dsn    string
+	db     *gorm.DB
+	seq    *sequencer
+	pubMu  sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewPostgresBroker(dsn string, db *gorm.DB) *PostgresBroker {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &PostgresBroker{
+		dsn:    dsn,
+		db:     db,
+		seq:    newSequencer(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	go b.listen()
+	return b
+}
+
+// Publish is serialised so NOTIFYs from this replica reach Postgres in sequence order.
+func (b *PostgresBroker) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+
+	data, err := json.Marshal(b.seq.stamp(topic, payload))
+	if err != nil {
+		return err
+	}
+	if len(data) > pgMaxPayload {
+		return errors.New("realtime event too large for NOTIFY")
+	}
+
+	return b.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", pgChannel, string(data)).Error
+}
+
+func (b *PostgresBroker) Subscribe(handler func(Event)) {
+	b.seq.subscribe(handler)
+}
+
+func (b *PostgresBroker) Close() error {
+	b.cancel()
+	return nil
+}
+
+func (b *PostgresBroker) listen() {
+	for {
+		if err := b.listenOnce(); err != nil && b.ctx.Err() == nil {
+			log.Printf("Realtime listener error: %v (reconnecting)", err)
+		}
+
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(pgReconnectWait):
+		}
+	}
+}
+
+func (b *PostgresBroker) listenOnce() error {
+	conn, err := pgx.Connect(b.ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(b.ctx, "LISTEN "+pgChannel); err != nil {
+		return err
+	}
+	log.Println(" Realtime listener attached to Postgres")
+
+	for {
+		n, err := conn.WaitForNotification(b.ctx)
+		if err != nil {
+			return err
+		}
+
+		var e Event
+		if err := json.Unmarshal([]byte(n.Payload), &e); err != nil {
+			log.Printf("Realtime listener: bad payload: %v", err)
+			continue
+		}
+		b.seq.dispatch(e)
+	}
+}
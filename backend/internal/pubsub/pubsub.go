@@ -0,0 +1,93 @@
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+// Event is one realtime message fanned out to every replica. Seq increases per
+// (Origin, Topic) so receivers can drop duplicates and stale deliveries.
+type Event struct {
+	ID      string          `json:"id"`
+	Origin  string          `json:"origin"`
+	Topic   string          `json:"topic"`
+	Seq     uint64          `json:"seq"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type Broker interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	Subscribe(handler func(Event))
+	Close() error
+}
+
+// InstanceID identifies this process as the origin of the events it publishes.
+var InstanceID = newID()
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// sequencer stamps outgoing events and filters incoming ones.
+type sequencer struct {
+	mu       sync.Mutex
+	next     map[string]uint64
+	last     map[string]uint64
+	handlers []func(Event)
+}
+
+func newSequencer() *sequencer {
+	return &sequencer{
+		next: make(map[string]uint64),
+		last: make(map[string]uint64),
+	}
+}
+
+func (s *sequencer) stamp(topic string, payload []byte) Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.next[topic]++
+	seq := s.next[topic]
+	return Event{
+		ID:      InstanceID + ":" + topic + ":" + strconv.FormatUint(seq, 10),
+		Origin:  InstanceID,
+		Topic:   topic,
+		Seq:     seq,
+		Payload: payload,
+	}
+}
+
+func (s *sequencer) subscribe(handler func(Event)) {
+	s.mu.Lock()
+	s.handlers = append(s.handlers, handler)
+	s.mu.Unlock()
+}
+
+// dispatch hands the event to subscribers unless it was already seen or
+// arrives behind a later event from the same origin and topic.
+func (s *sequencer) dispatch(e Event) {
+	key := e.Origin + "|" + e.Topic
+
+	s.mu.Lock()
+	if e.Seq <= s.last[key] {
+		s.mu.Unlock()
+		return
+	}
+	s.last[key] = e.Seq
+	handlers := make([]func(Event), len(s.handlers))
+	copy(handlers, s.handlers)
+	s.mu.Unlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
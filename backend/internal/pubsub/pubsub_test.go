@@ -0,0 +1,158 @@
+package pubsub
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSequencerDispatch(t *testing.T) {
+	ev := func(origin, topic string, seq uint64) Event {
+		return Event{Origin: origin, Topic: topic, Seq: seq}
+	}
+
+	tests := []struct {
+		name string
+		in   []Event
+		want []uint64
+	}{
+		{
+			name: "in order",
+			in:   []Event{ev("a", "t", 1), ev("a", "t", 2), ev("a", "t", 3)},
+			want: []uint64{1, 2, 3},
+		},
+		{
+			name: "duplicate is dropped",
+			in:   []Event{ev("a", "t", 1), ev("a", "t", 1), ev("a", "t", 2)},
+			want: []uint64{1, 2},
+		},
+		{
+			name: "stale delivery is dropped",
+			in:   []Event{ev("a", "t", 1), ev("a", "t", 3), ev("a", "t", 2)},
+			want: []uint64{1, 3},
+		},
+		{
+			name: "origins are sequenced apart",
+			in:   []Event{ev("a", "t", 5), ev("b", "t", 1)},
+			want: []uint64{5, 1},
+		},
+		{
+			name: "topics are sequenced apart",
+			in:   []Event{ev("a", "t", 5), ev("a", "u", 1)},
+			want: []uint64{5, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSequencer()
+			var got []uint64
+			s.subscribe(func(e Event) { got = append(got, e.Seq) })
+			for _, e := range tt.in {
+				s.dispatch(e)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("delivered %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequencerStamp(t *testing.T) {
+	s := newSequencer()
+	topics := []string{"t", "t", "u", "t"}
+	seqs := []uint64{1, 2, 1, 3}
+	for i, topic := range topics {
+		e := s.stamp(topic, []byte(`{}`))
+		want := seqs[i]
+		if e.Seq != want || e.Origin != InstanceID || e.Topic != topic {
+			t.Errorf("stamp %d = %+v, want seq %d on %s", i, e, want, topic)
+		}
+		if e.ID != InstanceID+":"+topic+":"+strconv.FormatUint(want, 10) {
+			t.Errorf("stamp %d id = %s", i, e.ID)
+		}
+	}
+}
+
+func TestMemoryBroker(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+
+	got := make(chan string, 8)
+	b.Subscribe(func(e Event) { got <- string(e.Payload) })
+
+	want := []string{`1`, `2`, `3`}
+	for _, p := range want {
+		if err := b.Publish(context.Background(), "t", []byte(p)); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	for _, w := range want {
+		select {
+		case p := <-got:
+			if p != w {
+				t.Errorf("delivered %s, want %s", p, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", w)
+		}
+	}
+
+	b.Close()
+	b.Close()
+	// A closed broker refuses work once its queue has no room left.
+	var err error
+	for i := 0; i <= cap(b.queue) && err == nil; i++ {
+		err = b.Publish(context.Background(), "t", []byte(`0`))
+	}
+	if err == nil {
+		t.Error("publish on a closed broker succeeded")
+	}
+}
+
+func TestMemoryBrokerConcurrentPublish(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+
+	const publishers, each = 8, 50
+	var mu sync.Mutex
+	var seqs []uint64
+	done := make(chan struct{})
+	b.Subscribe(func(e Event) {
+		mu.Lock()
+		seqs = append(seqs, e.Seq)
+		if len(seqs) == publishers*each {
+			close(done)
+		}
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				if err := b.Publish(context.Background(), "t", []byte(`{}`)); err != nil {
+					t.Errorf("publish: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		mu.Lock()
+		t.Fatalf("delivered %d of %d events", len(seqs), publishers*each)
+	}
+	for i, s := range seqs {
+		if s != uint64(i+1) {
+			t.Fatalf("event %d has seq %d, want %d", i, s, i+1)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// AllianceJoin adds the front a candidate's party belonged to on the
+// election's start date. The query must already join candidates and elections.
+const AllianceJoin = `LEFT JOIN alliance_memberships ON alliance_memberships.party_id = candidates.party_id
+	AND alliance_memberships.valid_from <= elections.start_date
+	AND (alliance_memberships.valid_to IS NULL OR alliance_memberships.valid_to > elections.start_date)
+	LEFT JOIN alliances ON alliances.id = alliance_memberships.alliance_id`
+
+// checkMembershipHistory refuses membership changes reaching back over an
+// election that has already been polled, so past results keep their fronts.
+func checkMembershipHistory(partyID uint, from time.Time, to *time.Time) error {
+	query := database.PostgresDB.Table("elections").
+		Joins("JOIN candidates ON candidates.election_id = elections.id").
+		Where("candidates.party_id = ? AND elections.start_date >= ?", partyID, from).
+		Where("EXISTS (SELECT 1 FROM votes WHERE votes.election_id = elections.id)")
+	if to != nil {
+		query = query.Where("elections.start_date < ?", *to)
+	}
+
+	var titles []string
+	query.Limit(1).Pluck("elections.title", &titles)
+	if len(titles) > 0 {
+		return fmt.Errorf("the change would alter the fronts of %s, which has already been polled", titles[0])
+	}
+	return nil
+}
+
+// CheckAllianceRename refuses to rename a front once its members have stood
+// in an election that has been polled, since results name the front as it is
+// called now.
+func CheckAllianceRename(allianceID uint) error {
+	var titles []string
+	database.PostgresDB.Table("elections").
+		Joins("JOIN candidates ON candidates.election_id = elections.id").
+		Joins(`JOIN alliance_memberships ON alliance_memberships.party_id = candidates.party_id
+			AND alliance_memberships.valid_from <= elections.start_date
+			AND (alliance_memberships.valid_to IS NULL OR alliance_memberships.valid_to > elections.start_date)`).
+		Where("alliance_memberships.alliance_id = ?", allianceID).
+		Where("EXISTS (SELECT 1 FROM votes WHERE votes.election_id = elections.id)").
+		Limit(1).Pluck("elections.title", &titles)
+	if len(titles) > 0 {
+		return fmt.Errorf("the front stood in %s, which has already been polled; create a new alliance instead", titles[0])
+	}
+	return nil
+}
+
+// AddAllianceMember starts a party's membership of a front. A party belongs to
+// at most one front at a time.
+func AddAllianceMember(allianceID, partyID uint, from time.Time, to *time.Time) (*models.AllianceMembership, error) {
+	if from.IsZero() {
+		return nil, errors.New("valid_from is required")
+	}
+	if to != nil && !to.After(from) {
+		return nil, errors.New("valid_to must be after valid_from")
+	}
+	if err := database.PostgresDB.First(&models.Alliance{}, allianceID).Error; err != nil {
+		return nil, errors.New("alliance not found")
+	}
+	var party models.Party
+	if err := database.PostgresDB.First(&party, partyID).Error; err != nil {
+		return nil, errors.New("party not found")
+	}
+
+	overlap := database.PostgresDB.Model(&models.AllianceMembership{}).
+		Where("party_id = ? AND (valid_to IS NULL OR valid_to > ?)", partyID, from)
+	if to != nil {
+		overlap = overlap.Where("valid_from < ?", *to)
+	}
+	var existing models.AllianceMembership
+	if err := overlap.First(&existing).Error; err == nil {
+		var other models.Alliance
+		database.PostgresDB.First(&other, existing.AllianceID)
+		return nil, fmt.Errorf("%s already belongs to %s from %s; end that membership first", party.Name, other.Name, existing.ValidFrom.Format("2006-01-02"))
+	}
+
+	if err := checkMembershipHistory(partyID, from, to); err != nil {
+		return nil, err
+	}
+
+	m := models.AllianceMembership{AllianceID: allianceID, PartyID: partyID, ValidFrom: from, ValidTo: to}
+	if err := database.PostgresDB.Create(&m).Error; err != nil {
+		return nil, errors.New("failed to add party to the alliance")
+	}
+	m.Party = party
+	return &m, nil
+}
+
+// EndAllianceMembership closes an open membership, typically when a party
+// leaves a front between elections.
+func EndAllianceMembership(membershipID uint, to time.Time) (*models.AllianceMembership, error) {
+	var m models.AllianceMembership
+	if err := database.PostgresDB.Preload("Party").First(&m, membershipID).Error; err != nil {
+		return nil, errors.New("membership not found")
+	}
+	if m.ValidTo != nil {
+		return nil, errors.New("membership has already ended")
+	}
+	if !to.After(m.ValidFrom) {
+		return nil, errors.New("valid_to must be after valid_from")
+	}
+	if err := checkMembershipHistory(m.PartyID, to, nil); err != nil {
+		return nil, err
+	}
+
+	if err := database.PostgresDB.Model(&m).Update("valid_to", to).Error; err != nil {
+		return nil, errors.New("failed to end membership")
+	}
+	m.ValidTo = &to
+	return &m, nil
+}
+
+// summarizeAlliances totals a result's entries by front. Independents and
+// parties outside every front are left out.
+func summarizeAlliances(result *models.ElectionResult) {
+	index := make(map[string]*models.AllianceTotal)
+	for _, e := range result.Entries {
+		if e.Alliance == "" {
+			continue
+		}
+		t, ok := index[e.Alliance]
+		if !ok {
+			t = &models.AllianceTotal{Alliance: e.Alliance}
+			index[e.Alliance] = t
+		}
+		t.Votes += e.VoteCount
+		if e.IsElected {
+			t.SeatsWon++
+		}
+	}
+
+	result.Alliances = nil
+	for _, t := range index {
+		if result.TotalVotes > 0 {
+			t.VoteShare = math.Round(float64(t.Votes)/float64(result.TotalVotes)*10000) / 100
+		}
+		result.Alliances = append(result.Alliances, *t)
+	}
+	sort.Slice(result.Alliances, func(i, j int) bool {
+		if result.Alliances[i].Votes != result.Alliances[j].Votes {
+			return result.Alliances[i].Votes > result.Alliances[j].Votes
+		}
+		return result.Alliances[i].Alliance < result.Alliances[j].Alliance
+	})
+}
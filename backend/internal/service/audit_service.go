@@ -6,6 +6,9 @@ import (
 	"time"
 )
 
+// OnAuditLogged, when set, receives every entry after it is saved (e.g. for the live audit feed).
+var OnAuditLogged func(models.AuditLog)
+
 func LogAdminAction(
 	actorID uint,
 	actorRole string,
@@ -22,14 +25,19 @@ func LogAdminAction(
 		now = time.Now().In(loc)
 	}
 
-	repository.SaveAuditLog(models.AuditLog{
+	entry := models.AuditLog{
 		ActorID:   actorID,
 		ActorRole: actorRole,
 		Action:    action,
 		TargetID:  targetID,
 		Metadata:  metadata,
 		Timestamp: now,
-	})
+	}
+	repository.SaveAuditLog(entry)
+
+	if OnAuditLogged != nil {
+		OnAuditLogged(entry)
+	}
 }
 
 func FetchAuditLogs() ([]models.AuditLog, error) {
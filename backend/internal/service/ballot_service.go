@@ -0,0 +1,172 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"fmt"
+)
+
+const (
+	BallotSingle   = "SINGLE"
+	BallotRanked   = "RANKED"
+	BallotApproval = "APPROVAL"
+
+	// NotaCandidateID is recorded, tallied and anchored in place of a
+	// candidate when the voter picks None of the Above.
+	NotaCandidateID uint = 0
+	NotaLabel            = "None of the Above"
+)
+
+// BallotInput is what a voter marked: one candidate, an approval set or a
+// ranked preference list, depending on the election's ballot type.
+type BallotInput struct {
+	CandidateID  uint
+	CandidateIDs []uint
+	Preferences  []uint
+	Nota         bool
+}
+
+func ValidBallotType(t string) bool {
+	return t == BallotSingle || t == BallotRanked || t == BallotApproval || t == BallotReferendum
+}
+
+// NormalizeSeats applies the seat and selection rules of a ballot type:
+// ranked ballots elect one member, single-choice ballots allow one mark,
+// approval ballots default to one mark per seat and referendums elect no one
+// (choices are set per question).
+func NormalizeSeats(ballotType string, seats, maxSelections int) (int, int, error) {
+	if seats == 0 {
+		seats = 1
+	}
+	if seats < 1 {
+		return 0, 0, errors.New("seats must be at least 1")
+	}
+
+	switch ballotType {
+	case BallotReferendum:
+		return 1, 1, nil
+	case BallotRanked:
+		if seats != 1 {
+			return 0, 0, errors.New("ranked elections elect a single member")
+		}
+		return 1, 1, nil
+	case BallotApproval:
+		if maxSelections == 0 {
+			maxSelections = seats
+		}
+		if maxSelections < 1 {
+			return 0, 0, errors.New("max selections must be at least 1")
+		}
+		return seats, maxSelections, nil
+	default:
+		if maxSelections > 1 {
+			return 0, 0, errors.New("single-choice elections allow one selection; use an APPROVAL ballot")
+		}
+		return seats, 1, nil
+	}
+}
+
+// ValidateNota checks that NOTA is only offered on ballots where it is a
+// single, exclusive mark.
+func ValidateNota(ballotType string, allowNota bool) error {
+	if allowNota && ballotType == BallotRanked {
+		return errors.New("NOTA is not available on ranked ballots")
+	}
+	if allowNota && ballotType == BallotReferendum {
+		return errors.New("NOTA is not available on referendums")
+	}
+	return nil
+}
+
+// NotaCandidate is the synthetic ballot entry shown to voters when NOTA is enabled.
+func NotaCandidate(electionID uint) models.Candidate {
+	return models.Candidate{
+		FullName:   NotaLabel,
+		ElectionID: electionID,
+		Party:      &models.Party{Name: "NOTA"},
+		IsNota:     true,
+	}
+}
+
+func electionCandidateSet(electionID uint) (map[uint]bool, error) {
+	var ids []uint
+	if err := database.PostgresDB.Model(&models.Candidate{}).
+		Where("election_id = ? AND nomination_status = ?", electionID, NominationFinal).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// ValidateBallot checks the choices on a ballot against the election's ballot
+// type and returns them in order. A single-choice ballot yields one ID.
+func ValidateBallot(e models.Election, in BallotInput) ([]uint, error) {
+	var choices []uint
+
+	if in.Nota {
+		if !e.AllowNota {
+			return nil, errors.New("NOTA is not available in this election")
+		}
+		if in.CandidateID != 0 || len(in.CandidateIDs) > 0 || len(in.Preferences) > 0 {
+			return nil, errors.New("NOTA cannot be combined with other choices")
+		}
+		return []uint{NotaCandidateID}, nil
+	}
+
+	switch e.BallotType {
+	case BallotRanked:
+		choices = in.Preferences
+		if len(choices) == 0 && in.CandidateID != 0 {
+			choices = []uint{in.CandidateID}
+		}
+		if len(choices) == 0 {
+			return nil, errors.New("rank at least one candidate")
+		}
+	case BallotApproval:
+		choices = in.CandidateIDs
+		if len(choices) == 0 && in.CandidateID != 0 {
+			choices = []uint{in.CandidateID}
+		}
+		if len(choices) == 0 {
+			return nil, errors.New("select at least one candidate")
+		}
+		if len(choices) > e.MaxSelections {
+			return nil, fmt.Errorf("select at most %d candidate(s)", e.MaxSelections)
+		}
+	default:
+		candidateID := in.CandidateID
+		picked := append(append([]uint{}, in.CandidateIDs...), in.Preferences...)
+		if len(picked) > 1 || (candidateID != 0 && len(picked) == 1 && picked[0] != candidateID) {
+			return nil, errors.New("this election accepts a single choice")
+		}
+		if candidateID == 0 && len(picked) == 1 {
+			candidateID = picked[0]
+		}
+		if candidateID == 0 {
+			return nil, errors.New("select a candidate")
+		}
+		choices = []uint{candidateID}
+	}
+
+	valid, err := electionCandidateSet(e.ID)
+	if err != nil {
+		return nil, errors.New("failed to load candidates")
+	}
+
+	seen := make(map[uint]bool, len(choices))
+	for _, id := range choices {
+		if seen[id] {
+			return nil, errors.New("a candidate may only be selected once")
+		}
+		if !valid[id] {
+			return nil, errors.New("ballot contains a candidate who is not standing in this election")
+		}
+		seen[id] = true
+	}
+	return choices, nil
+}
@@ -12,9 +12,12 @@ import (
 
 	"E-voting/internal/blockchain/contract"
 	"E-voting/internal/config"
+	"E-voting/internal/database"
+	"E-voting/internal/models"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
@@ -98,6 +101,19 @@ func CreateElectionOnChain(electionID uint, startTime int64, endTime int64) (str
 
 // Function to write vote to blockchain
 func CastVoteOnChain(electionID uint, candidateID uint, voterID uint) (string, error) {
+	return castOnChain(electionID, candidateID, fmt.Sprintf("%d", voterID), 1)
+}
+
+// CastSelectionOnChain anchors one selection of an approval ballot. The contract
+// accepts a single castVote per voter ID, so each selection is keyed by voter
+// and candidate; the per-candidate counts stay readable with getVotes.
+func CastSelectionOnChain(electionID uint, candidateID uint, voterID uint) (string, error) {
+	return castOnChain(electionID, candidateID, fmt.Sprintf("%d/%d", voterID, candidateID), 1)
+}
+
+// castOnChain writes one ballot. A weight above 1 goes through
+// castWeightedVote, which adds it to the candidate's public weighted count.
+func castOnChain(electionID uint, candidateID uint, voterKey string, weight int64) (string, error) {
 	if !isReady || instance == nil {
 		return "", errors.New("blockchain service not ready")
 	}
@@ -112,11 +128,17 @@ func CastVoteOnChain(electionID uint, candidateID uint, voterID uint) (string, e
 	eID := new(big.Int).SetUint64(uint64(electionID))
 	cID := new(big.Int).SetUint64(uint64(candidateID))
 	salt := config.Config.JWTSecret
-	voterData := fmt.Sprintf("%d-%s", voterID, salt)
+	voterData := fmt.Sprintf("%s-%s", voterKey, salt)
 	hashBytes := sha256.Sum256([]byte(voterData))
 	vID := new(big.Int).SetBytes(hashBytes[:])
 
-	tx, err := instance.CastVote(auth, eID, cID, vID)
+	var tx *types.Transaction
+	var err error
+	if weight > 1 {
+		tx, err = instance.CastWeightedVote(auth, eID, cID, vID, big.NewInt(weight))
+	} else {
+		tx, err = instance.CastVote(auth, eID, cID, vID)
+	}
 	if err != nil {
 		log.Printf(" Blockchain Vote Failed: %v", err)
 		return "", err
@@ -126,6 +148,98 @@ func CastVoteOnChain(electionID uint, candidateID uint, voterID uint) (string, e
 	return tx.Hash().Hex(), nil
 }
 
+// AnchorVote writes a recorded vote to the chain, resyncing elections and
+// retrying once on failure, then stores the transaction hash.
+func AnchorVote(eID, cID, vID uint, vHash string) {
+	txHash, err := CastVoteOnChain(eID, cID, vID)
+	if err != nil {
+		log.Printf("CRITICAL: Blockchain write failed for VoteHash %s. Error: %v", vHash, err)
+
+		SyncElectionsLogic()
+
+		log.Printf(" [Auto-Fix] Retrying Vote for %s...", vHash)
+		txHash, err = CastVoteOnChain(eID, cID, vID)
+
+		if err != nil {
+			log.Printf(" [Auto-Fix] Critical Failure: Retry also failed. Error: %v", err)
+			return
+		}
+	}
+
+	log.Printf(" !!! Vote written to blockchain! Tx: %s", txHash)
+	result := database.PostgresDB.Model(&models.Vote{}).
+		Where("vote_hash = ?", vHash).
+		Update("blockchain_tx", txHash)
+
+	if result.Error != nil {
+		log.Printf("ERROR: Failed to update DB with TxHash %s: %v", txHash, result.Error)
+	} else {
+		log.Printf("SUCCESS: Database updated with TxHash: %s", txHash)
+	}
+}
+
+// AnchorWeightedVote writes a single-choice vote of a weighted election to the
+// chain as one transaction carrying its weight, so getVotes counts the ballot
+// and getWeightedVotes its weight.
+func AnchorWeightedVote(voteHash string, voterID uint) error {
+	var vote models.Vote
+	if err := database.PostgresDB.Where("vote_hash = ?", voteHash).First(&vote).Error; err != nil {
+		return err
+	}
+	if vote.BlockchainTx != "" {
+		return nil
+	}
+
+	txHash, err := castOnChain(vote.ElectionID, vote.CandidateID, fmt.Sprintf("%d", voterID), vote.Weight)
+	if err != nil {
+		log.Printf("CRITICAL: Blockchain write failed for VoteHash %s. Error: %v", voteHash, err)
+		return err
+	}
+	return database.PostgresDB.Model(&vote).Update("blockchain_tx", txHash).Error
+}
+
+// AnchorSelections writes every not yet anchored selection of an approval
+// ballot to the chain, one transaction per selection carrying the ballot's
+// weight. The vote only gets its transaction hash once all of its selections
+// are anchored, so RetryVotesLogic picks up partial ballots.
+func AnchorSelections(voteHash string, voterID uint) error {
+	var vote models.Vote
+	if err := database.PostgresDB.Where("vote_hash = ?", voteHash).First(&vote).Error; err != nil {
+		return err
+	}
+
+	var selections []models.VoteSelection
+	if err := database.PostgresDB.Where("vote_id = ? AND (blockchain_tx = '' OR blockchain_tx IS NULL)", vote.ID).
+		Order("rank asc").Find(&selections).Error; err != nil {
+		return err
+	}
+
+	txHash := ""
+	for _, s := range selections {
+		hash, err := castOnChain(vote.ElectionID, s.CandidateID, fmt.Sprintf("%d/%d", voterID, s.CandidateID), vote.Weight)
+		if err != nil {
+			log.Printf("CRITICAL: Blockchain write failed for selection %d of VoteHash %s. Error: %v", s.ID, voteHash, err)
+			return err
+		}
+		// The selection is on chain now; failing to record that would anchor
+		// it a second time on the next retry, so stop and report instead.
+		if err := database.PostgresDB.Model(&s).Update("blockchain_tx", hash).Error; err != nil {
+			log.Printf("CRITICAL: Selection %d of VoteHash %s anchored in tx %s but not recorded: %v", s.ID, voteHash, hash, err)
+			return err
+		}
+		txHash = hash
+	}
+
+	if txHash == "" {
+		var last models.VoteSelection
+		if err := database.PostgresDB.Where("vote_id = ?", vote.ID).Order("rank desc").First(&last).Error; err != nil {
+			return err
+		}
+		txHash = last.BlockchainTx
+	}
+	return database.PostgresDB.Model(&vote).Update("blockchain_tx", txHash).Error
+}
+
 // Function to read votes from blockchain
 func GetVotesFromChain(electionID uint, candidateID uint) (int64, error) {
 	if !isReady || instance == nil {
@@ -162,3 +276,19 @@ func updateAuthOpts() error {
 
 	return nil
 }
+
+// GetWeightedVotesFromChain reads the sum of the weights anchored for a candidate.
+func GetWeightedVotesFromChain(electionID uint, candidateID uint) (int64, error) {
+	if !isReady || instance == nil {
+		return 0, errors.New("blockchain service not ready")
+	}
+
+	eID := new(big.Int).SetUint64(uint64(electionID))
+	cID := new(big.Int).SetUint64(uint64(candidateID))
+
+	count, err := instance.GetWeightedVotes(nil, eID, cID)
+	if err != nil {
+		return 0, err
+	}
+	return count.Int64(), nil
+}
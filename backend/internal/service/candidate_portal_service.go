@@ -0,0 +1,245 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/repository"
+	"E-voting/internal/utils"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DocumentAffidavit         = "AFFIDAVIT"
+	DocumentAffidavitRedacted = "AFFIDAVIT_REDACTED"
+	DocumentPhoto             = "PHOTO"
+	DocumentManifesto         = "MANIFESTO"
+
+	DocumentPending  = "PENDING"
+	DocumentApproved = "APPROVED"
+	DocumentRejected = "REJECTED"
+
+	maxCandidateDocumentSize = 10 << 20
+
+	// CandidateDocumentDir keeps portal uploads out of the public /uploads
+	// directory; they are only served through authenticated handlers.
+	CandidateDocumentDir = "./private/candidate_docs"
+)
+
+var documentExtensions = map[string][]string{
+	DocumentAffidavit:         {".pdf"},
+	DocumentAffidavitRedacted: {".pdf"},
+	DocumentPhoto:             {".jpg", ".jpeg", ".png"},
+	DocumentManifesto:         {".pdf", ".jpg", ".jpeg", ".png"},
+}
+
+func normalizeMobile(mobile string) string {
+	mobile = strings.TrimSpace(mobile)
+	if mobile != "" && !strings.HasPrefix(mobile, "+") {
+		mobile = "+91" + mobile
+	}
+	return mobile
+}
+
+// candidatePortalRole tells the candidate's own phone from their agent's.
+func candidatePortalRole(cand models.Candidate, mobile string) (string, bool) {
+	switch {
+	case cand.Mobile != "" && normalizeMobile(cand.Mobile) == mobile:
+		return "CANDIDATE", true
+	case cand.AgentMobile != "" && normalizeMobile(cand.AgentMobile) == mobile:
+		return "AGENT", true
+	}
+	return "", false
+}
+
+// InitiateCandidateLogin checks the phone against the candidate record and
+// returns it in the form the OTP is sent to.
+func InitiateCandidateLogin(candidateID uint, mobile string) (string, error) {
+	var cand models.Candidate
+	if err := database.PostgresDB.First(&cand, candidateID).Error; err != nil {
+		return "", errors.New("candidate not found")
+	}
+	mobile = normalizeMobile(mobile)
+	if _, ok := candidatePortalRole(cand, mobile); !ok {
+		return "", errors.New("this phone number is not registered for the candidate")
+	}
+	return mobile, nil
+}
+
+// VerifyCandidateFirebase exchanges a Firebase phone OTP for a candidate
+// portal token. Agents get the same access as the candidate they act for.
+func VerifyCandidateFirebase(candidateID uint, firebaseToken string) (string, error) {
+	token, err := utils.VerifyFirebaseToken(firebaseToken)
+	if err != nil {
+		return "", fmt.Errorf("invalid firebase token: %v", err)
+	}
+	firebasePhone, found := token.Claims["phone_number"].(string)
+	if !found {
+		return "", errors.New("firebase token does not contain phone number")
+	}
+
+	var cand models.Candidate
+	if err := database.PostgresDB.First(&cand, candidateID).Error; err != nil {
+		return "", errors.New("candidate not found")
+	}
+	actor, ok := candidatePortalRole(cand, firebasePhone)
+	if !ok {
+		return "", errors.New("phone number mismatch: verified phone is not registered for the candidate")
+	}
+
+	return utils.GenerateJWT(cand.ID, "CANDIDATE", actor, false, cand.FullName, "", cand.Photo)
+}
+
+// CandidateDocumentPath is where a stored document lives on disk.
+func CandidateDocumentPath(name string) string {
+	return filepath.Join(CandidateDocumentDir, filepath.Base(name))
+}
+
+// publishDocument copies an approved photo or manifesto into the public
+// uploads directory and returns its public path.
+func publishDocument(doc models.CandidateDocument) (string, error) {
+	src, err := os.Open(CandidateDocumentPath(doc.Path))
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	name := filepath.Base(doc.Path)
+	dst, err := os.Create(filepath.Join("./uploads", name))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	return "/uploads/" + name, nil
+}
+
+// SubmitCandidateDocument records an uploaded file for admin review. Only one
+// document of each kind waits for review at a time; a new upload replaces it.
+// path is the file's name inside CandidateDocumentDir.
+func SubmitCandidateDocument(candidateID uint, kind, fileName, path, submittedBy string) (*models.CandidateDocument, error) {
+	doc := models.CandidateDocument{
+		CandidateID: candidateID,
+		Kind:        kind,
+		Status:      DocumentPending,
+		Path:        path,
+		FileName:    fileName,
+		SubmittedBy: submittedBy,
+	}
+
+	database.PostgresDB.Where("candidate_id = ? AND kind = ? AND status = ?", candidateID, kind, DocumentPending).
+		Delete(&models.CandidateDocument{})
+	if err := database.PostgresDB.Create(&doc).Error; err != nil {
+		return nil, errors.New("failed to store document")
+	}
+	return &doc, nil
+}
+
+// CheckCandidateDocument validates the kind, size and extension of an upload.
+func CheckCandidateDocument(kind, fileName string, size int64) (string, error) {
+	kind = strings.ToUpper(strings.TrimSpace(kind))
+	allowed, ok := documentExtensions[kind]
+	if !ok {
+		return "", errors.New("document kind must be AFFIDAVIT, AFFIDAVIT_REDACTED, PHOTO or MANIFESTO")
+	}
+	if size > maxCandidateDocumentSize {
+		return "", errors.New("file is larger than 10 MB")
+	}
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, a := range allowed {
+		if a == ext {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("%s must be a %s file", strings.ToLower(kind), strings.Join(allowed, ", "))
+}
+
+// ReviewCandidateDocument approves or rejects a pending upload. An approved
+// document replaces the one shown on the candidate's profile.
+func ReviewCandidateDocument(docID uint, approve bool, note string, reviewer uint) (*models.CandidateDocument, error) {
+	var doc models.CandidateDocument
+	if err := database.PostgresDB.First(&doc, docID).Error; err != nil {
+		return nil, errors.New("document not found")
+	}
+	if doc.Status != DocumentPending {
+		return nil, errors.New("document has already been reviewed")
+	}
+	note = strings.TrimSpace(note)
+	if !approve && note == "" {
+		return nil, errors.New("a note is required to reject a document")
+	}
+
+	if approve {
+		var cand models.Candidate
+		if err := database.PostgresDB.First(&cand, doc.CandidateID).Error; err != nil {
+			return nil, errors.New("candidate not found")
+		}
+		column := map[string]string{
+			DocumentAffidavit:         "affidavit",
+			DocumentAffidavitRedacted: "affidavit_redacted",
+			DocumentPhoto:             "photo",
+			DocumentManifesto:         "manifesto",
+		}[doc.Kind]
+		if doc.Kind == DocumentPhoto {
+			if err := CheckBallotEditable(cand.ElectionID); err != nil {
+				return nil, errors.New("the ballot has been frozen; the photo can no longer change")
+			}
+		}
+		// Affidavits stay in the private store; the redacted copy is only
+		// served through PublicAffidavit. The photo and manifesto are public.
+		value := doc.Path
+		if doc.Kind != DocumentAffidavit && doc.Kind != DocumentAffidavitRedacted {
+			public, err := publishDocument(doc)
+			if err != nil {
+				return nil, errors.New("failed to publish document")
+			}
+			value = public
+		}
+		if err := database.PostgresDB.Model(&cand).Update(column, value).Error; err != nil {
+			return nil, errors.New("failed to update candidate")
+		}
+	}
+
+	now := time.Now()
+	doc.ReviewNote = note
+	doc.ReviewedBy = reviewer
+	doc.ReviewedAt = &now
+	doc.Status = DocumentRejected
+	if approve {
+		doc.Status = DocumentApproved
+	}
+	if err := database.PostgresDB.Save(&doc).Error; err != nil {
+		return nil, errors.New("failed to save review")
+	}
+	return &doc, nil
+}
+
+// CertifiedResult is the declared result of the candidate's own election.
+// Live counts are never shown through the portal.
+func CertifiedResult(candidateID uint) (*models.ElectionResult, error) {
+	var cand models.Candidate
+	if err := database.PostgresDB.First(&cand, candidateID).Error; err != nil {
+		return nil, errors.New("candidate not found")
+	}
+	result, err := GetDeclaredResult(cand.ElectionID)
+	if err != nil || result.Status != ResultStatusFinal {
+		return nil, errors.New("the result of this election has not been declared yet")
+	}
+	return result, nil
+}
+
+// CandidatePortalOpen lets admins switch the portal off. Logins are refused
+// and PermissionMiddleware rejects tokens already issued.
+func CandidatePortalOpen() bool {
+	return repository.GetSettingValue("candidate_portal_enabled") != "false"
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DisclosurePending  = "PENDING"
+	DisclosureVerified = "VERIFIED"
+	DisclosureRejected = "REJECTED"
+
+	CasePending   = "PENDING"
+	CaseConvicted = "CONVICTED"
+)
+
+// publishedNominations are the nominations whose profile is public. A
+// nomination is published once accepted and stays published if withdrawn.
+var publishedNominations = []string{NominationAccepted, NominationWithdrawn, NominationFinal}
+
+type DisclosureInput struct {
+	Education       string                `json:"education"`
+	Profession      string                `json:"profession"`
+	MovableAssets   int64                 `json:"movable_assets"`
+	ImmovableAssets int64                 `json:"immovable_assets"`
+	Liabilities     int64                 `json:"liabilities"`
+	Address         string                `json:"address"`
+	CriminalCases   []models.CriminalCase `json:"criminal_cases"`
+}
+
+func GetDisclosure(candidateID uint) (*models.CandidateDisclosure, error) {
+	var d models.CandidateDisclosure
+	if err := database.PostgresDB.Preload("CriminalCases").Where("candidate_id = ?", candidateID).First(&d).Error; err != nil {
+		return nil, errors.New("no disclosure has been filed")
+	}
+	return &d, nil
+}
+
+// FileDisclosure records or replaces a candidate's disclosure and sends it for
+// verification. A verified disclosure is locked until an admin reopens it.
+func FileDisclosure(candidateID uint, in DisclosureInput) (*models.CandidateDisclosure, error) {
+	var cand models.Candidate
+	if err := database.PostgresDB.First(&cand, candidateID).Error; err != nil {
+		return nil, errors.New("candidate not found")
+	}
+	if cand.NominationStatus == NominationRejected || cand.NominationStatus == NominationWithdrawn {
+		return nil, fmt.Errorf("a %s nomination cannot file a disclosure", cand.NominationStatus)
+	}
+
+	in.Education = strings.TrimSpace(in.Education)
+	if in.Education == "" {
+		return nil, errors.New("education is required")
+	}
+	if in.MovableAssets < 0 || in.ImmovableAssets < 0 || in.Liabilities < 0 {
+		return nil, errors.New("assets and liabilities cannot be negative")
+	}
+	for i := range in.CriminalCases {
+		cc := &in.CriminalCases[i]
+		cc.ID, cc.DisclosureID = 0, 0
+		cc.CaseNumber, cc.Court = strings.TrimSpace(cc.CaseNumber), strings.TrimSpace(cc.Court)
+		cc.Status = strings.ToUpper(strings.TrimSpace(cc.Status))
+		if cc.CaseNumber == "" || cc.Court == "" {
+			return nil, errors.New("each criminal case needs a case number and court")
+		}
+		if cc.Status == "" {
+			cc.Status = CasePending
+		}
+		if cc.Status != CasePending && cc.Status != CaseConvicted {
+			return nil, fmt.Errorf("case %s: status must be PENDING or CONVICTED", cc.CaseNumber)
+		}
+	}
+
+	var d models.CandidateDisclosure
+	err := database.PostgresDB.Where("candidate_id = ?", candidateID).First(&d).Error
+	if err == nil && d.Status == DisclosureVerified {
+		return nil, errors.New("the disclosure has been verified and can no longer change")
+	}
+
+	d.CandidateID = candidateID
+	d.Education = in.Education
+	d.Profession = strings.TrimSpace(in.Profession)
+	d.MovableAssets, d.ImmovableAssets, d.Liabilities = in.MovableAssets, in.ImmovableAssets, in.Liabilities
+	d.Address = strings.TrimSpace(in.Address)
+	d.Status = DisclosurePending
+	d.ReviewNote, d.VerifiedBy, d.VerifiedAt = "", 0, nil
+
+	err = database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("CriminalCases").Save(&d).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("disclosure_id = ?", d.ID).Delete(&models.CriminalCase{}).Error; err != nil {
+			return err
+		}
+		for i := range in.CriminalCases {
+			in.CriminalCases[i].DisclosureID = d.ID
+		}
+		if len(in.CriminalCases) > 0 {
+			return tx.Create(&in.CriminalCases).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("failed to save disclosure")
+	}
+	d.CriminalCases = in.CriminalCases
+	return &d, nil
+}
+
+// VerifyDisclosure approves a pending disclosure against the approved
+// affidavit, or rejects it. Rejecting a verified disclosure reopens it, which
+// takes it off the public profile until it is verified again.
+func VerifyDisclosure(candidateID uint, approve bool, note string, reviewer uint) (*models.CandidateDisclosure, error) {
+	d, err := GetDisclosure(candidateID)
+	if err != nil {
+		return nil, err
+	}
+	note = strings.TrimSpace(note)
+
+	if approve {
+		if d.Status != DisclosurePending {
+			return nil, errors.New("only a pending disclosure can be verified")
+		}
+		var cand models.Candidate
+		if err := database.PostgresDB.First(&cand, candidateID).Error; err != nil {
+			return nil, errors.New("candidate not found")
+		}
+		if cand.Affidavit == "" {
+			return nil, errors.New("the candidate has no approved affidavit to verify against")
+		}
+		if cand.AffidavitRedacted == "" {
+			return nil, errors.New("the candidate has no approved redacted affidavit to publish")
+		}
+	} else {
+		if d.Status == DisclosureRejected {
+			return nil, errors.New("disclosure has already been rejected")
+		}
+		if note == "" {
+			return nil, errors.New("a note is required to reject a disclosure")
+		}
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      DisclosureRejected,
+		"review_note": note,
+		"verified_by": reviewer,
+		"verified_at": nil,
+	}
+	if approve {
+		updates["status"] = DisclosureVerified
+		updates["verified_at"] = now
+	}
+	if err := database.PostgresDB.Model(d).Updates(updates).Error; err != nil {
+		return nil, errors.New("failed to save review")
+	}
+	return GetDisclosure(candidateID)
+}
+
+// PublicDisclosure is a verified disclosure without the fields that are never
+// published.
+type PublicDisclosure struct {
+	Education       string               `json:"education"`
+	Profession      string               `json:"profession"`
+	MovableAssets   int64                `json:"movable_assets"`
+	ImmovableAssets int64                `json:"immovable_assets"`
+	Liabilities     int64                `json:"liabilities"`
+	CriminalCases   []PublicCriminalCase `json:"criminal_cases"`
+	VerifiedAt      *time.Time           `json:"verified_at"`
+}
+
+// PublicCriminalCase is a declared case as published. The free-text
+// description stays with the admins, since it can name victims or witnesses.
+type PublicCriminalCase struct {
+	CaseNumber string `json:"case_number"`
+	Court      string `json:"court"`
+	Sections   string `json:"sections"`
+	Status     string `json:"status"`
+}
+
+type PublicCandidateProfile struct {
+	ID               uint              `json:"id"`
+	FullName         string            `json:"full_name"`
+	Photo            string            `json:"photo"`
+	Bio              string            `json:"bio"`
+	ElectionID       uint              `json:"election_id"`
+	ElectionTitle    string            `json:"election_title"`
+	PartyName        string            `json:"party_name"`
+	Independent      bool              `json:"independent"`
+	Symbol           string            `json:"symbol"`
+	SerialNo         int               `json:"serial_no"`
+	Gender           string            `json:"gender"`
+	Category         string            `json:"category"`
+	NominationStatus string            `json:"nomination_status"`
+	Manifesto        string            `json:"manifesto"`
+	Disclosure       *PublicDisclosure `json:"disclosure"`
+	AffidavitURL     string            `json:"affidavit_url,omitempty"`
+}
+
+func publicProfiles(candidates []models.Candidate) []PublicCandidateProfile {
+	ids := make([]uint, 0, len(candidates))
+	electionIDs := make([]uint, 0, len(candidates))
+	for _, cand := range candidates {
+		ids = append(ids, cand.ID)
+		electionIDs = append(electionIDs, cand.ElectionID)
+	}
+
+	disclosures := make(map[uint]models.CandidateDisclosure)
+	var verified []models.CandidateDisclosure
+	database.PostgresDB.Preload("CriminalCases").
+		Where("candidate_id IN ? AND status = ?", ids, DisclosureVerified).
+		Find(&verified)
+	for _, d := range verified {
+		disclosures[d.CandidateID] = d
+	}
+
+	titles := make(map[uint]string)
+	var elections []models.Election
+	database.PostgresDB.Select("id", "title").Where("id IN ?", electionIDs).Find(&elections)
+	for _, e := range elections {
+		titles[e.ID] = e.Title
+	}
+
+	out := make([]PublicCandidateProfile, 0, len(candidates))
+	for _, cand := range candidates {
+		p := PublicCandidateProfile{
+			ID:               cand.ID,
+			FullName:         cand.FullName,
+			Photo:            cand.Photo,
+			Bio:              cand.Bio,
+			ElectionID:       cand.ElectionID,
+			ElectionTitle:    titles[cand.ElectionID],
+			Independent:      cand.Party == nil,
+			Symbol:           cand.Symbol,
+			SerialNo:         cand.SerialNo,
+			Gender:           cand.Gender,
+			Category:         cand.Category,
+			NominationStatus: cand.NominationStatus,
+			Manifesto:        cand.Manifesto,
+		}
+		if cand.Party != nil {
+			p.PartyName = cand.Party.Name
+		} else {
+			p.PartyName = IndependentLabel
+		}
+		if d, ok := disclosures[cand.ID]; ok {
+			cases := make([]PublicCriminalCase, 0, len(d.CriminalCases))
+			for _, cc := range d.CriminalCases {
+				cases = append(cases, PublicCriminalCase{
+					CaseNumber: cc.CaseNumber,
+					Court:      cc.Court,
+					Sections:   cc.Sections,
+					Status:     cc.Status,
+				})
+			}
+			p.Disclosure = &PublicDisclosure{
+				Education:       d.Education,
+				Profession:      d.Profession,
+				MovableAssets:   d.MovableAssets,
+				ImmovableAssets: d.ImmovableAssets,
+				Liabilities:     d.Liabilities,
+				CriminalCases:   cases,
+				VerifiedAt:      d.VerifiedAt,
+			}
+			if cand.AffidavitRedacted != "" {
+				p.AffidavitURL = fmt.Sprintf("/api/public/candidates/%d/affidavit", cand.ID)
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// PublicCandidate returns the public profile of a candidate whose nomination
+// has been accepted. Profiles go out before polling, so they do not wait for
+// the election's results to be published.
+func PublicCandidate(candidateID uint) (*PublicCandidateProfile, error) {
+	var cand models.Candidate
+	if err := database.PostgresDB.Preload("Party").
+		Where("id = ? AND nomination_status IN ?", candidateID, publishedNominations).
+		First(&cand).Error; err != nil {
+		return nil, errors.New("candidate not found")
+	}
+	return &publicProfiles([]models.Candidate{cand})[0], nil
+}
+
+// PublicElectionCandidates lists the public profiles of an election's
+// accepted nominations.
+func PublicElectionCandidates(electionID uint) ([]PublicCandidateProfile, error) {
+	var election models.Election
+	if err := database.PostgresDB.Select("id").First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+
+	var candidates []models.Candidate
+	if err := database.PostgresDB.Preload("Party").
+		Where("election_id = ? AND nomination_status IN ?", electionID, publishedNominations).
+		Order("serial_no asc, full_name asc").
+		Find(&candidates).Error; err != nil {
+		return nil, errors.New("failed to fetch candidates")
+	}
+	return publicProfiles(candidates), nil
+}
+
+// PublicAffidavit returns where the redacted affidavit of an accepted
+// candidate is stored. It is only released with a verified disclosure.
+func PublicAffidavit(candidateID uint) (string, error) {
+	var cand models.Candidate
+	if err := database.PostgresDB.
+		Where("id = ? AND nomination_status IN ?", candidateID, publishedNominations).
+		First(&cand).Error; err != nil {
+		return "", errors.New("candidate not found")
+	}
+	var verified int64
+	database.PostgresDB.Model(&models.CandidateDisclosure{}).
+		Where("candidate_id = ? AND status = ?", candidateID, DisclosureVerified).
+		Count(&verified)
+	if verified == 0 || cand.AffidavitRedacted == "" {
+		return "", errors.New("no published affidavit for this candidate")
+	}
+	return CandidateDocumentPath(cand.AffidavitRedacted), nil
+}
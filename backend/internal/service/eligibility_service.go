@@ -0,0 +1,79 @@
+package service
+
+import (
+	"E-voting/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// IsVoterEligible applies the 3-tier local body hierarchy to decide whether a
+// voter belongs to an election's electorate. Indirect elections are voted in
+// by the elected members only.
+func IsVoterEligible(e models.Election, voter models.Voter) bool {
+	if e.Indirect {
+		return IsIndirectElector(e.ID, voter.ID)
+	}
+
+	isEligible := false
+
+	// Rule 1: District Match is always required (foundation)
+	if e.District == voter.District {
+		switch e.ElectionType {
+		case "District Panchayat":
+			isEligible = true
+
+		case "Block Panchayat":
+			if e.Block == voter.Block {
+				isEligible = true
+			}
+
+		case "Grama Panchayat":
+			if e.Block == voter.Block &&
+				e.LocalBodyName == voter.Panchayath &&
+				e.Ward == voter.Ward { // Strict Ward Match
+				isEligible = true
+			}
+
+		case "Municipality", "Municipal Corporation":
+			if e.LocalBodyName == voter.Panchayath &&
+				e.Ward == voter.Ward { // Strict Ward Match
+				isEligible = true
+			}
+		}
+	}
+
+	// Rule 2: Specific Ward Restriction (Optional)
+	if isEligible && e.Ward != "" {
+		if e.Ward != voter.Ward {
+			isEligible = false
+		}
+	}
+
+	return isEligible
+}
+
+// EligibleVoters scopes a voters query to the same electorate as IsVoterEligible.
+func EligibleVoters(db *gorm.DB, e models.Election) *gorm.DB {
+	if e.Indirect {
+		return db.Where("voters.id IN (SELECT voter_id FROM indirect_electors WHERE election_id = ?)", e.ID)
+	}
+
+	db = db.Where("voters.district = ?", e.District)
+
+	switch e.ElectionType {
+	case "District Panchayat":
+	case "Block Panchayat":
+		db = db.Where("voters.block = ?", e.Block)
+	case "Grama Panchayat":
+		db = db.Where("voters.block = ? AND voters.panchayath = ? AND voters.ward = ?", e.Block, e.LocalBodyName, e.Ward)
+	case "Municipality", "Municipal Corporation":
+		db = db.Where("voters.panchayath = ? AND voters.ward = ?", e.LocalBodyName, e.Ward)
+	default:
+		return db.Where("1 = 0")
+	}
+
+	if e.Ward != "" {
+		db = db.Where("voters.ward = ?", e.Ward)
+	}
+	return db
+}
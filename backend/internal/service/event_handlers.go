@@ -0,0 +1,122 @@
+package service
+
+import (
+	"E-voting/internal/events"
+	"log"
+)
+
+// RegisterEventHandlers wires audit logging, cache invalidation, chain
+// anchoring, feed revisions and outbound webhooks to the domain event bus.
+func RegisterEventHandlers() {
+	events.SubscribeAsync(events.NameVoteCast, func(e events.Event) {
+		v := e.(events.VoteCast)
+		if len(v.OptionIDs) > 0 {
+			if err := AnchorAnswers(v.VoteHash, v.VoterID); err != nil {
+				log.Printf("Referendum ballot %s left for the retry worker: %v", v.VoteHash, err)
+			}
+			return
+		}
+		if len(v.CandidateIDs) > 0 {
+			if err := AnchorSelections(v.VoteHash, v.VoterID); err != nil {
+				log.Printf("Approval ballot %s left for the retry worker: %v", v.VoteHash, err)
+			}
+			return
+		}
+		if v.Weight > 1 {
+			if err := AnchorWeightedVote(v.VoteHash, v.VoterID); err != nil {
+				log.Printf("Weighted ballot %s left for the retry worker: %v", v.VoteHash, err)
+			}
+			return
+		}
+		AnchorVote(v.ElectionID, v.CandidateID, v.VoterID, v.VoteHash)
+	})
+
+	refreshFeed := func(electionID uint) {
+		if err := RefreshFeedRevision(electionID); err != nil {
+			log.Printf("Failed to refresh feed revision of election %d: %v", electionID, err)
+		}
+	}
+	events.SubscribeAsync(events.NameVoteCast, func(e events.Event) {
+		refreshFeed(e.(events.VoteCast).ElectionID)
+	})
+	events.SubscribeAsync(events.NameElectionStateChanged, func(e events.Event) {
+		refreshFeed(e.(events.ElectionStateChanged).ElectionID)
+	})
+	events.SubscribeAsync(events.NameResultsPublished, func(e events.Event) {
+		refreshFeed(e.(events.ResultsPublished).ElectionID)
+	})
+
+	events.Subscribe(events.NameElectionStateChanged, func(e events.Event) {
+		ev := e.(events.ElectionStateChanged)
+		action := "PAUSE_ELECTION"
+		if ev.Active {
+			action = "RESUME_ELECTION"
+		}
+		LogAdminAction(ev.ActorID, ev.ActorRole, action, ev.ElectionID, map[string]interface{}{
+			"from": ev.From,
+			"to":   ev.To,
+		})
+	})
+
+	events.Subscribe(events.NameVoterVerified, func(e events.Event) {
+		ev := e.(events.VoterVerified)
+		LogAdminAction(ev.ActorID, ev.ActorRole, "VERIFY_VOTER", ev.VoterID, nil)
+	})
+
+	events.Subscribe(events.NameResultsPublished, func(e events.Event) {
+		ev := e.(events.ResultsPublished)
+		InvalidateResultsCache()
+
+		action := "UNPUBLISH_RESULTS"
+		if ev.Published {
+			action = "PUBLISH_RESULTS"
+		}
+		LogAdminAction(ev.ActorID, ev.ActorRole, action, ev.ElectionID, map[string]interface{}{"title": ev.Title})
+	})
+
+	events.Subscribe(events.NameAdminLoggedIn, func(e events.Event) {
+		ev := e.(events.AdminLoggedIn)
+		role := "STAFF"
+		if ev.IsSuper {
+			role = "SUPER_ADMIN"
+		}
+		LogAdminAction(ev.AdminID, role, "ADMIN_LOGIN", ev.AdminID, map[string]interface{}{"email": ev.Email})
+	})
+
+	events.SubscribeAsync(events.NameElectionStateChanged, func(e events.Event) {
+		ev := e.(events.ElectionStateChanged)
+		name := WebhookElectionClosed
+		if ev.Active {
+			name = WebhookElectionOpened
+		}
+		EnqueueWebhookEvent(name, map[string]interface{}{
+			"election_id": ev.ElectionID,
+			"title":       ev.Title,
+			"from":        ev.From,
+			"status":      ev.To,
+		})
+	})
+
+	events.SubscribeAsync(events.NameResultsPublished, func(e events.Event) {
+		ev := e.(events.ResultsPublished)
+		name := WebhookResultsUnpublished
+		if ev.Published {
+			name = WebhookResultsPublished
+		}
+		EnqueueWebhookEvent(name, map[string]interface{}{
+			"election_id": ev.ElectionID,
+			"title":       ev.Title,
+		})
+	})
+
+	events.SubscribeAsync(events.NameTurnoutMilestone, func(e events.Event) {
+		ev := e.(events.TurnoutMilestone)
+		EnqueueWebhookEvent(WebhookTurnoutMilestone, map[string]interface{}{
+			"election_id": ev.ElectionID,
+			"title":       ev.Title,
+			"milestone":   ev.Percent,
+			"votes_cast":  ev.VotesCast,
+			"eligible":    ev.Eligible,
+		})
+	})
+}
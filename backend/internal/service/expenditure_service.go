@@ -0,0 +1,403 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/repository"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var expenseCategories = []string{"PUBLICITY", "MEETINGS", "VEHICLES", "STAFF", "OTHER"}
+
+// ValidateExpenditureDeadline requires the filing deadline to fall after polling.
+func ValidateExpenditureDeadline(e models.Election) error {
+	if e.ExpenditureDeadline != nil && !e.ExpenditureDeadline.After(e.EndDate) {
+		return errors.New("expenditure deadline must be after the end date")
+	}
+	return nil
+}
+
+func SetExpenditureLimit(electionType string, amount int64) (*models.ExpenditureLimit, error) {
+	electionType = strings.TrimSpace(electionType)
+	if electionType == "" {
+		return nil, errors.New("election type is required")
+	}
+	if amount <= 0 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+
+	limit := models.ExpenditureLimit{ElectionType: electionType, Amount: amount}
+	err := database.PostgresDB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "election_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"amount"}),
+	}).Create(&limit).Error
+	if err != nil {
+		return nil, errors.New("failed to save limit")
+	}
+	return &limit, nil
+}
+
+func ListExpenditureLimits() ([]models.ExpenditureLimit, error) {
+	var limits []models.ExpenditureLimit
+	err := database.PostgresDB.Order("election_type asc").Find(&limits).Error
+	return limits, err
+}
+
+// expenditureDeadlines maps each election to its own deadline or, failing
+// that, the configured number of days after its result was declared. The
+// deadline is nil while neither is known.
+func expenditureDeadlines(elections []models.Election) map[uint]*time.Time {
+	out := make(map[uint]*time.Time, len(elections))
+	var pending []uint
+	for _, e := range elections {
+		out[e.ID] = e.ExpenditureDeadline
+		if e.ExpenditureDeadline == nil {
+			pending = append(pending, e.ID)
+		}
+	}
+	if len(pending) == 0 {
+		return out
+	}
+
+	var results []models.ElectionResult
+	database.PostgresDB.Select("election_id", "declared_at").
+		Where("election_id IN ? AND status = ? AND declared_at IS NOT NULL", pending, ResultStatusFinal).
+		Find(&results)
+	days := 30
+	if v, err := strconv.Atoi(repository.GetSettingValue("expenditure_filing_days")); err == nil && v > 0 {
+		days = v
+	}
+	for _, r := range results {
+		d := r.DeclaredAt.AddDate(0, 0, days)
+		out[r.ElectionID] = &d
+	}
+	return out
+}
+
+// lockStatement opens a candidate's statement if needed and locks it for the
+// rest of the transaction, so it cannot be filed while items change.
+func lockStatement(tx *gorm.DB, candidateID uint) (*models.ExpenditureStatement, error) {
+	var stmt models.ExpenditureStatement
+	if err := tx.Where(models.ExpenditureStatement{CandidateID: candidateID}).FirstOrCreate(&stmt).Error; err != nil {
+		return nil, errors.New("failed to open statement")
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stmt, stmt.ID).Error; err != nil {
+		return nil, errors.New("failed to open statement")
+	}
+	return &stmt, nil
+}
+
+// expenditureCandidate loads a contesting candidate. Only the final list has
+// to account for expenses.
+func expenditureCandidate(candidateID uint) (*models.Candidate, error) {
+	var cand models.Candidate
+	if err := database.PostgresDB.First(&cand, candidateID).Error; err != nil {
+		return nil, errors.New("candidate not found")
+	}
+	if cand.NominationStatus != NominationFinal {
+		return nil, errors.New("only contesting candidates file expenditure accounts")
+	}
+	return &cand, nil
+}
+
+func GetExpenditureStatement(candidateID uint) (*models.ExpenditureStatement, error) {
+	if _, err := expenditureCandidate(candidateID); err != nil {
+		return nil, err
+	}
+	stmt := models.ExpenditureStatement{CandidateID: candidateID}
+	database.PostgresDB.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("date asc, id asc") }).
+		Where("candidate_id = ?", candidateID).
+		First(&stmt)
+	return &stmt, nil
+}
+
+func updateExpenditureTotal(tx *gorm.DB, statementID uint) error {
+	return tx.Exec(`UPDATE expenditure_statements SET total =
+		(SELECT COALESCE(SUM(amount), 0) FROM expenditure_items WHERE statement_id = ?)
+		WHERE id = ?`, statementID, statementID).Error
+}
+
+// AddExpenditureItem adds an expense to a candidate's statement, opening the
+// statement on the first item.
+func AddExpenditureItem(candidateID uint, item models.ExpenditureItem) (*models.ExpenditureStatement, error) {
+	if _, err := expenditureCandidate(candidateID); err != nil {
+		return nil, err
+	}
+
+	item.Category = strings.ToUpper(strings.TrimSpace(item.Category))
+	known := false
+	for _, c := range expenseCategories {
+		if c == item.Category {
+			known = true
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("category must be one of %s", strings.Join(expenseCategories, ", "))
+	}
+	if item.Amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if item.Date.IsZero() {
+		return nil, errors.New("date is required")
+	}
+	item.Description, item.PaidTo = strings.TrimSpace(item.Description), strings.TrimSpace(item.PaidTo)
+
+	err := database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		stmt, err := lockStatement(tx, candidateID)
+		if err != nil {
+			return err
+		}
+		if stmt.FiledAt != nil {
+			return errors.New("the statement has already been filed")
+		}
+		item.ID, item.StatementID = 0, stmt.ID
+		if err := tx.Create(&item).Error; err != nil {
+			return errors.New("failed to add item")
+		}
+		return updateExpenditureTotal(tx, stmt.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetExpenditureStatement(candidateID)
+}
+
+func RemoveExpenditureItem(candidateID, itemID uint) (*models.ExpenditureStatement, error) {
+	if _, err := expenditureCandidate(candidateID); err != nil {
+		return nil, err
+	}
+
+	err := database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		stmt, err := lockStatement(tx, candidateID)
+		if err != nil {
+			return err
+		}
+		if stmt.FiledAt != nil {
+			return errors.New("the statement has already been filed")
+		}
+		res := tx.Where("id = ? AND statement_id = ?", itemID, stmt.ID).Delete(&models.ExpenditureItem{})
+		if res.Error != nil {
+			return errors.New("failed to remove item")
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("item not found")
+		}
+		return updateExpenditureTotal(tx, stmt.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetExpenditureStatement(candidateID)
+}
+
+// FileExpenditure closes a statement once polling is over. Statements filed
+// after the deadline are accepted but reported as late.
+func FileExpenditure(candidateID uint, filedBy string, now time.Time) (*models.ExpenditureStatement, error) {
+	cand, err := expenditureCandidate(candidateID)
+	if err != nil {
+		return nil, err
+	}
+	var election models.Election
+	if err := database.PostgresDB.First(&election, cand.ElectionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	if now.Before(election.EndDate) {
+		return nil, errors.New("expenditure accounts are filed after polling has ended")
+	}
+
+	err = database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		stmt, err := lockStatement(tx, candidateID)
+		if err != nil {
+			return err
+		}
+		if stmt.FiledAt != nil {
+			return errors.New("the statement has already been filed")
+		}
+		if err := tx.Model(stmt).Updates(map[string]interface{}{
+			"filed_at": now,
+			"filed_by": filedBy,
+		}).Error; err != nil {
+			return errors.New("failed to file statement")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetExpenditureStatement(candidateID)
+}
+
+// ExpenditureSummary is one candidate's line in the expenditure reports.
+// Limit is 0 when no cap is configured for the election type.
+type ExpenditureSummary struct {
+	CandidateID   uint             `json:"candidate_id"`
+	CandidateName string           `json:"candidate_name"`
+	PartyName     string           `json:"party_name"`
+	ElectionID    uint             `json:"election_id"`
+	ElectionTitle string           `json:"election_title"`
+	ElectionType  string           `json:"election_type"`
+	Total         int64            `json:"total"`
+	ByCategory    map[string]int64 `json:"by_category,omitempty"`
+	Limit         int64            `json:"limit"`
+	Filed         bool             `json:"filed"`
+	FiledAt       *time.Time       `json:"filed_at"`
+	Deadline      *time.Time       `json:"deadline"`
+
+	OverLimit bool `json:"over_limit"`
+	NotFiled  bool `json:"not_filed"`
+	LateFiled bool `json:"late_filed"`
+}
+
+func (s ExpenditureSummary) Flagged() bool {
+	return s.OverLimit || s.NotFiled || s.LateFiled
+}
+
+// setFlags marks a summary that is over its limit, unfiled past the deadline
+// or filed after it. Without a deadline only the limit is checked.
+func (s *ExpenditureSummary) setFlags(now time.Time) {
+	s.OverLimit = s.Limit > 0 && s.Total > s.Limit
+	s.NotFiled, s.LateFiled = false, false
+	if s.Deadline != nil {
+		s.NotFiled = !s.Filed && now.After(*s.Deadline)
+		s.LateFiled = s.Filed && s.FiledAt != nil && s.FiledAt.After(*s.Deadline)
+	}
+}
+
+func summarizeExpenditure(elections []models.Election, now time.Time) ([]ExpenditureSummary, error) {
+	if len(elections) == 0 {
+		return nil, nil
+	}
+	byID := make(map[uint]models.Election)
+	var ids []uint
+	for _, e := range elections {
+		byID[e.ID] = e
+		ids = append(ids, e.ID)
+	}
+	deadlines := expenditureDeadlines(elections)
+
+	limits := make(map[string]int64)
+	var rows []models.ExpenditureLimit
+	database.PostgresDB.Find(&rows)
+	for _, l := range rows {
+		limits[l.ElectionType] = l.Amount
+	}
+
+	var candidates []models.Candidate
+	if err := database.PostgresDB.Preload("Party").
+		Where("election_id IN ? AND nomination_status = ?", ids, NominationFinal).
+		Order("election_id asc, serial_no asc, id asc").
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	candIDs := make([]uint, 0, len(candidates))
+	for _, cand := range candidates {
+		candIDs = append(candIDs, cand.ID)
+	}
+
+	statements := make(map[uint]models.ExpenditureStatement)
+	var stmts []models.ExpenditureStatement
+	database.PostgresDB.Preload("Items").Where("candidate_id IN ?", candIDs).Find(&stmts)
+	for _, s := range stmts {
+		statements[s.CandidateID] = s
+	}
+
+	out := make([]ExpenditureSummary, 0, len(candidates))
+	for _, cand := range candidates {
+		e := byID[cand.ElectionID]
+		s := ExpenditureSummary{
+			CandidateID:   cand.ID,
+			CandidateName: cand.FullName,
+			PartyName:     IndependentLabel,
+			ElectionID:    e.ID,
+			ElectionTitle: e.Title,
+			ElectionType:  e.ElectionType,
+			Limit:         limits[e.ElectionType],
+			Deadline:      deadlines[e.ID],
+		}
+		if cand.Party != nil {
+			s.PartyName = cand.Party.Name
+		}
+		if stmt, ok := statements[cand.ID]; ok {
+			s.Total = stmt.Total
+			s.FiledAt = stmt.FiledAt
+			s.Filed = stmt.FiledAt != nil
+			s.ByCategory = make(map[string]int64)
+			for _, item := range stmt.Items {
+				s.ByCategory[item.Category] += item.Amount
+			}
+		}
+		s.setFlags(now)
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// ExpenditureReport lists every contesting candidate of an election with
+// their spending against the cap and their filing status.
+func ExpenditureReport(electionID uint, now time.Time) ([]ExpenditureSummary, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	return summarizeExpenditure([]models.Election{election}, now)
+}
+
+type ExpenditureFilter struct {
+	ElectionType string
+	District     string
+}
+
+// ExpenditureFlags lists the candidates who overspent, missed the deadline or
+// filed late, across all matching elections.
+func ExpenditureFlags(f ExpenditureFilter, now time.Time) ([]ExpenditureSummary, error) {
+	query := database.PostgresDB.Model(&models.Election{})
+	if f.ElectionType != "" {
+		query = query.Where("election_type = ?", f.ElectionType)
+	}
+	if f.District != "" {
+		query = query.Where("district = ?", f.District)
+	}
+	var elections []models.Election
+	if err := query.Find(&elections).Error; err != nil {
+		return nil, err
+	}
+
+	all, err := summarizeExpenditure(elections, now)
+	if err != nil {
+		return nil, err
+	}
+	flagged := make([]ExpenditureSummary, 0)
+	for _, s := range all {
+		if s.Flagged() {
+			flagged = append(flagged, s)
+		}
+	}
+	return flagged, nil
+}
+
+// PublicExpenditureSummary is a published election's expenditure report as
+// shown to the public: amounts appear only once a statement has been filed.
+func PublicExpenditureSummary(electionID uint, now time.Time) ([]ExpenditureSummary, error) {
+	var election models.Election
+	if err := database.PostgresDB.Where("id = ? AND is_published = ?", electionID, true).First(&election).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	summary, err := summarizeExpenditure([]models.Election{election}, now)
+	if err != nil {
+		return nil, err
+	}
+	for i := range summary {
+		if !summary[i].Filed {
+			summary[i].Total, summary[i].ByCategory, summary[i].OverLimit = 0, nil, false
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpenditureSummarySetFlags(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-24*time.Hour), now.Add(24*time.Hour)
+	early, late := past.Add(-time.Hour), past.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		summary  ExpenditureSummary
+		over     bool
+		notFiled bool
+		lateFile bool
+	}{
+		{
+			name:    "within the limit, filed on time",
+			summary: ExpenditureSummary{Total: 900, Limit: 1000, Filed: true, FiledAt: &early, Deadline: &past},
+		},
+		{
+			name:    "spending equal to the limit is allowed",
+			summary: ExpenditureSummary{Total: 1000, Limit: 1000, Filed: true, FiledAt: &early, Deadline: &past},
+		},
+		{
+			name:    "over the limit",
+			summary: ExpenditureSummary{Total: 1001, Limit: 1000, Filed: true, FiledAt: &early, Deadline: &past},
+			over:    true,
+		},
+		{
+			name:    "no limit configured",
+			summary: ExpenditureSummary{Total: 5000000},
+		},
+		{
+			name:     "unfiled past the deadline",
+			summary:  ExpenditureSummary{Total: 10, Deadline: &past},
+			notFiled: true,
+		},
+		{
+			name:    "unfiled before the deadline",
+			summary: ExpenditureSummary{Total: 10, Deadline: &future},
+		},
+		{
+			name:     "filed after the deadline",
+			summary:  ExpenditureSummary{Filed: true, FiledAt: &late, Deadline: &past},
+			lateFile: true,
+		},
+		{
+			name:    "no deadline known yet",
+			summary: ExpenditureSummary{Total: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.summary
+			s.setFlags(now)
+			if s.OverLimit != tt.over || s.NotFiled != tt.notFiled || s.LateFiled != tt.lateFile {
+				t.Errorf("flags = over %v, not filed %v, late %v; want %v, %v, %v",
+					s.OverLimit, s.NotFiled, s.LateFiled, tt.over, tt.notFiled, tt.lateFile)
+			}
+			if s.Flagged() != (tt.over || tt.notFiled || tt.lateFile) {
+				t.Errorf("Flagged() = %v", s.Flagged())
+			}
+		})
+	}
+}
@@ -0,0 +1,564 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FeedSchemaVersion is bumped on any breaking change to the media feed documents.
+const FeedSchemaVersion = "1.0"
+
+const FeedBasePath = "/api/public/feed/v1"
+
+// Feed requests that name nothing published fail with one of these, so they
+// can be told apart from load failures and are never cached.
+var (
+	ErrElectionFeedNotFound  = errors.New("published election not found")
+	ErrLocalBodyFeedNotFound = errors.New("no published results for this local body")
+)
+
+type FeedElection struct {
+	ID            uint      `json:"id" xml:"id,attr"`
+	Title         string    `json:"title" xml:"title"`
+	ElectionType  string    `json:"election_type" xml:"election_type"`
+	BallotType    string    `json:"ballot_type" xml:"ballot_type"`
+	Seats         int       `json:"seats" xml:"seats"`
+	Weighted      bool      `json:"weighted" xml:"weighted"`
+	Reservation   string    `json:"reservation" xml:"reservation"`
+	District      string    `json:"district" xml:"district"`
+	Block         string    `json:"block" xml:"block"`
+	LocalBodyName string    `json:"local_body_name" xml:"local_body_name"`
+	Ward          string    `json:"ward" xml:"ward"`
+	Status        string    `json:"status" xml:"status"`
+	StartDate     time.Time `json:"start_date" xml:"start_date"`
+	EndDate       time.Time `json:"end_date" xml:"end_date"`
+}
+
+type FeedCandidate struct {
+	CandidateID   uint    `json:"candidate_id" xml:"id,attr"`
+	Name          string  `json:"name" xml:"name"`
+	Party         string  `json:"party" xml:"party"`
+	Independent   bool    `json:"independent" xml:"independent"`
+	Alliance      string  `json:"alliance,omitempty" xml:"alliance,omitempty"`
+	SerialNo      int     `json:"serial_no,omitempty" xml:"serial_no,omitempty"`
+	Symbol        string  `json:"symbol,omitempty" xml:"symbol,omitempty"`
+	Votes         int64   `json:"votes" xml:"votes"`
+	VoteShare     float64 `json:"vote_share" xml:"vote_share"`
+	Headcount     int64   `json:"headcount,omitempty" xml:"headcount,omitempty"`
+	Rank          int     `json:"rank" xml:"rank"`
+	Elected       bool    `json:"elected" xml:"elected"`
+	WonByTieBreak bool    `json:"won_by_tie_break" xml:"won_by_tie_break"`
+}
+
+type FeedResult struct {
+	Status     string          `json:"status" xml:"status,attr"`
+	TotalVotes int64           `json:"total_votes" xml:"total_votes"`
+	Headcount  int64           `json:"headcount,omitempty" xml:"headcount,omitempty"`
+	Margin     int64           `json:"margin" xml:"margin"`
+	DecidedBy  string          `json:"decided_by,omitempty" xml:"decided_by,omitempty"`
+	IsTie      bool            `json:"is_tie" xml:"is_tie"`
+	DeclaredAt *time.Time      `json:"declared_at" xml:"declared_at,omitempty"`
+	Candidates []FeedCandidate `json:"candidates" xml:"candidates>candidate"`
+	Nota       *FeedNota       `json:"nota,omitempty" xml:"nota,omitempty"`
+	Rounds     []FeedRound     `json:"rounds,omitempty" xml:"rounds>round,omitempty"`
+}
+
+// FeedNota reports None of the Above; CanWin is always false.
+type FeedNota struct {
+	Votes     int64   `json:"votes" xml:"votes"`
+	VoteShare float64 `json:"vote_share" xml:"vote_share"`
+	CanWin    bool    `json:"can_win" xml:"can_win"`
+}
+
+// FeedRound is one instant-runoff count; only RANKED elections have rounds.
+type FeedRound struct {
+	Round      int              `json:"round" xml:"number,attr"`
+	Continuing int64            `json:"continuing" xml:"continuing"`
+	Exhausted  int64            `json:"exhausted" xml:"exhausted"`
+	Counts     []FeedRoundCount `json:"counts" xml:"counts>count"`
+	Eliminated []uint           `json:"eliminated" xml:"eliminated>candidate_id"`
+	Elected    []uint           `json:"elected" xml:"elected>candidate_id"`
+	Transfers  []FeedTransfer   `json:"transfers" xml:"transfers>transfer"`
+}
+
+type FeedRoundCount struct {
+	CandidateID uint  `json:"candidate_id" xml:"candidate_id,attr"`
+	Votes       int64 `json:"votes" xml:",chardata"`
+}
+
+// FeedTransfer moves ballots from an eliminated candidate; To is 0 for exhausted ballots.
+type FeedTransfer struct {
+	From  uint  `json:"from" xml:"from,attr"`
+	To    uint  `json:"to" xml:"to,attr"`
+	Votes int64 `json:"votes" xml:",chardata"`
+}
+
+type ElectionFeed struct {
+	XMLName       xml.Name     `json:"-" xml:"election_result"`
+	SchemaVersion string       `json:"schema_version" xml:"schema_version,attr"`
+	Seq           int64        `json:"seq" xml:"seq,attr"`
+	UpdatedAt     time.Time    `json:"updated_at" xml:"updated_at,attr"`
+	Election      FeedElection `json:"election" xml:"election"`
+	Result        FeedResult   `json:"result" xml:"result"`
+}
+
+type FeedWard struct {
+	Seq       int64        `json:"seq" xml:"seq,attr"`
+	UpdatedAt time.Time    `json:"updated_at" xml:"updated_at,attr"`
+	Election  FeedElection `json:"election" xml:"election"`
+	Result    FeedResult   `json:"result" xml:"result"`
+}
+
+type FeedParty struct {
+	Party     string  `json:"party" xml:"name,attr"`
+	SeatsWon  int     `json:"seats_won" xml:"seats_won"`
+	Votes     int64   `json:"votes" xml:"votes"`
+	VoteShare float64 `json:"vote_share" xml:"vote_share"`
+}
+
+type FeedLocalBody struct {
+	Name             string      `json:"name" xml:"name"`
+	District         string      `json:"district" xml:"district"`
+	Block            string      `json:"block" xml:"block"`
+	ElectionType     string      `json:"election_type" xml:"election_type"`
+	TotalSeats       int         `json:"total_seats" xml:"total_seats"`
+	DeclaredSeats    int         `json:"declared_seats" xml:"declared_seats"`
+	MajorityMark     int         `json:"majority_mark" xml:"majority_mark"`
+	Control          string      `json:"control" xml:"control"`
+	ControllingParty string      `json:"controlling_party" xml:"controlling_party"`
+	TotalVotes       int64       `json:"total_votes" xml:"total_votes"`
+	Parties          []FeedParty `json:"parties" xml:"parties>party"`
+	Independents     FeedParty   `json:"independents" xml:"independents"`
+
+	Alliances           []FeedAlliance `json:"alliances" xml:"alliances>alliance"`
+	ControllingAlliance string         `json:"controlling_alliance" xml:"controlling_alliance"`
+}
+
+type FeedAlliance struct {
+	Alliance  string  `json:"alliance" xml:"name,attr"`
+	SeatsWon  int     `json:"seats_won" xml:"seats_won"`
+	Votes     int64   `json:"votes" xml:"votes"`
+	VoteShare float64 `json:"vote_share" xml:"vote_share"`
+}
+
+type LocalBodyFeed struct {
+	XMLName       xml.Name      `json:"-" xml:"local_body_result"`
+	SchemaVersion string        `json:"schema_version" xml:"schema_version,attr"`
+	Seq           int64         `json:"seq" xml:"seq,attr"`
+	UpdatedAt     time.Time     `json:"updated_at" xml:"updated_at,attr"`
+	LocalBody     FeedLocalBody `json:"local_body" xml:"local_body"`
+	Wards         []FeedWard    `json:"wards" xml:"wards>ward"`
+}
+
+type FeedManifestElection struct {
+	ID            uint      `json:"id" xml:"id,attr"`
+	Title         string    `json:"title" xml:"title"`
+	ElectionType  string    `json:"election_type" xml:"election_type"`
+	District      string    `json:"district" xml:"district"`
+	LocalBodyName string    `json:"local_body_name" xml:"local_body_name"`
+	Ward          string    `json:"ward" xml:"ward"`
+	ResultStatus  string    `json:"result_status" xml:"result_status"`
+	Seq           int64     `json:"seq" xml:"seq"`
+	UpdatedAt     time.Time `json:"updated_at" xml:"updated_at"`
+	URL           string    `json:"url" xml:"url"`
+}
+
+type FeedManifestLocalBody struct {
+	Name         string    `json:"name" xml:"name"`
+	District     string    `json:"district" xml:"district"`
+	ElectionType string    `json:"election_type" xml:"election_type"`
+	Wards        int       `json:"wards" xml:"wards"`
+	Seq          int64     `json:"seq" xml:"seq"`
+	UpdatedAt    time.Time `json:"updated_at" xml:"updated_at"`
+	URL          string    `json:"url" xml:"url"`
+}
+
+type FeedManifest struct {
+	XMLName       xml.Name                `json:"-" xml:"manifest"`
+	SchemaVersion string                  `json:"schema_version" xml:"schema_version,attr"`
+	Seq           int64                   `json:"seq" xml:"seq,attr"`
+	UpdatedAt     time.Time               `json:"updated_at" xml:"updated_at,attr"`
+	Elections     []FeedManifestElection  `json:"elections" xml:"elections>election"`
+	LocalBodies   []FeedManifestLocalBody `json:"local_bodies" xml:"local_bodies>local_body"`
+}
+
+// feedResult prefers the declared verdict and falls back to the live count.
+func feedResult(electionID uint) FeedResult {
+	result, err := GetDeclaredResult(electionID)
+	if err != nil {
+		if result, err = ComputeElectionResult(electionID); err != nil {
+			return FeedResult{Status: ResultStatusDraft, Candidates: []FeedCandidate{}}
+		}
+	}
+
+	out := FeedResult{
+		Status:     result.Status,
+		TotalVotes: result.TotalVotes,
+		Margin:     result.Margin,
+		DecidedBy:  result.DecidedBy,
+		IsTie:      result.IsTie,
+		DeclaredAt: result.DeclaredAt,
+		Candidates: make([]FeedCandidate, 0, len(result.Entries)),
+	}
+	if result.NotaOffered {
+		out.Nota = &FeedNota{Votes: result.NotaVotes, VoteShare: result.NotaShare}
+	}
+	if result.Weighted {
+		out.Headcount = result.Headcount
+	}
+	for _, e := range result.Entries {
+		out.Candidates = append(out.Candidates, FeedCandidate{
+			CandidateID:   e.CandidateID,
+			Name:          e.CandidateName,
+			Party:         e.PartyName,
+			Independent:   e.Independent,
+			Alliance:      e.Alliance,
+			SerialNo:      e.SerialNo,
+			Symbol:        e.Symbol,
+			Votes:         e.VoteCount,
+			VoteShare:     e.VoteShare,
+			Rank:          e.Rank,
+			Elected:       e.IsElected,
+			WonByTieBreak: e.WonByTieBreak,
+		})
+		if result.Weighted {
+			out.Candidates[len(out.Candidates)-1].Headcount = e.Headcount
+		}
+	}
+	for _, r := range result.Rounds {
+		round := FeedRound{
+			Round:      r.Round,
+			Continuing: r.Continuing,
+			Exhausted:  r.Exhausted,
+			Counts:     make([]FeedRoundCount, 0, len(r.Counts)),
+			Eliminated: append([]uint{}, r.Eliminated...),
+			Elected:    append([]uint{}, r.Elected...),
+			Transfers:  make([]FeedTransfer, 0, len(r.Transfers)),
+		}
+		for _, c := range r.Counts {
+			round.Counts = append(round.Counts, FeedRoundCount{CandidateID: c.CandidateID, Votes: c.Votes})
+		}
+		for _, t := range r.Transfers {
+			round.Transfers = append(round.Transfers, FeedTransfer{From: t.From, To: t.To, Votes: t.Votes})
+		}
+		out.Rounds = append(out.Rounds, round)
+	}
+	return out
+}
+
+func feedElection(e models.Election) FeedElection {
+	return FeedElection{
+		ID:            e.ID,
+		Title:         e.Title,
+		ElectionType:  e.ElectionType,
+		BallotType:    e.BallotType,
+		Seats:         e.Seats,
+		Weighted:      e.Weighted,
+		Reservation:   e.Reservation,
+		District:      e.District,
+		Block:         e.Block,
+		LocalBodyName: LocalBodyName(e),
+		Ward:          e.Ward,
+		Status:        e.Status,
+		StartDate:     e.StartDate.UTC(),
+		EndDate:       e.EndDate.UTC(),
+	}
+}
+
+// feedRevisionMu serialises revision writes on this replica, so a slower
+// refresh cannot overwrite the digest of a newer one.
+var feedRevisionMu sync.Mutex
+
+func feedDigest(doc ElectionFeed) string {
+	content, _ := json.Marshal(struct {
+		E FeedElection
+		R FeedResult
+	}{doc.Election, doc.Result})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// RefreshFeedRevision recomputes the feed document of a published election and
+// moves its sequence number on when the content changed. It runs when votes,
+// results or the election change, so serving a feed never writes.
+func RefreshFeedRevision(electionID uint) error {
+	var e models.Election
+	if err := database.PostgresDB.Where("id = ? AND is_published = ?", electionID, true).First(&e).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	feedRevisionMu.Lock()
+	defer feedRevisionMu.Unlock()
+
+	result := feedResult(e.ID)
+	digest := feedDigest(ElectionFeed{Election: feedElection(e), Result: result})
+	now := time.Now().UTC()
+	res := database.PostgresDB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ResultFeedRevision{ElectionID: e.ID, Seq: 1, Digest: digest, ResultStatus: result.Status, UpdatedAt: now})
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	return database.PostgresDB.Model(&models.ResultFeedRevision{}).
+		Where("election_id = ? AND (digest <> ? OR result_status <> ?)", e.ID, digest, result.Status).
+		Updates(map[string]interface{}{
+			"seq":           gorm.Expr("CASE WHEN digest <> ? THEN seq + 1 ELSE seq END", digest),
+			"updated_at":    gorm.Expr("CASE WHEN digest <> ? THEN ? ELSE updated_at END", digest, now),
+			"digest":        digest,
+			"result_status": result.Status,
+		}).Error
+}
+
+// RefreshFeedRevisions refreshes every published election, for start-up and
+// after the tallies have been rebuilt.
+func RefreshFeedRevisions() {
+	var ids []uint
+	database.PostgresDB.Model(&models.Election{}).Where("is_published = ?", true).Pluck("id", &ids)
+	for _, id := range ids {
+		if err := RefreshFeedRevision(id); err != nil {
+			log.Printf("Failed to refresh feed revision of election %d: %v", id, err)
+		}
+	}
+}
+
+func feedRevisions(elections []models.Election) map[uint]models.ResultFeedRevision {
+	ids := make([]uint, 0, len(elections))
+	for _, e := range elections {
+		ids = append(ids, e.ID)
+	}
+	var revs []models.ResultFeedRevision
+	database.PostgresDB.Where("election_id IN ?", ids).Find(&revs)
+
+	out := make(map[uint]models.ResultFeedRevision, len(revs))
+	for _, r := range revs {
+		out[r.ElectionID] = r
+	}
+	return out
+}
+
+// FeedStamp moves whenever a feed revision moves or a published election
+// changes, so cached feed documents are only rebuilt after a write.
+func FeedStamp() string {
+	var row struct {
+		Elections int64
+		Seqs      int64
+		Latest    *time.Time
+	}
+	database.PostgresDB.Model(&models.Election{}).
+		Select("COUNT(*) AS elections, COALESCE(SUM(result_feed_revisions.seq), 0) AS seqs, "+
+			"MAX(GREATEST(elections.updated_at, result_feed_revisions.updated_at)) AS latest").
+		Joins("LEFT JOIN result_feed_revisions ON result_feed_revisions.election_id = elections.id").
+		Where("elections.is_published = ?", true).
+		Scan(&row)
+
+	var latest int64
+	if row.Latest != nil {
+		latest = row.Latest.UnixNano()
+	}
+	return fmt.Sprintf("%d-%d-%d", row.Elections, row.Seqs, latest)
+}
+
+func buildElectionFeed(e models.Election, rev models.ResultFeedRevision) ElectionFeed {
+	return ElectionFeed{
+		SchemaVersion: FeedSchemaVersion,
+		Seq:           rev.Seq,
+		UpdatedAt:     rev.UpdatedAt.UTC(),
+		Election:      feedElection(e),
+		Result:        feedResult(e.ID),
+	}
+}
+
+// BuildElectionFeed returns the media feed document of a published election.
+func BuildElectionFeed(electionID uint) (*ElectionFeed, error) {
+	var election models.Election
+	if err := database.PostgresDB.Where("id = ? AND is_published = ?", electionID, true).First(&election).Error; err != nil {
+		return nil, ErrElectionFeedNotFound
+	}
+	doc := buildElectionFeed(election, feedRevisions([]models.Election{election})[election.ID])
+	return &doc, nil
+}
+
+// BuildLocalBodyFeed returns every published ward of a local body with the
+// seat roll-up. Its sequence number is the sum of the ward sequence numbers.
+func BuildLocalBodyFeed(district, name, electionType string) (*LocalBodyFeed, error) {
+	if district == "" || name == "" {
+		return nil, errors.New("district and name are required")
+	}
+
+	query := database.PostgresDB.Where("is_published = ? AND ward <> '' AND district = ?", true, district)
+	if electionType != "" {
+		query = query.Where("election_type = ?", electionType)
+	}
+	var elections []models.Election
+	if err := query.Order("ward asc, id asc").Find(&elections).Error; err != nil {
+		return nil, errors.New("failed to load elections")
+	}
+
+	doc := LocalBodyFeed{SchemaVersion: FeedSchemaVersion, Wards: []FeedWard{}}
+	revs := feedRevisions(elections)
+	for _, e := range elections {
+		if LocalBodyName(e) != name {
+			continue
+		}
+		if electionType == "" {
+			electionType = e.ElectionType
+		} else if e.ElectionType != electionType {
+			continue
+		}
+		ward := buildElectionFeed(e, revs[e.ID])
+		doc.Wards = append(doc.Wards, FeedWard{
+			Seq:       ward.Seq,
+			UpdatedAt: ward.UpdatedAt,
+			Election:  ward.Election,
+			Result:    ward.Result,
+		})
+		doc.Seq += ward.Seq
+		if ward.UpdatedAt.After(doc.UpdatedAt) {
+			doc.UpdatedAt = ward.UpdatedAt
+		}
+	}
+	if len(doc.Wards) == 0 {
+		return nil, ErrLocalBodyFeedNotFound
+	}
+
+	doc.LocalBody = FeedLocalBody{Name: name, District: district, ElectionType: electionType, Parties: []FeedParty{}, Alliances: []FeedAlliance{}}
+	rollups, err := BuildRollups(RollupLocalBody, RollupFilter{District: district, ElectionType: electionType})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rollups {
+		if r.LocalBodyName != name {
+			continue
+		}
+		doc.LocalBody.Block = r.Block
+		doc.LocalBody.TotalSeats = r.TotalSeats
+		doc.LocalBody.DeclaredSeats = r.DeclaredSeats
+		doc.LocalBody.MajorityMark = r.MajorityMark
+		doc.LocalBody.Control = r.Control
+		doc.LocalBody.ControllingParty = r.ControllingParty
+		doc.LocalBody.TotalVotes = r.TotalVotes
+		doc.LocalBody.ControllingAlliance = r.ControllingAlliance
+		for _, a := range r.Alliances {
+			doc.LocalBody.Alliances = append(doc.LocalBody.Alliances, FeedAlliance{
+				Alliance:  a.Alliance,
+				SeatsWon:  a.SeatsWon,
+				Votes:     a.Votes,
+				VoteShare: a.VoteShare,
+			})
+		}
+		doc.LocalBody.Independents = FeedParty{
+			Party:     IndependentLabel,
+			SeatsWon:  r.Independents.SeatsWon,
+			Votes:     r.Independents.Votes,
+			VoteShare: r.Independents.VoteShare,
+		}
+		for _, p := range r.Parties {
+			doc.LocalBody.Parties = append(doc.LocalBody.Parties, FeedParty{
+				Party:     p.Party,
+				SeatsWon:  p.SeatsWon,
+				Votes:     p.Votes,
+				VoteShare: p.VoteShare,
+			})
+		}
+		break
+	}
+	return &doc, nil
+}
+
+func LocalBodyFeedURL(district, name, electionType string) string {
+	q := url.Values{}
+	q.Set("district", district)
+	q.Set("name", name)
+	q.Set("election_type", electionType)
+	return FeedBasePath + "/local-bodies?" + q.Encode()
+}
+
+// BuildFeedManifest lists every published election and local body with the
+// sequence number a client should compare before fetching the document. It
+// reads the stored revisions and counts no results.
+func BuildFeedManifest() (*FeedManifest, error) {
+	var elections []models.Election
+	if err := database.PostgresDB.Where("is_published = ?", true).Order("id asc").Find(&elections).Error; err != nil {
+		return nil, errors.New("failed to load elections")
+	}
+
+	m := FeedManifest{
+		SchemaVersion: FeedSchemaVersion,
+		Elections:     []FeedManifestElection{},
+		LocalBodies:   []FeedManifestLocalBody{},
+	}
+	bodies := make(map[string]*FeedManifestLocalBody)
+
+	revs := feedRevisions(elections)
+	for _, e := range elections {
+		rev := revs[e.ID]
+		status := rev.ResultStatus
+		if status == "" {
+			status = ResultStatusDraft
+		}
+		localBody := LocalBodyName(e)
+		m.Elections = append(m.Elections, FeedManifestElection{
+			ID:            e.ID,
+			Title:         e.Title,
+			ElectionType:  e.ElectionType,
+			District:      e.District,
+			LocalBodyName: localBody,
+			Ward:          e.Ward,
+			ResultStatus:  status,
+			Seq:           rev.Seq,
+			UpdatedAt:     rev.UpdatedAt.UTC(),
+			URL:           fmt.Sprintf("%s/elections/%d", FeedBasePath, e.ID),
+		})
+		m.Seq += rev.Seq
+		if rev.UpdatedAt.After(m.UpdatedAt) {
+			m.UpdatedAt = rev.UpdatedAt.UTC()
+		}
+
+		if e.Ward == "" || localBody == "" {
+			continue
+		}
+		key := e.District + "|" + e.ElectionType + "|" + localBody
+		body, ok := bodies[key]
+		if !ok {
+			body = &FeedManifestLocalBody{
+				Name:         localBody,
+				District:     e.District,
+				ElectionType: e.ElectionType,
+				URL:          LocalBodyFeedURL(e.District, localBody, e.ElectionType),
+			}
+			bodies[key] = body
+		}
+		body.Wards++
+		body.Seq += rev.Seq
+		if rev.UpdatedAt.After(body.UpdatedAt) {
+			body.UpdatedAt = rev.UpdatedAt.UTC()
+		}
+	}
+
+	for _, b := range bodies {
+		m.LocalBodies = append(m.LocalBodies, *b)
+	}
+	sort.Slice(m.LocalBodies, func(i, j int) bool {
+		if m.LocalBodies[i].District != m.LocalBodies[j].District {
+			return m.LocalBodies[i].District < m.LocalBodies[j].District
+		}
+		return m.LocalBodies[i].Name < m.LocalBodies[j].Name
+	})
+	return &m, nil
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ValidateIndirect checks the settings of an indirect election: it covers a
+// whole Grama Panchayat, Municipality or Corporation and elects one member on
+// a single-choice ballot.
+func ValidateIndirect(e models.Election) error {
+	if !e.Indirect {
+		if e.OpenBallot {
+			return errors.New("open ballots are only used in indirect elections")
+		}
+		return nil
+	}
+
+	switch e.ElectionType {
+	case "Grama Panchayat", "Municipality", "Municipal Corporation":
+	default:
+		return errors.New("indirect elections are held by a Grama Panchayat, Municipality or Municipal Corporation")
+	}
+	if e.LocalBodyName == "" {
+		return errors.New("local body name is required for an indirect election")
+	}
+	if e.Ward != "" {
+		return errors.New("an indirect election covers the whole local body, not a ward")
+	}
+	if e.BallotType != BallotSingle || e.Seats != 1 {
+		return errors.New("indirect elections use a single-choice ballot for one office")
+	}
+	if e.AllowNota || e.Weighted {
+		return errors.New("indirect elections offer neither NOTA nor weighted voting")
+	}
+	if strings.TrimSpace(e.Office) == "" {
+		return errors.New("office is required for an indirect election, e.g. President or Chairperson")
+	}
+	return nil
+}
+
+// wardElections are the direct ward elections of the local body an indirect
+// election belongs to.
+func wardElections(e models.Election) ([]models.Election, error) {
+	query := database.PostgresDB.
+		Where("indirect = ? AND ward <> ''", false).
+		Where("district = ? AND election_type = ? AND local_body_name = ?", e.District, e.ElectionType, e.LocalBodyName)
+	if e.ElectionType == "Grama Panchayat" {
+		query = query.Where("block = ?", e.Block)
+	}
+
+	var wards []models.Election
+	err := query.Order("ward asc").Find(&wards).Error
+	return wards, err
+}
+
+// BuildIndirectElectorate seats the declared winners of every ward election of
+// the local body as the electorate of an indirect election. It refuses while a
+// ward result is not yet declared or a winner is not linked to a voter.
+func BuildIndirectElectorate(electionID uint) ([]models.IndirectElector, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	if !election.Indirect {
+		return nil, errors.New("election is not an indirect election")
+	}
+	if election.Round > 1 {
+		return nil, errors.New("a second round keeps the electorate of the first")
+	}
+
+	var votes int64
+	database.PostgresDB.Model(&models.Vote{}).Where("election_id = ?", electionID).Count(&votes)
+	if votes > 0 {
+		return nil, errors.New("cannot change the electorate after voting has started")
+	}
+
+	wards, err := wardElections(election)
+	if err != nil {
+		return nil, errors.New("failed to load ward elections")
+	}
+	if len(wards) == 0 {
+		return nil, errors.New("no ward elections found for this local body")
+	}
+
+	var pending, unlinked []string
+	var electors []models.IndirectElector
+	seen := make(map[uint]bool)
+	for _, w := range wards {
+		result, err := GetDeclaredResult(w.ID)
+		if err != nil || result.Status != ResultStatusFinal {
+			pending = append(pending, w.Ward)
+			continue
+		}
+		for _, entry := range result.Entries {
+			if !entry.IsElected {
+				continue
+			}
+			var cand models.Candidate
+			if err := database.PostgresDB.First(&cand, entry.CandidateID).Error; err != nil || cand.VoterID == nil {
+				unlinked = append(unlinked, fmt.Sprintf("%s (ward %s)", entry.CandidateName, w.Ward))
+				continue
+			}
+			if seen[*cand.VoterID] {
+				continue
+			}
+			seen[*cand.VoterID] = true
+			electors = append(electors, models.IndirectElector{
+				ElectionID:     electionID,
+				VoterID:        *cand.VoterID,
+				WardElectionID: w.ID,
+				CandidateID:    cand.ID,
+				Ward:           w.Ward,
+				FullName:       cand.FullName,
+			})
+		}
+	}
+
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("results are not yet declared for ward(s) %s", strings.Join(pending, ", "))
+	}
+	if len(unlinked) > 0 {
+		return nil, fmt.Errorf("elected members not linked to a voter registration: %s", strings.Join(unlinked, ", "))
+	}
+
+	err = database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("election_id = ?", electionID).Delete(&models.IndirectElector{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&electors).Error
+	})
+	if err != nil {
+		return nil, errors.New("failed to store the electorate")
+	}
+	return electors, nil
+}
+
+func ListIndirectElectorate(electionID uint) ([]models.IndirectElector, error) {
+	var electors []models.IndirectElector
+	err := database.PostgresDB.Where("election_id = ?", electionID).Order("ward asc").Find(&electors).Error
+	return electors, err
+}
+
+func IsIndirectElector(electionID, voterID uint) bool {
+	var count int64
+	database.PostgresDB.Model(&models.IndirectElector{}).
+		Where("election_id = ? AND voter_id = ?", electionID, voterID).
+		Count(&count)
+	return count > 0
+}
+
+// ValidateIndirectCandidate requires candidates of an indirect election to be
+// members of its electorate.
+func ValidateIndirectCandidate(e models.Election, voterID *uint) error {
+	if !e.Indirect {
+		return nil
+	}
+	if voterID == nil || !IsIndirectElector(e.ID, *voterID) {
+		return errors.New("candidates must be elected members of the local body")
+	}
+	return nil
+}
+
+// applyMajorityRule flags the first round of an indirect election for a second
+// round when the leader does not hold more than half of the votes cast.
+func applyMajorityRule(result *models.ElectionResult, election models.Election) {
+	if !election.Indirect || election.Round > 1 || result.TotalVotes == 0 || len(result.Entries) == 0 {
+		return
+	}
+	if result.Entries[0].VoteCount*2 > result.TotalVotes && (len(result.Entries) == 1 || result.Entries[1].VoteCount < result.Entries[0].VoteCount) {
+		return
+	}
+
+	result.NeedsSecondRound = true
+	result.IsTie = false
+	result.PendingTie = nil
+	result.TieBreak = nil
+	result.TieBreaks = nil
+	result.Status = ResultStatusDraft
+	for i := range result.Entries {
+		result.Entries[i].IsElected = false
+		result.Entries[i].WonByTieBreak = false
+	}
+}
+
+// secondRoundCandidates are the first-round leaders: everyone tied for first,
+// or the leader and everyone tied for second.
+func secondRoundCandidates(entries []models.ElectionResultEntry) []uint {
+	sorted := append([]models.ElectionResultEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].VoteCount > sorted[j].VoteCount })
+
+	var ids []uint
+	if len(sorted) < 2 {
+		for _, e := range sorted {
+			ids = append(ids, e.CandidateID)
+		}
+		return ids
+	}
+
+	cutoff := sorted[1].VoteCount
+	for _, e := range sorted {
+		if e.VoteCount >= cutoff {
+			ids = append(ids, e.CandidateID)
+		}
+	}
+	return ids
+}
+
+// StartSecondRound opens the next round of an indirect election whose first
+// round gave no majority, between the leading candidates and with the same
+// electorate and ballot settings.
+func StartSecondRound(electionID uint, start, end time.Time) (*models.Election, error) {
+	var prev models.Election
+	if err := database.PostgresDB.First(&prev, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	if !prev.Indirect {
+		return nil, errors.New("second rounds are only held in indirect elections")
+	}
+	if prev.IsActive && time.Now().Before(prev.EndDate) {
+		return nil, errors.New("close the current round before starting the next")
+	}
+
+	var existing int64
+	database.PostgresDB.Model(&models.Election{}).Where("previous_round_id = ?", prev.ID).Count(&existing)
+	if existing > 0 {
+		return nil, errors.New("a second round has already been started")
+	}
+
+	result, err := ComputeElectionResult(prev.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !result.NeedsSecondRound {
+		return nil, errors.New("the first round produced a majority; no second round is needed")
+	}
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+
+	ids := secondRoundCandidates(result.Entries)
+
+	next := prev
+	next.ID = 0
+	next.CreatedAt, next.UpdatedAt = time.Time{}, time.Time{}
+	next.Title = fmt.Sprintf("%s (Round %d)", strings.TrimSuffix(prev.Title, fmt.Sprintf(" (Round %d)", prev.Round)), prev.Round+1)
+	next.Round = prev.Round + 1
+	next.PreviousRoundID = &prev.ID
+	next.StartDate, next.EndDate = start, end
+	next.IsActive, next.IsPublished = false, false
+	next.BallotFrozenAt = nil
+	next.Status = "UPCOMING"
+
+	err = database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO indirect_electors (election_id, voter_id, ward_election_id, candidate_id, ward, full_name)
+			SELECT ?, voter_id, ward_election_id, candidate_id, ward, full_name
+			FROM indirect_electors WHERE election_id = ?
+		`, next.ID, prev.ID).Error; err != nil {
+			return err
+		}
+
+		var candidates []models.Candidate
+		if err := tx.Where("id IN ?", ids).Find(&candidates).Error; err != nil {
+			return err
+		}
+		for _, cand := range candidates {
+			copyCand := models.Candidate{
+				FullName:   cand.FullName,
+				NameKey:    cand.NameKey,
+				ElectionID: next.ID,
+				PartyID:    cand.PartyID,
+				Bio:        cand.Bio,
+				Photo:      cand.Photo,
+				VoterID:    cand.VoterID,
+				Gender:     cand.Gender,
+				Category:   cand.Category,
+				Symbol:     cand.Symbol,
+
+				NominationStatus: NominationFinal,
+			}
+			if err := tx.Create(&copyCand).Error; err != nil {
+				return err
+			}
+		}
+		return freezeBallot(tx, next.ID, time.Now())
+	})
+	if err != nil {
+		return nil, errors.New("failed to create the second round")
+	}
+	return &next, nil
+}
+
+// OpenBallotEntry is one member's recorded vote in an open-ballot election.
+type OpenBallotEntry struct {
+	VoterName     string    `json:"voter_name"`
+	Ward          string    `json:"ward"`
+	CandidateID   uint      `json:"candidate_id"`
+	CandidateName string    `json:"candidate_name"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+func ListOpenBallots(electionID uint) ([]OpenBallotEntry, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	if !election.OpenBallot {
+		return nil, errors.New("this election uses a secret ballot")
+	}
+
+	var out []OpenBallotEntry
+	err := database.PostgresDB.Table("open_ballot_records").
+		Select(`
+			indirect_electors.full_name as voter_name,
+			indirect_electors.ward,
+			open_ballot_records.candidate_id,
+			candidates.full_name as candidate_name,
+			open_ballot_records.timestamp
+		`).
+		Joins("JOIN indirect_electors ON indirect_electors.election_id = open_ballot_records.election_id AND indirect_electors.voter_id = open_ballot_records.voter_id").
+		Joins("LEFT JOIN candidates ON candidates.id = open_ballot_records.candidate_id").
+		Where("open_ballot_records.election_id = ?", electionID).
+		Order("indirect_electors.ward asc").
+		Scan(&out).Error
+	return out, err
+}
@@ -64,6 +64,43 @@ func RetryVotesLogic() (int, []string) {
 		}
 
 		// 2. Retry Blockchain Write
+		var answers int64
+		database.PostgresDB.Model(&models.BallotAnswer{}).Where("vote_id = ?", vote.ID).Count(&answers)
+		if answers > 0 {
+			if err := AnchorAnswers(vote.VoteHash, participation.VoterID); err != nil {
+				logs = append(logs, fmt.Sprintf("Vote %d: Failed again (%v)", vote.ID, err))
+				continue
+			}
+			successCount++
+			logs = append(logs, fmt.Sprintf("Vote %d: REPAIRED (%d answers)", vote.ID, answers))
+			continue
+		}
+
+		var selections int64
+		database.PostgresDB.Model(&models.VoteSelection{}).
+			Joins("JOIN elections ON elections.id = vote_selections.election_id").
+			Where("vote_selections.vote_id = ? AND elections.ballot_type = ?", vote.ID, BallotApproval).
+			Count(&selections)
+		if selections > 0 {
+			if err := AnchorSelections(vote.VoteHash, participation.VoterID); err != nil {
+				logs = append(logs, fmt.Sprintf("Vote %d: Failed again (%v)", vote.ID, err))
+				continue
+			}
+			successCount++
+			logs = append(logs, fmt.Sprintf("Vote %d: REPAIRED (%d selections)", vote.ID, selections))
+			continue
+		}
+
+		if vote.Weight > 1 {
+			if err := AnchorWeightedVote(vote.VoteHash, participation.VoterID); err != nil {
+				logs = append(logs, fmt.Sprintf("Vote %d: Failed again (%v)", vote.ID, err))
+				continue
+			}
+			successCount++
+			logs = append(logs, fmt.Sprintf("Vote %d: REPAIRED (weight %d)", vote.ID, vote.Weight))
+			continue
+		}
+
 		txHash, err := CastVoteOnChain(vote.ElectionID, vote.CandidateID, participation.VoterID)
 		if err != nil {
 			logs = append(logs, fmt.Sprintf("Vote %d: Failed again (%v)", vote.ID, err))
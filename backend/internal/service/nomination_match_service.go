@@ -0,0 +1,443 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	MatchSameVoter        = "SAME_VOTER"
+	MatchSameNameDistrict = "SAME_NAME_DISTRICT"
+	MatchSameName         = "SAME_NAME" // advisory: does not hold back acceptance
+
+	MatchSameElection = "SAME_ELECTION"
+	MatchConcurrent   = "CONCURRENT"
+
+	MatchOpen      = "OPEN"
+	MatchConfirmed = "CONFIRMED"
+	MatchDismissed = "DISMISSED"
+)
+
+// blockingRules are the match rules an open match holds acceptance back on.
+// A confirmed match blocks whatever its rule.
+var blockingRules = []string{MatchSameVoter, MatchSameNameDistrict}
+
+// activeNominations can still reach the ballot.
+var activeNominations = []string{NominationFiled, NominationScrutiny, NominationAccepted, NominationFinal}
+
+var honorifics = map[string]bool{
+	"dr": true, "adv": true, "prof": true, "mr": true, "mrs": true, "ms": true,
+	"smt": true, "sri": true, "shri": true, "kum": true,
+}
+
+// NormalizeCandidateName reduces a name to the key nominations are compared
+// on: lower case, without punctuation, honorifics or repeated spaces.
+func NormalizeCandidateName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, name)
+
+	var words []string
+	for _, w := range strings.Fields(cleaned) {
+		if !honorifics[w] {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// CheckCandidateVoter requires a candidate's voter link to point at a voter
+// on the roll.
+func CheckCandidateVoter(voterID *uint) error {
+	if voterID == nil {
+		return nil
+	}
+	if err := database.PostgresDB.First(&models.Voter{}, *voterID).Error; err != nil {
+		return errors.New("voter registration not found")
+	}
+	return nil
+}
+
+// concurrentElections are the elections whose polling overlaps e's, e itself
+// included. Rounds of the same indirect election never overlap each other and
+// are left out.
+func concurrentElections(e models.Election) (map[uint]models.Election, error) {
+	var elections []models.Election
+	if err := database.PostgresDB.
+		Where("start_date < ? AND end_date > ?", e.EndDate, e.StartDate).
+		Find(&elections).Error; err != nil {
+		return nil, err
+	}
+	chain, err := roundChain(e)
+	if err != nil {
+		return nil, err
+	}
+	return concurrentWith(e, elections, chain), nil
+}
+
+// concurrentWith keeps the elections whose polling overlaps e's, less the
+// rounds in chain. e itself is always included.
+func concurrentWith(e models.Election, elections []models.Election, chain map[uint]bool) map[uint]models.Election {
+	out := map[uint]models.Election{e.ID: e}
+	for _, other := range elections {
+		if chain[other.ID] || !other.StartDate.Before(e.EndDate) || !other.EndDate.After(e.StartDate) {
+			continue
+		}
+		out[other.ID] = other
+	}
+	return out
+}
+
+// roundChain collects every round of e's indirect election, e included, by
+// following previous_round_id back to the first round and then forward.
+func roundChain(e models.Election) (map[uint]bool, error) {
+	chain := map[uint]bool{e.ID: true}
+	for prev := e.PreviousRoundID; prev != nil && !chain[*prev]; {
+		var p models.Election
+		err := database.PostgresDB.Select("id", "previous_round_id").First(&p, *prev).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chain[p.ID] = true
+		prev = p.PreviousRoundID
+	}
+
+	frontier := make([]uint, 0, len(chain))
+	for id := range chain {
+		frontier = append(frontier, id)
+	}
+	for len(frontier) > 0 {
+		var next []uint
+		if err := database.PostgresDB.Model(&models.Election{}).
+			Where("previous_round_id IN ?", frontier).
+			Pluck("id", &next).Error; err != nil {
+			return nil, err
+		}
+		frontier = frontier[:0]
+		for _, id := range next {
+			if !chain[id] {
+				chain[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return chain, nil
+}
+
+// DetectNominationMatches looks up the active nominations in the same or a
+// concurrent election that share the nomination's voter link or name key and
+// queues the likely duplicates for review. Pairs already reviewed keep their
+// decision; open matches that no longer apply are dropped. A successful run
+// clears the nomination's MatchScanPending flag.
+func DetectNominationMatches(candidateID uint) ([]models.NominationMatch, error) {
+	var cand models.Candidate
+	if err := database.PostgresDB.First(&cand, candidateID).Error; err != nil {
+		return nil, errors.New("candidate not found")
+	}
+	var election models.Election
+	if err := database.PostgresDB.First(&election, cand.ElectionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	elections, err := concurrentElections(election)
+	if err != nil {
+		return nil, errors.New("failed to load concurrent elections")
+	}
+	return detectNominationMatches(cand, elections)
+}
+
+// detectNominationMatches runs the duplicate check for cand against the
+// nominations of elections, the elections concurrent with cand's.
+func detectNominationMatches(cand models.Candidate, elections map[uint]models.Election) ([]models.NominationMatch, error) {
+	var found []models.NominationMatch
+	key := NormalizeCandidateName(cand.FullName)
+	active := false
+	for _, s := range activeNominations {
+		if cand.NominationStatus == s {
+			active = true
+		}
+	}
+
+	if active && (key != "" || cand.VoterID != nil) {
+		ids := make([]uint, 0, len(elections))
+		for id := range elections {
+			ids = append(ids, id)
+		}
+
+		same := database.PostgresDB.Where("name_key = ? AND name_key <> ''", key)
+		if cand.VoterID != nil {
+			same = same.Or("voter_id = ?", *cand.VoterID)
+		}
+		var others []models.Candidate
+		if err := database.PostgresDB.Select("id", "election_id", "voter_id", "name_key").
+			Where("election_id IN ? AND id <> ? AND nomination_status IN ?", ids, cand.ID, activeNominations).
+			Where(same).
+			Find(&others).Error; err != nil {
+			return nil, errors.New("failed to load nominations")
+		}
+
+		self := matchSubject{VoterID: cand.VoterID, NameKey: key, District: elections[cand.ElectionID].District}
+		for _, other := range others {
+			rule := classifyMatch(self, matchSubject{
+				VoterID:  other.VoterID,
+				NameKey:  other.NameKey,
+				District: elections[other.ElectionID].District,
+			})
+			if rule == "" {
+				continue
+			}
+
+			scope := MatchConcurrent
+			if other.ElectionID == cand.ElectionID {
+				scope = MatchSameElection
+			}
+			low, high := cand.ID, other.ID
+			if high < low {
+				low, high = high, low
+			}
+			found = append(found, models.NominationMatch{
+				CandidateID:        low,
+				MatchedCandidateID: high,
+				Rule:               rule,
+				Scope:              scope,
+				Status:             MatchOpen,
+			})
+		}
+	}
+
+	err := database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Where("(candidate_id = ? OR matched_candidate_id = ?) AND status = ?", cand.ID, cand.ID, MatchOpen)
+		for _, m := range found {
+			stale = stale.Where("NOT (candidate_id = ? AND matched_candidate_id = ?)", m.CandidateID, m.MatchedCandidateID)
+		}
+		if err := stale.Delete(&models.NominationMatch{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Candidate{}).Where("id = ?", cand.ID).Updates(map[string]interface{}{
+			"name_key":           key,
+			"match_scan_pending": false,
+		}).Error; err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "candidate_id"}, {Name: "matched_candidate_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rule", "scope"}),
+		}).Create(&found).Error
+	})
+	if err != nil {
+		return nil, errors.New("failed to store nomination matches")
+	}
+	return found, nil
+}
+
+// matchSubject is what two nominations are compared on.
+type matchSubject struct {
+	VoterID  *uint
+	NameKey  string
+	District string
+}
+
+// classifyMatch names the rule under which a and b look like the same
+// person, or returns "" when they do not. Voter links decide when both sides
+// have one. Otherwise a shared name is only enough to block acceptance within
+// one district; across districts it is queued as advisory.
+func classifyMatch(a, b matchSubject) string {
+	if a.VoterID != nil && b.VoterID != nil {
+		if *a.VoterID == *b.VoterID {
+			return MatchSameVoter
+		}
+		return ""
+	}
+	if a.NameKey == "" || a.NameKey != b.NameKey {
+		return ""
+	}
+	if a.District != "" && strings.EqualFold(strings.TrimSpace(a.District), strings.TrimSpace(b.District)) {
+		return MatchSameNameDistrict
+	}
+	return MatchSameName
+}
+
+// ScanElectionNominations runs the duplicate checks over every nomination of
+// an election, for nominations filed before the checks existed.
+func ScanElectionNominations(electionID uint) (int, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return 0, errors.New("election not found")
+	}
+	elections, err := concurrentElections(election)
+	if err != nil {
+		return 0, errors.New("failed to load concurrent elections")
+	}
+	var candidates []models.Candidate
+	if err := database.PostgresDB.Where("election_id = ?", electionID).Find(&candidates).Error; err != nil {
+		return 0, errors.New("failed to load nominations")
+	}
+
+	seen := make(map[[2]uint]bool)
+	for _, cand := range candidates {
+		matches, err := detectNominationMatches(cand, elections)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range matches {
+			seen[[2]uint{m.CandidateID, m.MatchedCandidateID}] = true
+		}
+	}
+	return len(seen), nil
+}
+
+// BackfillCandidateNameKeys sets the name key of nominations stored before
+// the duplicate check looked nominations up by it.
+func BackfillCandidateNameKeys() {
+	var candidates []models.Candidate
+	database.PostgresDB.Select("id", "full_name").Where("name_key IS NULL OR name_key = ''").Find(&candidates)
+	for _, cand := range candidates {
+		key := NormalizeCandidateName(cand.FullName)
+		if key == "" {
+			continue
+		}
+		if err := database.PostgresDB.Model(&models.Candidate{}).Where("id = ?", cand.ID).Update("name_key", key).Error; err != nil {
+			log.Printf("Failed to set the name key of candidate %d: %v", cand.ID, err)
+		}
+	}
+}
+
+// RescanElectionNominations holds back every nomination of an election and
+// runs the duplicate checks again, for when its polling dates change and so
+// which elections are concurrent with it.
+func RescanElectionNominations(electionID uint) (int, error) {
+	if err := database.PostgresDB.Model(&models.Candidate{}).
+		Where("election_id = ?", electionID).
+		Update("match_scan_pending", true).Error; err != nil {
+		return 0, errors.New("failed to flag nominations for rescan")
+	}
+	return ScanElectionNominations(electionID)
+}
+
+// unresolvedMatches lists the nominations among ids that are held back by a
+// confirmed match, or an open one under a blocking rule, with another
+// nomination still in the running.
+func unresolvedMatches(ids []uint) ([]models.NominationMatch, error) {
+	var matches []models.NominationMatch
+	err := database.PostgresDB.Preload("Candidate").Preload("MatchedCandidate").
+		Where("status = ? OR (status = ? AND rule IN ?)", MatchConfirmed, MatchOpen, blockingRules).
+		Where("(candidate_id IN ? OR matched_candidate_id IN ?)", ids, ids).
+		Find(&matches).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.NominationMatch
+	for _, m := range matches {
+		if m.Candidate == nil || m.MatchedCandidate == nil {
+			continue
+		}
+		if m.Candidate.NominationStatus == NominationRejected || m.Candidate.NominationStatus == NominationWithdrawn ||
+			m.MatchedCandidate.NominationStatus == NominationRejected || m.MatchedCandidate.NominationStatus == NominationWithdrawn {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// checkNominationMatches refuses to accept a nomination while it is part of an
+// unresolved duplicate or its duplicate check has not run.
+func checkNominationMatches(ids []uint) error {
+	var pending models.Candidate
+	err := database.PostgresDB.Select("id", "full_name").
+		Where("id IN ? AND match_scan_pending = ?", ids, true).
+		First(&pending).Error
+	if err == nil {
+		return fmt.Errorf("the duplicate check for %s (#%d) has not completed; rescan the election's nominations first",
+			pending.FullName, pending.ID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("failed to check nomination matches")
+	}
+
+	matches, err := unresolvedMatches(ids)
+	if err != nil {
+		return errors.New("failed to check nomination matches")
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	m := matches[0]
+	if m.Status == MatchConfirmed {
+		return fmt.Errorf("%s (#%d) and %s (#%d) are confirmed duplicates; reject or withdraw one of them first",
+			m.Candidate.FullName, m.CandidateID, m.MatchedCandidate.FullName, m.MatchedCandidateID)
+	}
+	return fmt.Errorf("%s (#%d) and %s (#%d) are awaiting duplicate review",
+		m.Candidate.FullName, m.CandidateID, m.MatchedCandidate.FullName, m.MatchedCandidateID)
+}
+
+func ListNominationMatches(status string, electionID uint) ([]models.NominationMatch, error) {
+	query := database.PostgresDB.Preload("Candidate").Preload("MatchedCandidate").Model(&models.NominationMatch{})
+	if status != "ALL" {
+		query = query.Where("status = ?", status)
+	}
+	if electionID > 0 {
+		query = query.Where("(candidate_id IN (?) OR matched_candidate_id IN (?))",
+			database.PostgresDB.Model(&models.Candidate{}).Select("id").Where("election_id = ?", electionID),
+			database.PostgresDB.Model(&models.Candidate{}).Select("id").Where("election_id = ?", electionID))
+	}
+
+	var matches []models.NominationMatch
+	err := query.Order("created_at asc").Find(&matches).Error
+	return matches, err
+}
+
+// ReviewNominationMatch records an admin's decision on a match: CONFIRMED for
+// a genuine duplicate, which keeps both nominations from being accepted until
+// one is rejected or withdrawn, or DISMISSED for different people.
+func ReviewNominationMatch(matchID uint, decision, note string, reviewer uint, now time.Time) (*models.NominationMatch, error) {
+	var m models.NominationMatch
+	if err := database.PostgresDB.First(&m, matchID).Error; err != nil {
+		return nil, errors.New("match not found")
+	}
+	decision = strings.ToUpper(strings.TrimSpace(decision))
+	if decision != MatchConfirmed && decision != MatchDismissed {
+		return nil, errors.New("decision must be CONFIRMED or DISMISSED")
+	}
+	if m.Status != MatchOpen {
+		return nil, fmt.Errorf("match has already been %s", strings.ToLower(m.Status))
+	}
+	note = strings.TrimSpace(note)
+	if decision == MatchDismissed && note == "" {
+		return nil, errors.New("a note is required to dismiss a match")
+	}
+
+	// Only the first of two concurrent reviews gets to decide.
+	res := database.PostgresDB.Model(&m).Where("status = ?", MatchOpen).Updates(map[string]interface{}{
+		"status":      decision,
+		"review_note": note,
+		"reviewed_by": reviewer,
+		"reviewed_at": now,
+	})
+	if res.Error != nil {
+		return nil, errors.New("failed to save review")
+	}
+	if res.RowsAffected == 0 {
+		return nil, errors.New("match has already been reviewed")
+	}
+	database.PostgresDB.Preload("Candidate").Preload("MatchedCandidate").First(&m, m.ID)
+	return &m, nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"E-voting/internal/models"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestNormalizeCandidateName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Anita Sharma", "anita sharma"},
+		{"case and spacing", "  ANITA   sharma ", "anita sharma"},
+		{"honorific", "Dr. Anita Sharma", "anita sharma"},
+		{"several honorifics", "Smt. Adv. Anita Sharma", "anita sharma"},
+		{"punctuation and initials", "K.P. Raman-Nair", "k p raman nair"},
+		{"honorific inside a word is kept", "Drona Mrinal", "drona mrinal"},
+		{"digits kept", "Ward 7 Raju", "ward 7 raju"},
+		{"non-latin letters", "Ánita Śarmā", "ánita śarmā"},
+		{"only honorifics", "Mr. Dr.", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeCandidateName(tt.in); got != tt.want {
+				t.Errorf("NormalizeCandidateName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrentWith(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 11, d, 0, 0, 0, 0, time.UTC) }
+	election := func(id uint, start, end int) models.Election {
+		e := models.Election{StartDate: day(start), EndDate: day(end)}
+		e.ID = id
+		return e
+	}
+	e := election(1, 10, 12)
+
+	tests := []struct {
+		name      string
+		elections []models.Election
+		chain     map[uint]bool
+		want      []uint
+	}{
+		{
+			name:      "overlapping elections are concurrent",
+			elections: []models.Election{election(2, 11, 13), election(3, 9, 11), election(4, 5, 20)},
+			want:      []uint{1, 2, 3, 4},
+		},
+		{
+			name:      "touching end and start do not overlap",
+			elections: []models.Election{election(2, 12, 14), election(3, 8, 10)},
+			want:      []uint{1},
+		},
+		{
+			name:      "every round of the chain is left out",
+			elections: []models.Election{election(2, 11, 13), election(3, 10, 12), election(4, 11, 12)},
+			chain:     map[uint]bool{1: true, 2: true, 3: true},
+			want:      []uint{1, 4},
+		},
+		{
+			name: "the election itself is always included",
+			want: []uint{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := tt.chain
+			if chain == nil {
+				chain = map[uint]bool{e.ID: true}
+			}
+			var got []uint
+			for id := range concurrentWith(e, tt.elections, chain) {
+				got = append(got, id)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("concurrent = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyMatch(t *testing.T) {
+	voter := func(id uint) *uint { return &id }
+
+	tests := []struct {
+		name string
+		a, b matchSubject
+		want string
+	}{
+		{
+			name: "same voter whatever the name",
+			a:    matchSubject{VoterID: voter(1), NameKey: "anita sharma", District: "Kollam"},
+			b:    matchSubject{VoterID: voter(1), NameKey: "a sharma", District: "Idukki"},
+			want: MatchSameVoter,
+		},
+		{
+			name: "different voters are different people",
+			a:    matchSubject{VoterID: voter(1), NameKey: "anita sharma", District: "Kollam"},
+			b:    matchSubject{VoterID: voter(2), NameKey: "anita sharma", District: "Kollam"},
+		},
+		{
+			name: "same name in the same district blocks",
+			a:    matchSubject{VoterID: voter(1), NameKey: "anita sharma", District: "Kollam"},
+			b:    matchSubject{NameKey: "anita sharma", District: " kollam"},
+			want: MatchSameNameDistrict,
+		},
+		{
+			name: "same name across districts is advisory",
+			a:    matchSubject{NameKey: "anita sharma", District: "Kollam"},
+			b:    matchSubject{NameKey: "anita sharma", District: "Idukki"},
+			want: MatchSameName,
+		},
+		{
+			name: "no district is advisory",
+			a:    matchSubject{NameKey: "anita sharma"},
+			b:    matchSubject{NameKey: "anita sharma"},
+			want: MatchSameName,
+		},
+		{
+			name: "different names",
+			a:    matchSubject{NameKey: "anita sharma", District: "Kollam"},
+			b:    matchSubject{NameKey: "anil sharma", District: "Kollam"},
+		},
+		{
+			name: "empty names never match",
+			a:    matchSubject{District: "Kollam"},
+			b:    matchSubject{District: "Kollam"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyMatch(tt.a, tt.b); got != tt.want {
+				t.Errorf("classifyMatch = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
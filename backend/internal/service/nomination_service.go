@@ -0,0 +1,194 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NominationFiled     = "FILED"
+	NominationScrutiny  = "UNDER_SCRUTINY"
+	NominationAccepted  = "ACCEPTED"
+	NominationRejected  = "REJECTED"
+	NominationWithdrawn = "WITHDRAWN"
+	NominationFinal     = "FINAL"
+)
+
+// nominationSteps lists the statuses a nomination may move to by hand. FINAL
+// is only reached through FinalizeNominations.
+var nominationSteps = map[string][]string{
+	NominationFiled:    {NominationScrutiny},
+	NominationScrutiny: {NominationAccepted, NominationRejected},
+	NominationAccepted: {NominationWithdrawn},
+}
+
+// pendingNominations are the statuses that still have to be settled before the
+// final list can be published.
+var pendingNominations = []string{NominationFiled, NominationScrutiny}
+
+// ValidateNominationDeadlines requires nominations to close before the
+// withdrawal deadline and both to fall before polling starts.
+func ValidateNominationDeadlines(e models.Election) error {
+	if e.NominationDeadline != nil && !e.NominationDeadline.Before(e.StartDate) {
+		return errors.New("nomination deadline must be before the start date")
+	}
+	if e.WithdrawalDeadline != nil {
+		if !e.WithdrawalDeadline.Before(e.StartDate) {
+			return errors.New("withdrawal deadline must be before the start date")
+		}
+		if e.NominationDeadline != nil && !e.WithdrawalDeadline.After(*e.NominationDeadline) {
+			return errors.New("withdrawal deadline must be after the nomination deadline")
+		}
+	}
+	return nil
+}
+
+// CheckNominationsOpen refuses new nominations after the nomination deadline
+// or once polling has started.
+func CheckNominationsOpen(e models.Election, now time.Time) error {
+	if e.NominationDeadline != nil && now.After(*e.NominationDeadline) {
+		return fmt.Errorf("nominations closed on %s", e.NominationDeadline.Format(time.RFC1123))
+	}
+	if e.IsActive && now.After(e.StartDate) {
+		return errors.New("nominations cannot be filed once polling has started")
+	}
+	return nil
+}
+
+func nominationsLocked(electionID uint) bool {
+	var votes int64
+	database.PostgresDB.Model(&models.Vote{}).Where("election_id = ?", electionID).Count(&votes)
+	return votes > 0
+}
+
+// ChangeNominationStatus moves a nomination one step along its lifecycle and
+// returns the status it left. Rejections need a reason; withdrawals close at
+// the election's withdrawal deadline.
+func ChangeNominationStatus(cand *models.Candidate, to, reason string, now time.Time) (string, error) {
+	to = strings.ToUpper(strings.TrimSpace(to))
+	reason = strings.TrimSpace(reason)
+
+	allowed := false
+	for _, next := range nominationSteps[cand.NominationStatus] {
+		if next == to {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return "", fmt.Errorf("a %s nomination cannot be moved to %s", cand.NominationStatus, to)
+	}
+	if to == NominationRejected && reason == "" {
+		return "", errors.New("a reason is required to reject a nomination")
+	}
+
+	var election models.Election
+	if err := database.PostgresDB.First(&election, cand.ElectionID).Error; err != nil {
+		return "", errors.New("election not found")
+	}
+	if nominationsLocked(election.ID) {
+		return "", errors.New("nominations cannot change after voting has started")
+	}
+	if to == NominationWithdrawn && election.WithdrawalDeadline != nil && now.After(*election.WithdrawalDeadline) {
+		return "", fmt.Errorf("withdrawals closed on %s", election.WithdrawalDeadline.Format(time.RFC1123))
+	}
+	if to == NominationAccepted {
+		if err := checkNominationMatches([]uint{cand.ID}); err != nil {
+			return "", err
+		}
+	}
+
+	from := cand.NominationStatus
+	err := database.PostgresDB.Model(cand).Updates(map[string]interface{}{
+		"nomination_status":     to,
+		"nomination_reason":     reason,
+		"nomination_updated_at": now,
+	}).Error
+	if err != nil {
+		return "", errors.New("failed to update nomination")
+	}
+	cand.NominationStatus = to
+	cand.NominationReason = reason
+	cand.NominationUpdatedAt = &now
+	return from, nil
+}
+
+// FinalizeNominations publishes the final list of candidates: every accepted
+// nomination becomes FINAL and the ballot is frozen. It waits for the
+// withdrawal deadline and for scrutiny of every filed nomination to finish.
+func FinalizeNominations(electionID uint, now time.Time) ([]models.Candidate, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	if election.BallotFrozenAt != nil {
+		return nil, errors.New("the final list has already been published")
+	}
+	if election.WithdrawalDeadline != nil && now.Before(*election.WithdrawalDeadline) {
+		return nil, fmt.Errorf("the final list can be published after the withdrawal deadline (%s)", election.WithdrawalDeadline.Format(time.RFC1123))
+	}
+	if nominationsLocked(election.ID) {
+		return nil, errors.New("nominations cannot change after voting has started")
+	}
+
+	var pending int64
+	database.PostgresDB.Model(&models.Candidate{}).
+		Where("election_id = ? AND nomination_status IN ?", electionID, pendingNominations).
+		Count(&pending)
+	if pending > 0 {
+		return nil, fmt.Errorf("%d nomination(s) are still awaiting scrutiny", pending)
+	}
+
+	var accepted []models.Candidate
+	if err := database.PostgresDB.Where("election_id = ? AND nomination_status = ?", electionID, NominationAccepted).Find(&accepted).Error; err != nil {
+		return nil, errors.New("failed to load nominations")
+	}
+	if len(accepted) == 0 {
+		return nil, errors.New("there are no accepted nominations to finalise")
+	}
+
+	var ids []uint
+	for _, cand := range accepted {
+		ids = append(ids, cand.ID)
+	}
+	if err := checkNominationMatches(ids); err != nil {
+		return nil, err
+	}
+	err := database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Candidate{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"nomination_status":     NominationFinal,
+			"nomination_updated_at": now,
+		}).Error; err != nil {
+			return errors.New("failed to publish the final list")
+		}
+		return freezeBallot(tx, electionID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var final []models.Candidate
+	database.PostgresDB.Where("id IN ?", ids).Order("serial_no asc").Find(&final)
+	return final, nil
+}
+
+// CheckFinalList keeps a candidate election closed while nominations are
+// still open or unsettled.
+func CheckFinalList(e models.Election) error {
+	if e.BallotType == BallotReferendum {
+		return nil
+	}
+	var open int64
+	database.PostgresDB.Model(&models.Candidate{}).
+		Where("election_id = ? AND nomination_status IN ?", e.ID, []string{NominationFiled, NominationScrutiny, NominationAccepted}).
+		Count(&open)
+	if open > 0 {
+		return errors.New("publish the final list of candidates before opening the election")
+	}
+	return nil
+}
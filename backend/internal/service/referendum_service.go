@@ -0,0 +1,355 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	BallotReferendum = "REFERENDUM"
+
+	QuestionYesNo          = "YES_NO"
+	QuestionMultipleChoice = "MULTIPLE_CHOICE"
+
+	OutcomePassed     = "PASSED"
+	OutcomeRejected   = "REJECTED"
+	OutcomeDecided    = "DECIDED"
+	OutcomeNoDecision = "NO_DECISION"
+	OutcomeNoQuorum   = "QUORUM_NOT_MET"
+
+	// OptionChainOffset keeps referendum options apart from candidate IDs in
+	// the contract's per-candidate vote counts.
+	OptionChainOffset uint = 1 << 31
+)
+
+type AnswerInput struct {
+	QuestionID uint   `json:"question_id"`
+	OptionIDs  []uint `json:"option_ids"`
+}
+
+type OptionResult struct {
+	OptionID  uint    `json:"option_id"`
+	Label     string  `json:"label"`
+	Votes     int64   `json:"votes"`
+	VoteShare float64 `json:"vote_share"`
+}
+
+type QuestionResult struct {
+	QuestionID       uint           `json:"question_id"`
+	Text             string         `json:"text"`
+	Kind             string         `json:"kind"`
+	Answered         int64          `json:"answered"`
+	Abstained        int64          `json:"abstained"`
+	Options          []OptionResult `json:"options"`
+	QuorumPercent    float64        `json:"quorum_percent"`
+	QuorumMet        bool           `json:"quorum_met"`
+	ThresholdPercent float64        `json:"threshold_percent"`
+	ThresholdMet     bool           `json:"threshold_met"`
+	LeadingOptionID  uint           `json:"leading_option_id,omitempty"`
+	Outcome          string         `json:"outcome"`
+}
+
+type ReferendumResult struct {
+	ElectionID  uint             `json:"election_id"`
+	Title       string           `json:"title"`
+	Final       bool             `json:"final"`
+	Eligible    int64            `json:"eligible"`
+	BallotsCast int64            `json:"ballots_cast"`
+	Turnout     float64          `json:"turnout"`
+	Questions   []QuestionResult `json:"questions"`
+}
+
+// NormalizeQuestion validates a question and builds its options. Yes/no
+// questions always get the options Yes and No, in that order.
+func NormalizeQuestion(q *models.BallotQuestion, labels []string) error {
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Text == "" {
+		return errors.New("question text is required")
+	}
+	if q.QuorumPercent < 0 || q.QuorumPercent > 100 || q.ThresholdPercent < 0 || q.ThresholdPercent > 100 {
+		return errors.New("quorum and threshold must be between 0 and 100")
+	}
+
+	switch q.Kind {
+	case "", QuestionYesNo:
+		q.Kind = QuestionYesNo
+		q.MaxChoices = 1
+		labels = []string{"Yes", "No"}
+	case QuestionMultipleChoice:
+		if len(labels) < 2 {
+			return errors.New("a multiple choice question needs at least two options")
+		}
+		if q.MaxChoices == 0 {
+			q.MaxChoices = 1
+		}
+		if q.MaxChoices < 1 || q.MaxChoices > len(labels) {
+			return errors.New("max choices must be between 1 and the number of options")
+		}
+	default:
+		return errors.New("question kind must be YES_NO or MULTIPLE_CHOICE")
+	}
+
+	seen := make(map[string]bool)
+	q.Options = nil
+	for i, l := range labels {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[strings.ToLower(l)] {
+			return errors.New("options must be non-empty and distinct")
+		}
+		seen[strings.ToLower(l)] = true
+		q.Options = append(q.Options, models.BallotOption{Position: i + 1, Label: l})
+	}
+	return nil
+}
+
+// CheckQuestionsEditable allows question changes on a referendum only until
+// the first ballot is cast.
+func CheckQuestionsEditable(electionID uint) error {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return errors.New("election not found")
+	}
+	if election.BallotType != BallotReferendum {
+		return errors.New("questions can only be added to REFERENDUM elections")
+	}
+
+	var votes int64
+	database.PostgresDB.Model(&models.Vote{}).Where("election_id = ?", electionID).Count(&votes)
+	if votes > 0 {
+		return errors.New("cannot change questions: ballots have already been cast")
+	}
+	return nil
+}
+
+func LoadQuestions(electionID uint) ([]models.BallotQuestion, error) {
+	var questions []models.BallotQuestion
+	err := database.PostgresDB.
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Where("election_id = ?", electionID).
+		Order("position asc, id asc").
+		Find(&questions).Error
+	return questions, err
+}
+
+// ValidateAnswers checks a referendum ballot. Questions may be left blank, but
+// at least one must be answered and none may exceed its max choices.
+func ValidateAnswers(e models.Election, answers []AnswerInput) ([]models.BallotAnswer, error) {
+	questions, err := LoadQuestions(e.ID)
+	if err != nil {
+		return nil, errors.New("failed to load questions")
+	}
+	byID := make(map[uint]models.BallotQuestion, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+
+	var out []models.BallotAnswer
+	answered := make(map[uint]bool)
+	for _, a := range answers {
+		if len(a.OptionIDs) == 0 {
+			continue
+		}
+		q, ok := byID[a.QuestionID]
+		if !ok {
+			return nil, errors.New("ballot answers a question that is not part of this election")
+		}
+		if answered[q.ID] {
+			return nil, errors.New("each question may only be answered once")
+		}
+		answered[q.ID] = true
+		if len(a.OptionIDs) > q.MaxChoices {
+			return nil, fmt.Errorf("question %d allows at most %d choice(s)", q.Position, q.MaxChoices)
+		}
+
+		picked := make(map[uint]bool)
+		for _, optID := range a.OptionIDs {
+			valid := false
+			for _, o := range q.Options {
+				if o.ID == optID {
+					valid = true
+					break
+				}
+			}
+			if !valid || picked[optID] {
+				return nil, fmt.Errorf("invalid choice for question %d", q.Position)
+			}
+			picked[optID] = true
+			out = append(out, models.BallotAnswer{ElectionID: e.ID, QuestionID: q.ID, OptionID: optID})
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, errors.New("answer at least one question")
+	}
+	return out, nil
+}
+
+// RecordAnswerTally bumps the question and option tallies inside the vote transaction.
+func RecordAnswerTally(tx *gorm.DB, answers []models.BallotAnswer) error {
+	counted := make(map[uint]bool)
+	for _, a := range answers {
+		if !counted[a.QuestionID] {
+			counted[a.QuestionID] = true
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "question_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"answered": gorm.Expr("question_tallies.answered + 1")}),
+			}).Create(&models.QuestionTally{QuestionID: a.QuestionID, ElectionID: a.ElectionID, Answered: 1}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "question_id"}, {Name: "option_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"vote_count": gorm.Expr("option_tallies.vote_count + 1")}),
+		}).Create(&models.OptionTally{QuestionID: a.QuestionID, OptionID: a.OptionID, VoteCount: 1}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnchorAnswers writes each not yet anchored answer of a referendum ballot to
+// the chain as a selection of OptionChainOffset + option ID.
+func AnchorAnswers(voteHash string, voterID uint) error {
+	var vote models.Vote
+	if err := database.PostgresDB.Where("vote_hash = ?", voteHash).First(&vote).Error; err != nil {
+		return err
+	}
+
+	var answers []models.BallotAnswer
+	if err := database.PostgresDB.Where("vote_id = ? AND (blockchain_tx = '' OR blockchain_tx IS NULL)", vote.ID).
+		Order("id asc").Find(&answers).Error; err != nil {
+		return err
+	}
+
+	txHash := ""
+	for _, a := range answers {
+		hash, err := CastSelectionOnChain(vote.ElectionID, OptionChainOffset+a.OptionID, voterID)
+		if err != nil {
+			log.Printf("CRITICAL: Blockchain write failed for answer %d of VoteHash %s. Error: %v", a.ID, voteHash, err)
+			return err
+		}
+		if err := database.PostgresDB.Model(&a).Update("blockchain_tx", hash).Error; err != nil {
+			log.Printf("CRITICAL: Answer %d of VoteHash %s anchored in tx %s but not recorded: %v", a.ID, voteHash, hash, err)
+			return err
+		}
+		txHash = hash
+	}
+
+	if txHash == "" {
+		var last models.BallotAnswer
+		if err := database.PostgresDB.Where("vote_id = ?", vote.ID).Order("id desc").First(&last).Error; err != nil {
+			return err
+		}
+		txHash = last.BlockchainTx
+	}
+	return database.PostgresDB.Model(&vote).Update("blockchain_tx", txHash).Error
+}
+
+// ComputeReferendumResult reports turnout, option counts and, per question,
+// whether the quorum and threshold were met.
+func ComputeReferendumResult(electionID uint) (*ReferendumResult, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	if election.BallotType != BallotReferendum {
+		return nil, errors.New("election is not a referendum")
+	}
+
+	questions, err := LoadQuestions(electionID)
+	if err != nil {
+		return nil, errors.New("failed to load questions")
+	}
+
+	res := &ReferendumResult{
+		ElectionID: election.ID,
+		Title:      election.Title,
+		Final:      !election.IsActive || time.Now().After(election.EndDate),
+		Questions:  []QuestionResult{},
+	}
+
+	EligibleVoters(database.PostgresDB.Model(&models.Voter{}), election).
+		Where("voters.is_verified = ? AND voters.is_blocked = ?", true, false).
+		Count(&res.Eligible)
+
+	var tally models.ElectionTally
+	if err := database.PostgresDB.Where("election_id = ?", electionID).First(&tally).Error; err == nil {
+		res.BallotsCast = tally.TotalVotes
+	}
+	res.Turnout = percent(res.BallotsCast, res.Eligible)
+
+	var qTallies []models.QuestionTally
+	database.PostgresDB.Where("election_id = ?", electionID).Find(&qTallies)
+	answered := make(map[uint]int64)
+	for _, t := range qTallies {
+		answered[t.QuestionID] = t.Answered
+	}
+
+	var oTallies []models.OptionTally
+	database.PostgresDB.Where("question_id IN (?)", database.PostgresDB.Model(&models.BallotQuestion{}).Select("id").Where("election_id = ?", electionID)).Find(&oTallies)
+	votes := make(map[uint]int64)
+	for _, t := range oTallies {
+		votes[t.OptionID] = t.VoteCount
+	}
+
+	for _, q := range questions {
+		qr := QuestionResult{
+			QuestionID:       q.ID,
+			Text:             q.Text,
+			Kind:             q.Kind,
+			Answered:         answered[q.ID],
+			Abstained:        res.BallotsCast - answered[q.ID],
+			QuorumPercent:    q.QuorumPercent,
+			QuorumMet:        res.Turnout >= q.QuorumPercent,
+			ThresholdPercent: q.ThresholdPercent,
+		}
+		for _, o := range q.Options {
+			qr.Options = append(qr.Options, OptionResult{
+				OptionID:  o.ID,
+				Label:     o.Label,
+				Votes:     votes[o.ID],
+				VoteShare: percent(votes[o.ID], qr.Answered),
+			})
+		}
+		decideQuestion(&qr, q)
+		res.Questions = append(res.Questions, qr)
+	}
+	return res, nil
+}
+
+// decideQuestion applies the quorum and threshold. The leading option must be
+// strictly ahead of every other option; on a yes/no question anything but a
+// qualifying Yes is a rejection.
+func decideQuestion(qr *QuestionResult, q models.BallotQuestion) {
+	ranked := append([]OptionResult(nil), qr.Options...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Votes > ranked[j].Votes })
+
+	leads := len(ranked) > 0 && ranked[0].Votes > 0 && (len(ranked) == 1 || ranked[0].Votes > ranked[1].Votes)
+	if leads {
+		qr.LeadingOptionID = ranked[0].OptionID
+		qr.ThresholdMet = ranked[0].VoteShare >= qr.ThresholdPercent
+	}
+
+	switch {
+	case !qr.QuorumMet:
+		qr.Outcome = OutcomeNoQuorum
+	case q.Kind == QuestionYesNo:
+		qr.Outcome = OutcomeRejected
+		if leads && qr.ThresholdMet && len(q.Options) > 0 && ranked[0].OptionID == q.Options[0].ID {
+			qr.Outcome = OutcomePassed
+		}
+	case leads && qr.ThresholdMet:
+		qr.Outcome = OutcomeDecided
+	default:
+		qr.Outcome = OutcomeNoDecision
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ReservationGeneral = "GENERAL"
+	ReservationWomen   = "WOMEN"
+	ReservationSC      = "SC"
+	ReservationSCWomen = "SC_WOMEN"
+	ReservationST      = "ST"
+	ReservationSTWomen = "ST_WOMEN"
+
+	GenderFemale = "FEMALE"
+	GenderMale   = "MALE"
+	GenderOther  = "OTHER"
+
+	CategoryGeneral = "GENERAL"
+	CategoryOBC     = "OBC"
+	CategorySC      = "SC"
+	CategoryST      = "ST"
+)
+
+var reservationLabels = map[string]string{
+	ReservationGeneral: "General",
+	ReservationWomen:   "Women",
+	ReservationSC:      "Scheduled Caste",
+	ReservationSCWomen: "Scheduled Caste (Women)",
+	ReservationST:      "Scheduled Tribe",
+	ReservationSTWomen: "Scheduled Tribe (Women)",
+}
+
+// NormalizeReservation upper-cases a reservation category; empty means GENERAL.
+func NormalizeReservation(r string) (string, error) {
+	r = strings.ToUpper(strings.TrimSpace(strings.ReplaceAll(r, " ", "_")))
+	if r == "" {
+		return ReservationGeneral, nil
+	}
+	if _, ok := reservationLabels[r]; !ok {
+		return "", errors.New("reservation must be GENERAL, WOMEN, SC, SC_WOMEN, ST or ST_WOMEN")
+	}
+	return r, nil
+}
+
+func ReservationLabel(r string) string {
+	if l, ok := reservationLabels[r]; ok {
+		return l
+	}
+	return reservationLabels[ReservationGeneral]
+}
+
+// NormalizeCandidateProfile validates the gender and community category of a candidate.
+func NormalizeCandidateProfile(gender, category string) (string, string, error) {
+	gender = strings.ToUpper(strings.TrimSpace(gender))
+	category = strings.ToUpper(strings.TrimSpace(category))
+
+	switch gender {
+	case "", GenderFemale, GenderMale, GenderOther:
+	default:
+		return "", "", errors.New("gender must be FEMALE, MALE or OTHER")
+	}
+	switch category {
+	case "", CategoryGeneral, CategoryOBC, CategorySC, CategoryST:
+	default:
+		return "", "", errors.New("category must be GENERAL, OBC, SC or ST")
+	}
+	return gender, category, nil
+}
+
+// CheckCandidateReservation rejects a candidate who does not qualify for the
+// seat's reservation: women's seats need a female candidate and SC/ST seats a
+// candidate of that community.
+func CheckCandidateReservation(reservation, gender, category string) error {
+	needWoman := reservation == ReservationWomen || reservation == ReservationSCWomen || reservation == ReservationSTWomen
+	needCategory := ""
+	switch reservation {
+	case ReservationSC, ReservationSCWomen:
+		needCategory = CategorySC
+	case ReservationST, ReservationSTWomen:
+		needCategory = CategoryST
+	}
+
+	if needWoman && gender != GenderFemale {
+		return fmt.Errorf("this seat is reserved for %s: the candidate must be a woman", ReservationLabel(reservation))
+	}
+	if needCategory != "" && category != needCategory {
+		return fmt.Errorf("this seat is reserved for %s: the candidate must belong to the %s category", ReservationLabel(reservation), needCategory)
+	}
+	return nil
+}
+
+// CheckReservationChange makes sure every candidate already standing still
+// qualifies when an election's reservation changes.
+func CheckReservationChange(electionID uint, reservation string) error {
+	var candidates []models.Candidate
+	if err := database.PostgresDB.
+		Where("election_id = ? AND nomination_status NOT IN ?", electionID, []string{NominationRejected, NominationWithdrawn}).
+		Find(&candidates).Error; err != nil {
+		return errors.New("failed to load candidates")
+	}
+	for _, cand := range candidates {
+		if err := CheckCandidateReservation(reservation, cand.Gender, cand.Category); err != nil {
+			return fmt.Errorf("%s does not qualify: %v", cand.FullName, err)
+		}
+	}
+	return nil
+}
+
+// LookupWardReservation finds the rotation entry for an election's ward (or,
+// for an indirect election, the head of the local body) in a delimitation cycle.
+// A missing entry is an error: the seat must not silently fall back to GENERAL.
+func LookupWardReservation(e models.Election) (string, error) {
+	var row models.WardReservation
+	err := database.PostgresDB.
+		Where("cycle = ? AND district = ? AND election_type = ? AND local_body_name = ? AND ward = ?",
+			e.DelimitationCycle, e.District, e.ElectionType, e.LocalBodyName, e.Ward).
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		seat := "the head of " + e.LocalBodyName
+		if e.Ward != "" {
+			seat = fmt.Sprintf("ward %s of %s", e.Ward, e.LocalBodyName)
+		}
+		return "", fmt.Errorf("delimitation cycle %s has no reservation for %s", e.DelimitationCycle, seat)
+	}
+	if err != nil {
+		return "", errors.New("failed to look up the ward reservation")
+	}
+	return row.Reservation, nil
+}
+
+// ImportWardReservations loads a rotation CSV for a delimitation cycle. The
+// header row is skipped; columns are district, election_type, local_body_name,
+// ward, reservation. An empty ward reserves the president or chairperson.
+// Rows already present for the cycle are updated.
+func ImportWardReservations(cycle string, r io.Reader) (int, []string, error) {
+	cycle = strings.TrimSpace(cycle)
+	if cycle == "" {
+		return 0, nil, errors.New("delimitation cycle is required")
+	}
+
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return 0, nil, errors.New("failed to parse CSV")
+	}
+
+	rows, skipped := parseWardReservations(cycle, records)
+	if len(rows) == 0 {
+		return 0, skipped, errors.New("no valid rows in file")
+	}
+
+	err = database.PostgresDB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cycle"}, {Name: "district"}, {Name: "election_type"}, {Name: "local_body_name"}, {Name: "ward"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reservation"}),
+	}).CreateInBatches(&rows, 500).Error
+	if err != nil {
+		return 0, skipped, errors.New("failed to store reservations")
+	}
+	return len(rows), skipped, nil
+}
+
+// parseWardReservations turns the CSV records of a rotation file into rows,
+// listing the lines it had to skip. A ward listed twice takes its last line.
+func parseWardReservations(cycle string, records [][]string) ([]models.WardReservation, []string) {
+	var rows []models.WardReservation
+	var skipped []string
+	type seat struct{ index, line int }
+	seen := make(map[[4]string]seat)
+	for i, rec := range records {
+		if i == 0 {
+			continue
+		}
+		if len(rec) < 5 || strings.TrimSpace(rec[0]) == "" || strings.TrimSpace(rec[1]) == "" || strings.TrimSpace(rec[2]) == "" {
+			skipped = append(skipped, fmt.Sprintf("line %d: missing columns", i+1))
+			continue
+		}
+		res, err := NormalizeReservation(rec[4])
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("line %d: %v", i+1, err))
+			continue
+		}
+		row := models.WardReservation{
+			Cycle:         cycle,
+			District:      strings.TrimSpace(rec[0]),
+			ElectionType:  strings.TrimSpace(rec[1]),
+			LocalBodyName: strings.TrimSpace(rec[2]),
+			Ward:          strings.TrimSpace(rec[3]),
+			Reservation:   res,
+		}
+		key := [4]string{row.District, row.ElectionType, row.LocalBodyName, row.Ward}
+		if prev, ok := seen[key]; ok {
+			skipped = append(skipped, fmt.Sprintf("line %d: superseded by line %d", prev.line, i+1))
+			rows[prev.index] = row
+			seen[key] = seat{prev.index, i + 1}
+			continue
+		}
+		seen[key] = seat{len(rows), i + 1}
+		rows = append(rows, row)
+	}
+	return rows, skipped
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"E-voting/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestParseWardReservations(t *testing.T) {
+	records := [][]string{
+		{"district", "election_type", "local_body_name", "ward", "reservation"},
+		{" Kollam ", "Grama Panchayat", "Chavara", "3", "sc women"},
+		{"Kollam", "Block Panchayat", "Chavara", "", "Women"},
+		{"Kollam", "Grama Panchayat", "Chavara", "4", ""},
+		{"Kollam", "Grama Panchayat", "", "5", "SC"},
+		{"Kollam", "Grama Panchayat", "Chavara"},
+		{"Kollam", "Grama Panchayat", "Chavara", "6", "OBC"},
+		{"Kollam", "Grama Panchayat", "Chavara", "4", "ST"},
+	}
+
+	rows, skipped := parseWardReservations("2025", records)
+
+	want := []models.WardReservation{
+		{Cycle: "2025", District: "Kollam", ElectionType: "Grama Panchayat", LocalBodyName: "Chavara", Ward: "3", Reservation: ReservationSCWomen},
+		{Cycle: "2025", District: "Kollam", ElectionType: "Block Panchayat", LocalBodyName: "Chavara", Ward: "", Reservation: ReservationWomen},
+		{Cycle: "2025", District: "Kollam", ElectionType: "Grama Panchayat", LocalBodyName: "Chavara", Ward: "4", Reservation: ReservationST},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v\nwant %+v", rows, want)
+	}
+	if len(skipped) != 4 || skipped[3] != "line 4: superseded by line 8" {
+		t.Errorf("skipped = %v, want lines 5, 6 and 7, and line 4 superseded by line 8", skipped)
+	}
+}
+
+func TestCheckCandidateReservation(t *testing.T) {
+	tests := []struct {
+		reservation, gender, category string
+		ok                            bool
+	}{
+		{ReservationGeneral, GenderMale, CategoryGeneral, true},
+		{ReservationWomen, GenderFemale, CategoryOBC, true},
+		{ReservationWomen, GenderMale, CategoryGeneral, false},
+		{ReservationSC, GenderMale, CategorySC, true},
+		{ReservationSC, GenderMale, CategoryST, false},
+		{ReservationSCWomen, GenderFemale, CategorySC, true},
+		{ReservationSCWomen, GenderMale, CategorySC, false},
+		{ReservationSTWomen, GenderFemale, CategorySC, false},
+		{ReservationST, GenderOther, CategoryST, true},
+	}
+	for _, tt := range tests {
+		err := CheckCandidateReservation(tt.reservation, tt.gender, tt.category)
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckCandidateReservation(%s, %s, %s) = %v, want ok %v", tt.reservation, tt.gender, tt.category, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TieBreakDrawOfLots  = "DRAW_OF_LOTS"
+	TieBreakRandomDraw  = "RANDOM_DRAW"
+	ResultStatusPending = "TIE_PENDING"
+	ResultStatusDraft   = "PROVISIONAL"
+	ResultStatusFinal   = "DECLARED"
+
+	// DecidedByVotes marks a result settled by the count rather than a tie-break.
+	DecidedByVotes = "VOTES"
+)
+
+type candidateTotal struct {
+	CandidateID   uint
+	CandidateName string
+	PartyName     string
+	Independent   bool
+	Alliance      string
+	SerialNo      int
+	Symbol        string
+	VoteCount     int64
+	WeightedCount int64
+	Headcount     int64
+}
+
+func loadCandidateTotals(electionID uint) ([]candidateTotal, error) {
+	var totals []candidateTotal
+	err := database.PostgresDB.Table("candidates").
+		Select(`
+			candidates.id as candidate_id,
+			candidates.full_name as candidate_name,
+			COALESCE(parties.name, 'Independent') as party_name,
+			candidates.party_id IS NULL as independent,
+			COALESCE(alliances.name, '') as alliance,
+			candidates.serial_no,
+			candidates.symbol,
+			COALESCE(candidate_tallies.vote_count, 0) as vote_count,
+			COALESCE(candidate_tallies.weighted_count, 0) as weighted_count
+		`).
+		Joins("JOIN elections ON elections.id = candidates.election_id").
+		Joins("LEFT JOIN parties ON parties.id = candidates.party_id").
+		Joins(AllianceJoin).
+		Joins("LEFT JOIN candidate_tallies ON candidate_tallies.candidate_id = candidates.id AND candidate_tallies.election_id = candidates.election_id").
+		Where("candidates.election_id = ? AND candidates.nomination_status = ?", electionID, NominationFinal).
+		Scan(&totals).Error
+	return totals, err
+}
+
+// ComputeElectionResult ranks the candidates of an election, marks the elected
+// ones and applies a recorded tie-break when the tie it resolved still stands.
+func ComputeElectionResult(electionID uint) (*models.ElectionResult, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	if election.BallotType == BallotReferendum {
+		return nil, errors.New("referendum results are reported per question")
+	}
+
+	totals, err := loadCandidateTotals(electionID)
+	if err != nil {
+		return nil, errors.New("failed to count votes")
+	}
+	if len(totals) == 0 {
+		return nil, errors.New("election has no candidates")
+	}
+
+	if election.BallotType == BallotRanked {
+		result, err := computeRankedResult(election, totals)
+		if err == nil {
+			summarizeAlliances(result)
+		}
+		return result, err
+	}
+
+	// A weighted election ranks by weight; the headcount is kept alongside.
+	for i := range totals {
+		totals[i].Headcount = totals[i].VoteCount
+		if election.Weighted {
+			totals[i].VoteCount = totals[i].WeightedCount
+		}
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		if totals[i].VoteCount != totals[j].VoteCount {
+			return totals[i].VoteCount > totals[j].VoteCount
+		}
+		return totals[i].CandidateName < totals[j].CandidateName
+	})
+
+	seats := election.Seats
+	if seats < 1 {
+		seats = 1
+	}
+	if seats > len(totals) {
+		seats = len(totals)
+	}
+
+	result := &models.ElectionResult{
+		ElectionID:  electionID,
+		Seats:       seats,
+		Status:      ResultStatusDraft,
+		Weighted:    election.Weighted,
+		Reservation: election.Reservation,
+	}
+
+	// Shares are of ballots cast; on an approval ballot they add up to more than 100%.
+	var tally models.ElectionTally
+	if err := database.PostgresDB.Where("election_id = ?", electionID).First(&tally).Error; err == nil {
+		result.TotalVotes = tally.TotalVotes
+		result.Headcount = tally.TotalVotes
+		if election.Weighted {
+			result.TotalVotes = tally.WeightedTotal
+		}
+	} else {
+		for _, t := range totals {
+			result.TotalVotes += t.VoteCount
+			result.Headcount += t.Headcount
+		}
+	}
+
+	applyNota(result, election)
+
+	rank := 0
+	for i, t := range totals {
+		if i == 0 || t.VoteCount != totals[i-1].VoteCount {
+			rank = i + 1
+		}
+		share := 0.0
+		if result.TotalVotes > 0 {
+			share = math.Round(float64(t.VoteCount)/float64(result.TotalVotes)*10000) / 100
+		}
+		result.Entries = append(result.Entries, models.ElectionResultEntry{
+			CandidateID:   t.CandidateID,
+			CandidateName: t.CandidateName,
+			PartyName:     t.PartyName,
+			Independent:   t.Independent,
+			Alliance:      t.Alliance,
+			SerialNo:      t.SerialNo,
+			Symbol:        t.Symbol,
+			VoteCount:     t.VoteCount,
+			VoteShare:     share,
+			Headcount:     t.Headcount,
+			Rank:          rank,
+		})
+	}
+
+	// Everyone strictly above the cutoff is elected; a tie at the cutoff
+	// leaves the remaining seats to a recorded tie-break.
+	cutoff := totals[seats-1].VoteCount
+	tied, open := tiedAtCutoff(result.Entries, seats, cutoff)
+
+	for i := range result.Entries {
+		if result.Entries[i].VoteCount > cutoff {
+			result.Entries[i].IsElected = true
+		}
+	}
+
+	if result.TotalVotes == 0 {
+		// Nothing has been polled: there is no result yet, not a tie.
+		for i := range result.Entries {
+			result.Entries[i].IsElected = false
+		}
+	} else if len(tied) > open {
+		result.IsTie = true
+		result.Status = ResultStatusPending
+		result.PendingTie = tied
+		result.TieSeats = open
+
+		if tb := matchTieBreak(loadTieBreaks(electionID), tied); tb != nil {
+			winners := splitIDs(tb.WinnerCandidateIDs)
+			for i := range result.Entries {
+				if containsID(winners, result.Entries[i].CandidateID) {
+					result.Entries[i].IsElected = true
+					result.Entries[i].WonByTieBreak = true
+				}
+			}
+			result.TieBreak = tb
+			result.TieBreaks = []models.TieBreak{*tb}
+			result.Status = ResultStatusDraft
+			result.PendingTie = nil
+			result.DecidedBy = tb.Method
+		}
+	} else {
+		for i := range result.Entries {
+			if result.Entries[i].VoteCount == cutoff {
+				result.Entries[i].IsElected = true
+			}
+		}
+		if len(totals) > seats {
+			result.Margin = cutoff - totals[seats].VoteCount
+		} else {
+			result.Margin = cutoff
+		}
+		result.DecidedBy = DecidedByVotes
+	}
+
+	applyMajorityRule(result, election)
+	summarizeAlliances(result)
+	return result, nil
+}
+
+// applyNota reports NOTA next to the candidates. It is never ranked, so it
+// cannot win a seat however many votes it polls.
+func applyNota(result *models.ElectionResult, election models.Election) {
+	var nota models.CandidateTally
+	if err := database.PostgresDB.Where("election_id = ? AND candidate_id = ?", election.ID, NotaCandidateID).First(&nota).Error; err == nil {
+		result.NotaVotes = nota.VoteCount
+		if election.Weighted {
+			result.NotaVotes = nota.WeightedCount
+		}
+	}
+	result.NotaOffered = election.AllowNota || result.NotaVotes > 0
+	if result.TotalVotes > 0 {
+		result.NotaShare = math.Round(float64(result.NotaVotes)/float64(result.TotalVotes)*10000) / 100
+	}
+	describeNota(result)
+}
+
+func describeNota(result *models.ElectionResult) {
+	if result.NotaOffered {
+		result.NotaNote = "NOTA votes are counted and reported but cannot win the seat; seats go to the highest-polling candidates."
+	}
+}
+
+// tiedAtCutoff returns the candidates sharing the cutoff vote count and the
+// number of seats still open to them.
+func tiedAtCutoff(entries []models.ElectionResultEntry, seats int, cutoff int64) ([]uint, int) {
+	var tied []uint
+	above := 0
+	for _, e := range entries {
+		if e.VoteCount > cutoff {
+			above++
+		} else if e.VoteCount == cutoff {
+			tied = append(tied, e.CandidateID)
+		}
+	}
+	sort.Slice(tied, func(i, j int) bool { return tied[i] < tied[j] })
+	return tied, seats - above
+}
+
+func RecordTieBreak(electionID uint, method string, winnerIDs []uint, notes string, actorID uint, actorRole string) (*models.TieBreak, error) {
+	result, err := ComputeElectionResult(electionID)
+	if err != nil {
+		return nil, err
+	}
+	if result.NeedsSecondRound {
+		return nil, errors.New("no candidate has a majority: hold a second round instead")
+	}
+	if len(result.PendingTie) == 0 {
+		return nil, errors.New("election result is not tied")
+	}
+
+	tied, open := result.PendingTie, result.TieSeats
+
+	tb := models.TieBreak{
+		ElectionID:       electionID,
+		Method:           method,
+		TiedCandidateIDs: joinIDs(tied),
+		Notes:            notes,
+		RecordedBy:       actorID,
+	}
+
+	switch method {
+	case TieBreakDrawOfLots:
+		if len(winnerIDs) != open {
+			return nil, fmt.Errorf("exactly %d winner(s) must be drawn", open)
+		}
+		for _, id := range winnerIDs {
+			if !containsID(tied, id) {
+				return nil, fmt.Errorf("candidate %d is not part of the tie", id)
+			}
+		}
+		if len(uniqueIDs(winnerIDs)) != len(winnerIDs) {
+			return nil, errors.New("duplicate winners in draw")
+		}
+		tb.WinnerCandidateIDs = joinIDs(winnerIDs)
+
+	case TieBreakRandomDraw:
+		order, err := shuffleIDs(tied)
+		if err != nil {
+			return nil, errors.New("random draw failed")
+		}
+		tb.DrawOrder = joinIDs(order)
+		tb.WinnerCandidateIDs = joinIDs(order[:open])
+
+	default:
+		return nil, errors.New("unknown tie-break method")
+	}
+
+	if err := database.PostgresDB.Create(&tb).Error; err != nil {
+		return nil, errors.New("failed to record tie-break: it may already have been recorded")
+	}
+
+	LogAdminAction(actorID, actorRole, "RECORD_TIE_BREAK", electionID, map[string]interface{}{
+		"method":     tb.Method,
+		"tied":       tb.TiedCandidateIDs,
+		"winners":    tb.WinnerCandidateIDs,
+		"draw_order": tb.DrawOrder,
+	})
+
+	return &tb, nil
+}
+
+// DeclareElectionResult freezes the computed outcome so results pages read a stored verdict.
+func DeclareElectionResult(electionID uint, actorID uint, actorRole string) (*models.ElectionResult, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	if election.IsActive && time.Now().Before(election.EndDate) {
+		return nil, errors.New("cannot declare results while polling is open")
+	}
+
+	result, err := ComputeElectionResult(electionID)
+	if err != nil {
+		return nil, err
+	}
+	if result.TotalVotes == 0 {
+		return nil, errors.New("no votes have been cast in this election")
+	}
+	if result.Status == ResultStatusPending {
+		return nil, errors.New("result is tied: record a tie-break before declaring")
+	}
+	if result.NeedsSecondRound {
+		return nil, errors.New("no candidate has a majority: hold a second round before declaring")
+	}
+
+	now := time.Now()
+	result.Status = ResultStatusFinal
+	result.DeclaredBy = actorID
+	result.DeclaredAt = &now
+
+	err = database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		var existing models.ElectionResult
+		if err := tx.Where("election_id = ?", electionID).First(&existing).Error; err == nil {
+			if err := tx.Where("result_id = ?", existing.ID).Delete(&models.ElectionResultEntry{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&existing).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(result).Error
+	})
+	if err != nil {
+		return nil, errors.New("failed to store result")
+	}
+	InvalidateResultsCache()
+
+	var elected []uint
+	for _, e := range result.Entries {
+		if e.IsElected {
+			elected = append(elected, e.CandidateID)
+		}
+	}
+	LogAdminAction(actorID, actorRole, "DECLARE_RESULT", electionID, map[string]interface{}{
+		"total_votes": result.TotalVotes,
+		"margin":      result.Margin,
+		"decided_by":  result.DecidedBy,
+		"elected":     joinIDs(elected),
+		"tie_break":   result.TieBreak != nil,
+	})
+
+	return result, nil
+}
+
+func GetDeclaredResult(electionID uint) (*models.ElectionResult, error) {
+	var result models.ElectionResult
+	if err := database.PostgresDB.
+		Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("rank asc, id asc") }).
+		Where("election_id = ?", electionID).
+		First(&result).Error; err != nil {
+		return nil, err
+	}
+
+	if tbs := loadTieBreaks(electionID); len(tbs) > 0 {
+		result.TieBreaks = tbs
+		result.TieBreak = &tbs[len(tbs)-1]
+	}
+	var election models.Election
+	if err := database.PostgresDB.Select("reservation").First(&election, electionID).Error; err == nil {
+		result.Reservation = election.Reservation
+	}
+	describeNota(&result)
+	summarizeAlliances(&result)
+	return &result, nil
+}
+
+// --- Helpers ---
+
+// loadTieBreaks returns every tie-break recorded for an election, oldest first.
+func loadTieBreaks(electionID uint) []models.TieBreak {
+	var tbs []models.TieBreak
+	database.PostgresDB.Where("election_id = ?", electionID).Order("id asc").Find(&tbs)
+	return tbs
+}
+
+// matchTieBreak finds the tie-break recorded for exactly this tied set.
+func matchTieBreak(tbs []models.TieBreak, tied []uint) *models.TieBreak {
+	key := joinIDs(tied)
+	for i := range tbs {
+		if tbs[i].TiedCandidateIDs == key {
+			return &tbs[i]
+		}
+	}
+	return nil
+}
+
+func shuffleIDs(ids []uint) ([]uint, error) {
+	out := append([]uint(nil), ids...)
+	for i := len(out) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+		k := int(j.Int64())
+		out[i], out[k] = out[k], out[i]
+	}
+	return out, nil
+}
+
+func joinIDs(ids []uint) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitIDs(s string) []uint {
+	var ids []uint
+	for _, p := range strings.Split(s, ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(p), 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool)
+	var out []uint
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/repository"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RollSummary struct {
+	ElectionID   uint          `json:"election_id"`
+	Voters       int64         `json:"voters"`
+	TotalWeight  int64         `json:"total_weight"`
+	Capped       int64         `json:"capped"`
+	CappedVoters []CappedVoter `json:"capped_voters"`
+	MaxWeight    int64         `json:"max_weight"`
+	FinalizedAt  time.Time     `json:"finalized_at"`
+}
+
+// CappedVoter is a roll entry whose shares exceeded max_vote_weight.
+type CappedVoter struct {
+	VoterID uint  `json:"voter_id"`
+	Shares  int64 `json:"shares"`
+	Weight  int64 `json:"weight"`
+}
+
+// ValidateWeighted limits weighted counting to ballots tallied per mark.
+func ValidateWeighted(ballotType string, weighted bool) error {
+	if weighted && ballotType != BallotSingle && ballotType != BallotApproval {
+		return errors.New("weighted voting is only available on SINGLE and APPROVAL ballots")
+	}
+	return nil
+}
+
+func maxVoteWeight() int64 {
+	if v, err := strconv.ParseInt(repository.GetSettingValue("max_vote_weight"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return 100
+}
+
+// FinalizeWeightedRoll freezes the weights of a weighted election: every
+// verified, unblocked voter of its electorate holding shares gets a roll entry
+// weighted by those shares, capped at max_vote_weight. Voters without shares
+// are not on the roll.
+func FinalizeWeightedRoll(electionID uint) (*RollSummary, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+	if !election.Weighted {
+		return nil, errors.New("election does not use weighted voting")
+	}
+	if election.RollFinalizedAt != nil {
+		return nil, errors.New("roll has already been finalised")
+	}
+
+	var votes int64
+	database.PostgresDB.Model(&models.Vote{}).Where("election_id = ?", electionID).Count(&votes)
+	if votes > 0 {
+		return nil, errors.New("cannot finalise the roll after voting has started")
+	}
+
+	var voters []models.Voter
+	if err := EligibleVoters(database.PostgresDB.Model(&models.Voter{}), election).
+		Where("voters.is_verified = ? AND voters.is_blocked = ? AND voters.shares > 0", true, false).
+		Find(&voters).Error; err != nil {
+		return nil, errors.New("failed to load the roll")
+	}
+	if len(voters) == 0 {
+		return nil, errors.New("no eligible voter holds shares")
+	}
+
+	now := time.Now()
+	entries, summary := weighRoll(electionID, voters, maxVoteWeight())
+	summary.FinalizedAt = now
+
+	err := database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("election_id = ?", electionID).Delete(&models.RollEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(&entries, 500).Error; err != nil {
+			return err
+		}
+		return tx.Model(&election).Update("roll_finalized_at", now).Error
+	})
+	if err != nil {
+		return nil, errors.New("failed to finalise the roll")
+	}
+	return summary, nil
+}
+
+// weighRoll turns the voters' shares into roll entries, capping each weight
+// at max. Every capped voter is listed in the summary so the admin can see
+// whose weight was reduced.
+func weighRoll(electionID uint, voters []models.Voter, max int64) ([]models.RollEntry, *RollSummary) {
+	summary := &RollSummary{ElectionID: electionID, MaxWeight: max, CappedVoters: []CappedVoter{}}
+	entries := make([]models.RollEntry, 0, len(voters))
+	for _, v := range voters {
+		weight := v.Shares
+		if weight > max {
+			weight = max
+			summary.CappedVoters = append(summary.CappedVoters, CappedVoter{VoterID: v.ID, Shares: v.Shares, Weight: weight})
+		}
+		entries = append(entries, models.RollEntry{ElectionID: electionID, VoterID: v.ID, Shares: v.Shares, Weight: weight})
+		summary.TotalWeight += weight
+	}
+	summary.Voters = int64(len(entries))
+	summary.Capped = int64(len(summary.CappedVoters))
+	return entries, summary
+}
+
+// VoteWeight is the frozen weight of a voter in an election, 1 when the
+// election is not weighted.
+func VoteWeight(election models.Election, voterID uint) (int64, error) {
+	if !election.Weighted {
+		return 1, nil
+	}
+	if election.RollFinalizedAt == nil {
+		return 0, errors.New("the roll for this election has not been finalised yet")
+	}
+
+	var entry models.RollEntry
+	if err := database.PostgresDB.Where("election_id = ? AND voter_id = ?", election.ID, voterID).First(&entry).Error; err != nil {
+		return 0, errors.New("you are not on the electoral roll for this election")
+	}
+	return entry.Weight, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"E-voting/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestWeighRoll(t *testing.T) {
+	voter := func(id uint, shares int64) models.Voter {
+		v := models.Voter{Shares: shares}
+		v.ID = id
+		return v
+	}
+
+	tests := []struct {
+		name    string
+		voters  []models.Voter
+		max     int64
+		weights []int64
+		total   int64
+		capped  []CappedVoter
+	}{
+		{
+			name:    "all under the cap",
+			voters:  []models.Voter{voter(1, 3), voter(2, 7)},
+			max:     10,
+			weights: []int64{3, 7},
+			total:   10,
+			capped:  []CappedVoter{},
+		},
+		{
+			name:    "shares equal to the cap are not capped",
+			voters:  []models.Voter{voter(1, 10)},
+			max:     10,
+			weights: []int64{10},
+			total:   10,
+			capped:  []CappedVoter{},
+		},
+		{
+			name:    "capped voters are listed",
+			voters:  []models.Voter{voter(1, 250), voter(2, 4), voter(3, 101)},
+			max:     100,
+			weights: []int64{100, 4, 100},
+			total:   204,
+			capped: []CappedVoter{
+				{VoterID: 1, Shares: 250, Weight: 100},
+				{VoterID: 3, Shares: 101, Weight: 100},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, summary := weighRoll(9, tt.voters, tt.max)
+			var weights []int64
+			for _, e := range entries {
+				if e.ElectionID != 9 {
+					t.Errorf("entry election = %d, want 9", e.ElectionID)
+				}
+				weights = append(weights, e.Weight)
+			}
+			if !reflect.DeepEqual(weights, tt.weights) {
+				t.Errorf("weights = %v, want %v", weights, tt.weights)
+			}
+			if summary.TotalWeight != tt.total {
+				t.Errorf("total weight = %d, want %d", summary.TotalWeight, tt.total)
+			}
+			if summary.Voters != int64(len(tt.voters)) {
+				t.Errorf("voters = %d, want %d", summary.Voters, len(tt.voters))
+			}
+			if summary.Capped != int64(len(tt.capped)) || !reflect.DeepEqual(summary.CappedVoters, tt.capped) {
+				t.Errorf("capped = %d %+v, want %+v", summary.Capped, summary.CappedVoters, tt.capped)
+			}
+		})
+	}
+}
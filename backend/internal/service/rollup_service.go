@@ -0,0 +1,398 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"math"
+	"sort"
+)
+
+const (
+	RollupLocalBody = "local-bodies"
+	RollupBlock     = "blocks"
+	RollupDistrict  = "districts"
+
+	ControlMajority = "MAJORITY"
+	ControlHung     = "HUNG"
+	ControlPending  = "PENDING"
+)
+
+type RollupFilter struct {
+	ElectionType       string
+	District           string
+	Block              string
+	LocalBodyName      string
+	Year               int
+	IncludeUnpublished bool
+}
+
+type PartyRollup struct {
+	Party            string  `json:"party"`
+	SeatsWon         int     `json:"seats_won"`
+	Votes            int64   `json:"votes"`
+	VoteShare        float64 `json:"vote_share"`
+	BodiesControlled int     `json:"bodies_controlled,omitempty"`
+}
+
+// AllianceRollup totals the parties of a front, using the membership in force
+// when each election started.
+type AllianceRollup struct {
+	Alliance         string  `json:"alliance"`
+	SeatsWon         int     `json:"seats_won"`
+	Votes            int64   `json:"votes"`
+	VoteShare        float64 `json:"vote_share"`
+	BodiesControlled int     `json:"bodies_controlled,omitempty"`
+}
+
+// IndependentRollup totals the independents of an area. They are reported
+// apart from the parties and never counted towards a party's control.
+type IndependentRollup struct {
+	SeatsWon  int     `json:"seats_won"`
+	Votes     int64   `json:"votes"`
+	VoteShare float64 `json:"vote_share"`
+}
+
+type RollupSummary struct {
+	Level            string            `json:"level"`
+	District         string            `json:"district"`
+	Block            string            `json:"block,omitempty"`
+	LocalBodyName    string            `json:"local_body_name,omitempty"`
+	ElectionType     string            `json:"election_type,omitempty"`
+	TotalSeats       int               `json:"total_seats"`
+	DeclaredSeats    int               `json:"declared_seats"`
+	TotalVotes       int64             `json:"total_votes"`
+	MajorityMark     int               `json:"majority_mark,omitempty"`
+	Control          string            `json:"control,omitempty"`
+	ControllingParty string            `json:"controlling_party,omitempty"`
+	LocalBodies      int               `json:"local_bodies,omitempty"`
+	HungBodies       int               `json:"hung_bodies,omitempty"`
+	Parties          []PartyRollup     `json:"parties"`
+	Alliances        []AllianceRollup  `json:"alliances"`
+	Independents     IndependentRollup `json:"independents"`
+
+	// ControllingAlliance is the front holding the majority mark, if any.
+	ControllingAlliance string `json:"controlling_alliance,omitempty"`
+
+	partyIndex    map[string]*PartyRollup
+	allianceIndex map[string]*AllianceRollup
+}
+
+func (s *RollupSummary) party(name string) *PartyRollup {
+	if s.partyIndex == nil {
+		s.partyIndex = make(map[string]*PartyRollup)
+	}
+	p, ok := s.partyIndex[name]
+	if !ok {
+		p = &PartyRollup{Party: name}
+		s.partyIndex[name] = p
+	}
+	return p
+}
+
+func (s *RollupSummary) alliance(name string) *AllianceRollup {
+	if s.allianceIndex == nil {
+		s.allianceIndex = make(map[string]*AllianceRollup)
+	}
+	a, ok := s.allianceIndex[name]
+	if !ok {
+		a = &AllianceRollup{Alliance: name}
+		s.allianceIndex[name] = a
+	}
+	return a
+}
+
+func (s *RollupSummary) finish() {
+	if s.TotalVotes > 0 {
+		s.Independents.VoteShare = math.Round(float64(s.Independents.Votes)/float64(s.TotalVotes)*10000) / 100
+	}
+	s.Parties = make([]PartyRollup, 0, len(s.partyIndex))
+	for _, p := range s.partyIndex {
+		if s.TotalVotes > 0 {
+			p.VoteShare = math.Round(float64(p.Votes)/float64(s.TotalVotes)*10000) / 100
+		}
+		s.Parties = append(s.Parties, *p)
+	}
+	sort.Slice(s.Parties, func(i, j int) bool {
+		if s.Parties[i].SeatsWon != s.Parties[j].SeatsWon {
+			return s.Parties[i].SeatsWon > s.Parties[j].SeatsWon
+		}
+		if s.Parties[i].Votes != s.Parties[j].Votes {
+			return s.Parties[i].Votes > s.Parties[j].Votes
+		}
+		return s.Parties[i].Party < s.Parties[j].Party
+	})
+
+	s.Alliances = make([]AllianceRollup, 0, len(s.allianceIndex))
+	for _, a := range s.allianceIndex {
+		if s.TotalVotes > 0 {
+			a.VoteShare = math.Round(float64(a.Votes)/float64(s.TotalVotes)*10000) / 100
+		}
+		s.Alliances = append(s.Alliances, *a)
+	}
+	sort.Slice(s.Alliances, func(i, j int) bool {
+		if s.Alliances[i].SeatsWon != s.Alliances[j].SeatsWon {
+			return s.Alliances[i].SeatsWon > s.Alliances[j].SeatsWon
+		}
+		if s.Alliances[i].Votes != s.Alliances[j].Votes {
+			return s.Alliances[i].Votes > s.Alliances[j].Votes
+		}
+		return s.Alliances[i].Alliance < s.Alliances[j].Alliance
+	})
+}
+
+// LocalBodyName returns the name of the body a ward election belongs to. District
+// and Block Panchayat divisions usually carry no local body name of their own.
+func LocalBodyName(e models.Election) string {
+	if e.LocalBodyName != "" {
+		return e.LocalBodyName
+	}
+	switch e.ElectionType {
+	case "District Panchayat":
+		return e.District + " District Panchayat"
+	case "Block Panchayat":
+		return e.Block + " Block Panchayat"
+	}
+	return ""
+}
+
+// BuildRollups aggregates ward results up to local body, block or district level.
+// Seats only count once a result has been declared; vote shares use live counts.
+func BuildRollups(level string, f RollupFilter) ([]RollupSummary, error) {
+	if level != RollupLocalBody && level != RollupBlock && level != RollupDistrict {
+		return nil, errors.New("unknown roll-up level")
+	}
+
+	query := database.PostgresDB.Model(&models.Election{}).Where("ward <> ''")
+	if f.ElectionType != "" {
+		query = query.Where("election_type = ?", f.ElectionType)
+	}
+	if f.District != "" {
+		query = query.Where("district = ?", f.District)
+	}
+	if f.Block != "" {
+		query = query.Where("block = ?", f.Block)
+	}
+	if f.Year > 0 {
+		query = query.Where("EXTRACT(YEAR FROM start_date) = ?", f.Year)
+	}
+	if !f.IncludeUnpublished {
+		query = query.Where("is_published = ?", true)
+	}
+
+	var all []models.Election
+	if err := query.Find(&all).Error; err != nil {
+		return nil, errors.New("failed to load elections")
+	}
+	// District and Block Panchayat divisions have no local_body_name of
+	// their own, so the name is matched as LocalBodyName reports it.
+	elections := all[:0]
+	for _, e := range all {
+		if f.LocalBodyName == "" || LocalBodyName(e) == f.LocalBodyName {
+			elections = append(elections, e)
+		}
+	}
+	if len(elections) == 0 {
+		return []RollupSummary{}, nil
+	}
+
+	ids := make([]uint, len(elections))
+	for i, e := range elections {
+		ids[i] = e.ID
+	}
+
+	// Independents come back with an empty party name, parties outside
+	// every front with an empty alliance. Weighted elections count weight.
+	var partyVotes []struct {
+		ElectionID uint
+		PartyName  string
+		Alliance   string
+		Votes      int64
+	}
+	if err := database.PostgresDB.Table("candidate_tallies").
+		Select("candidate_tallies.election_id, COALESCE(parties.name, '') as party_name, COALESCE(alliances.name, '') as alliance, SUM(CASE WHEN elections.weighted THEN candidate_tallies.weighted_count ELSE candidate_tallies.vote_count END) as votes").
+		Joins("JOIN candidates ON candidates.id = candidate_tallies.candidate_id").
+		Joins("JOIN elections ON elections.id = candidate_tallies.election_id").
+		Joins("LEFT JOIN parties ON parties.id = candidates.party_id").
+		Joins(AllianceJoin).
+		Where("candidate_tallies.election_id IN ?", ids).
+		Group("candidate_tallies.election_id, parties.name, alliances.name").
+		Scan(&partyVotes).Error; err != nil {
+		return nil, errors.New("failed to count votes")
+	}
+
+	var winners []struct {
+		ElectionID  uint
+		PartyName   string
+		Independent bool
+		Alliance    string
+	}
+	if err := database.PostgresDB.Table("election_result_entries").
+		Select("election_results.election_id, election_result_entries.party_name, election_result_entries.independent, election_result_entries.alliance").
+		Joins("JOIN election_results ON election_results.id = election_result_entries.result_id").
+		Where("election_results.election_id IN ? AND election_results.status = ? AND election_result_entries.is_elected = ?", ids, ResultStatusFinal, true).
+		Scan(&winners).Error; err != nil {
+		return nil, errors.New("failed to load declared results")
+	}
+
+	// 1. Ward -> Local Body
+	bodies := make(map[string]*RollupSummary)
+	var bodyOrder []string
+	bodyOf := make(map[uint]*RollupSummary)
+
+	for _, e := range elections {
+		name := LocalBodyName(e)
+		key := e.District + "|" + e.ElectionType + "|" + name
+		body, ok := bodies[key]
+		if !ok {
+			body = &RollupSummary{
+				Level:         RollupLocalBody,
+				District:      e.District,
+				Block:         e.Block,
+				LocalBodyName: name,
+				ElectionType:  e.ElectionType,
+			}
+			bodies[key] = body
+			bodyOrder = append(bodyOrder, key)
+		}
+		if e.Seats > 1 {
+			body.TotalSeats += e.Seats
+		} else {
+			body.TotalSeats++
+		}
+		bodyOf[e.ID] = body
+	}
+
+	for _, pv := range partyVotes {
+		body := bodyOf[pv.ElectionID]
+		body.TotalVotes += pv.Votes
+		if pv.Alliance != "" {
+			body.alliance(pv.Alliance).Votes += pv.Votes
+		}
+		if pv.PartyName == "" {
+			body.Independents.Votes += pv.Votes
+			continue
+		}
+		body.party(pv.PartyName).Votes += pv.Votes
+	}
+
+	for _, w := range winners {
+		body := bodyOf[w.ElectionID]
+		body.DeclaredSeats++
+		if w.Alliance != "" {
+			body.alliance(w.Alliance).SeatsWon++
+		}
+		if w.Independent {
+			body.Independents.SeatsWon++
+			continue
+		}
+		body.party(w.PartyName).SeatsWon++
+	}
+
+	localBodies := make([]RollupSummary, 0, len(bodyOrder))
+	for _, key := range bodyOrder {
+		body := bodies[key]
+		body.MajorityMark = body.TotalSeats/2 + 1
+		body.Control, body.ControllingParty = controlStatus(body)
+		body.ControllingAlliance = allianceControl(body)
+		body.finish()
+		localBodies = append(localBodies, *body)
+	}
+
+	sort.Slice(localBodies, func(i, j int) bool {
+		if localBodies[i].District != localBodies[j].District {
+			return localBodies[i].District < localBodies[j].District
+		}
+		return localBodies[i].LocalBodyName < localBodies[j].LocalBodyName
+	})
+
+	if level == RollupLocalBody {
+		return localBodies, nil
+	}
+
+	// 2. Local Body -> Block / District
+	groups := make(map[string]*RollupSummary)
+	var groupOrder []string
+
+	for _, body := range localBodies {
+		key := body.District
+		if level == RollupBlock {
+			if body.Block == "" {
+				continue
+			}
+			key = body.District + "|" + body.Block
+		}
+
+		group, ok := groups[key]
+		if !ok {
+			group = &RollupSummary{Level: level, District: body.District}
+			if level == RollupBlock {
+				group.Block = body.Block
+			}
+			groups[key] = group
+			groupOrder = append(groupOrder, key)
+		}
+
+		group.LocalBodies++
+		group.TotalSeats += body.TotalSeats
+		group.DeclaredSeats += body.DeclaredSeats
+		group.TotalVotes += body.TotalVotes
+		for _, p := range body.Parties {
+			gp := group.party(p.Party)
+			gp.SeatsWon += p.SeatsWon
+			gp.Votes += p.Votes
+		}
+		for _, a := range body.Alliances {
+			ga := group.alliance(a.Alliance)
+			ga.SeatsWon += a.SeatsWon
+			ga.Votes += a.Votes
+		}
+		if body.ControllingAlliance != "" {
+			group.alliance(body.ControllingAlliance).BodiesControlled++
+		}
+		group.Independents.SeatsWon += body.Independents.SeatsWon
+		group.Independents.Votes += body.Independents.Votes
+		switch body.Control {
+		case ControlMajority:
+			group.party(body.ControllingParty).BodiesControlled++
+		case ControlHung:
+			group.HungBodies++
+		}
+	}
+
+	out := make([]RollupSummary, 0, len(groupOrder))
+	for _, key := range groupOrder {
+		groups[key].finish()
+		out = append(out, *groups[key])
+	}
+	return out, nil
+}
+
+// controlStatus reports MAJORITY once a party holds the majority mark, HUNG once
+// no party can still reach it, and PENDING otherwise.
+func controlStatus(body *RollupSummary) (string, string) {
+	leader, best := "", 0
+	for name, p := range body.partyIndex {
+		if p.SeatsWon > best || (p.SeatsWon == best && name < leader) {
+			leader, best = name, p.SeatsWon
+		}
+	}
+
+	if best >= body.MajorityMark {
+		return ControlMajority, leader
+	}
+	if best+(body.TotalSeats-body.DeclaredSeats) < body.MajorityMark {
+		return ControlHung, ""
+	}
+	return ControlPending, ""
+}
+
+// allianceControl names the front holding the majority mark of a body.
+func allianceControl(body *RollupSummary) string {
+	for name, a := range body.allianceIndex {
+		if a.SeatsWon >= body.MajorityMark {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"math"
+	"sort"
+)
+
+// loadRankedBallots returns every ballot of an election as its ordered list of
+// preferences. Votes stored without selections count as a single preference.
+func loadRankedBallots(electionID uint) ([][]uint, error) {
+	var rows []struct {
+		VoteID      uint
+		CandidateID uint
+	}
+	if err := database.PostgresDB.Model(&models.VoteSelection{}).
+		Select("vote_id, candidate_id").
+		Where("election_id = ?", electionID).
+		Order("vote_id asc, rank asc").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var ballots [][]uint
+	var current uint
+	for _, r := range rows {
+		if len(ballots) == 0 || r.VoteID != current {
+			ballots = append(ballots, nil)
+			current = r.VoteID
+		}
+		ballots[len(ballots)-1] = append(ballots[len(ballots)-1], r.CandidateID)
+	}
+
+	var singles []uint
+	if err := database.PostgresDB.Model(&models.Vote{}).
+		Where("election_id = ? AND NOT EXISTS (SELECT 1 FROM vote_selections WHERE vote_selections.vote_id = votes.id)", electionID).
+		Pluck("candidate_id", &singles).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range singles {
+		ballots = append(ballots, []uint{id})
+	}
+	return ballots, nil
+}
+
+type runoffOutcome struct {
+	Rounds        []models.RunoffRound
+	Winner        uint
+	WonByTieBreak bool
+	PendingTie    []uint
+	Applied       []models.TieBreak // tie-breaks used, round by round
+	LastRound     map[uint]int      // last round each candidate was counted in
+	LastCount     map[uint]int64    // votes held in that round
+	history       []map[uint]int64  // counts per round, for backward tie-breaks
+}
+
+// runInstantRunoff counts ballots round by round, eliminating the weakest
+// candidate until one holds a majority of the continuing ballots.
+//
+// A tie for last place is settled by the most recent earlier round in which the
+// tied candidates differ. If that fails, the tied candidates are eliminated
+// together when their combined votes cannot overtake anyone else; otherwise a
+// recorded tie-break (draw of lots) for that exact tied set decides who stays.
+// Without ballots there is no result: no winner and no tie.
+func runInstantRunoff(candidates []uint, ballots [][]uint, tbs []models.TieBreak) runoffOutcome {
+	out := runoffOutcome{
+		LastRound: make(map[uint]int),
+		LastCount: make(map[uint]int64),
+	}
+	if len(ballots) == 0 {
+		return out
+	}
+
+	continuing := make(map[uint]bool, len(candidates))
+	for _, id := range candidates {
+		continuing[id] = true
+	}
+
+	topChoice := func(ballot []uint, skip map[uint]bool) uint {
+		for _, id := range ballot {
+			if continuing[id] && !skip[id] {
+				return id
+			}
+		}
+		return 0
+	}
+
+	for round := 1; len(continuing) > 0; round++ {
+		counts := make(map[uint]int64, len(continuing))
+		for id := range continuing {
+			counts[id] = 0
+		}
+		var exhausted int64
+		for _, b := range ballots {
+			if top := topChoice(b, nil); top != 0 {
+				counts[top]++
+			} else {
+				exhausted++
+			}
+		}
+		out.history = append(out.history, counts)
+
+		r := models.RunoffRound{
+			Round:      round,
+			Continuing: int64(len(ballots)) - exhausted,
+			Exhausted:  exhausted,
+		}
+		for id, v := range counts {
+			r.Counts = append(r.Counts, models.RunoffCount{CandidateID: id, Votes: v})
+			out.LastRound[id] = round
+			out.LastCount[id] = v
+		}
+		sort.Slice(r.Counts, func(i, j int) bool {
+			if r.Counts[i].Votes != r.Counts[j].Votes {
+				return r.Counts[i].Votes > r.Counts[j].Votes
+			}
+			return r.Counts[i].CandidateID < r.Counts[j].CandidateID
+		})
+
+		leader := r.Counts[0]
+		if len(continuing) == 1 || leader.Votes*2 > r.Continuing {
+			out.Winner = leader.CandidateID
+			r.Elected = []uint{leader.CandidateID}
+			out.Rounds = append(out.Rounds, r)
+			return out
+		}
+
+		// 1. Candidates tied for last place, narrowed by earlier rounds
+		low := r.Counts[len(r.Counts)-1].Votes
+		var lowest []uint
+		for _, c := range r.Counts {
+			if c.Votes == low {
+				lowest = append(lowest, c.CandidateID)
+			}
+		}
+		for h := len(out.history) - 2; h >= 0 && len(lowest) > 1; h-- {
+			lowest = fewestIn(out.history[h], lowest)
+		}
+		sort.Slice(lowest, func(i, j int) bool { return lowest[i] < lowest[j] })
+
+		// 2. Decide who goes out this round
+		eliminated := lowest
+		if len(lowest) > 1 {
+			var combined int64
+			for _, id := range lowest {
+				combined += counts[id]
+			}
+			next, others := int64(math.MaxInt64), false
+			for id, v := range counts {
+				if !containsID(lowest, id) {
+					others = true
+					if v < next {
+						next = v
+					}
+				}
+			}
+
+			tb := matchTieBreak(tbs, lowest)
+			switch {
+			case others && combined < next:
+				// Eliminating them together cannot change who survives.
+			case tb != nil:
+				keep := splitIDs(tb.WinnerCandidateIDs)
+				eliminated = nil
+				for _, id := range lowest {
+					if !containsID(keep, id) {
+						eliminated = append(eliminated, id)
+					}
+				}
+				out.Applied = append(out.Applied, *tb)
+				if !others && len(keep) == 1 {
+					out.WonByTieBreak = true
+				}
+			default:
+				out.PendingTie = lowest
+				out.Rounds = append(out.Rounds, r)
+				return out
+			}
+		}
+
+		// 3. Transfer their ballots to the next continuing preference
+		gone := make(map[uint]bool, len(eliminated))
+		for _, id := range eliminated {
+			gone[id] = true
+		}
+		flows := make(map[[2]uint]int64)
+		for _, b := range ballots {
+			if from := topChoice(b, nil); gone[from] {
+				flows[[2]uint{from, topChoice(b, gone)}]++
+			}
+		}
+		for k, v := range flows {
+			r.Transfers = append(r.Transfers, models.RunoffTransfer{From: k[0], To: k[1], Votes: v})
+		}
+		sort.Slice(r.Transfers, func(i, j int) bool {
+			if r.Transfers[i].From != r.Transfers[j].From {
+				return r.Transfers[i].From < r.Transfers[j].From
+			}
+			return r.Transfers[i].To < r.Transfers[j].To
+		})
+
+		r.Eliminated = eliminated
+		out.Rounds = append(out.Rounds, r)
+		for _, id := range eliminated {
+			delete(continuing, id)
+		}
+	}
+	return out
+}
+
+// fewestIn keeps the candidates with the lowest count in an earlier round.
+func fewestIn(counts map[uint]int64, ids []uint) []uint {
+	min := int64(math.MaxInt64)
+	for _, id := range ids {
+		if counts[id] < min {
+			min = counts[id]
+		}
+	}
+	var out []uint
+	for _, id := range ids {
+		if counts[id] == min {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// computeRankedResult builds the result of a RANKED election from an
+// instant-runoff count. VoteCount is what each candidate held in the last
+// round they took part in.
+func computeRankedResult(election models.Election, totals []candidateTotal) (*models.ElectionResult, error) {
+	ballots, err := loadRankedBallots(election.ID)
+	if err != nil {
+		return nil, errors.New("failed to count ballots")
+	}
+
+	ids := make([]uint, len(totals))
+	for i, t := range totals {
+		ids[i] = t.CandidateID
+	}
+
+	run := runInstantRunoff(ids, ballots, loadTieBreaks(election.ID))
+
+	result := &models.ElectionResult{
+		ElectionID:  election.ID,
+		Seats:       1,
+		TotalVotes:  int64(len(ballots)),
+		Headcount:   int64(len(ballots)),
+		Status:      ResultStatusDraft,
+		Reservation: election.Reservation,
+		Rounds:      run.Rounds,
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		a, b := totals[i].CandidateID, totals[j].CandidateID
+		if (a == run.Winner) != (b == run.Winner) {
+			return a == run.Winner
+		}
+		if run.LastRound[a] != run.LastRound[b] {
+			return run.LastRound[a] > run.LastRound[b]
+		}
+		if run.LastCount[a] != run.LastCount[b] {
+			return run.LastCount[a] > run.LastCount[b]
+		}
+		return totals[i].CandidateName < totals[j].CandidateName
+	})
+
+	rank := 0
+	for i, t := range totals {
+		id := t.CandidateID
+		if i == 0 || id == run.Winner || totals[i-1].CandidateID == run.Winner ||
+			run.LastRound[id] != run.LastRound[totals[i-1].CandidateID] ||
+			run.LastCount[id] != run.LastCount[totals[i-1].CandidateID] {
+			rank = i + 1
+		}
+		share := 0.0
+		if result.TotalVotes > 0 {
+			share = math.Round(float64(run.LastCount[id])/float64(result.TotalVotes)*10000) / 100
+		}
+		result.Entries = append(result.Entries, models.ElectionResultEntry{
+			CandidateID:   id,
+			CandidateName: t.CandidateName,
+			PartyName:     t.PartyName,
+			Independent:   t.Independent,
+			Alliance:      t.Alliance,
+			SerialNo:      t.SerialNo,
+			Symbol:        t.Symbol,
+			VoteCount:     run.LastCount[id],
+			VoteShare:     share,
+			Headcount:     run.LastCount[id],
+			Rank:          rank,
+			IsElected:     id == run.Winner,
+			WonByTieBreak: id == run.Winner && run.WonByTieBreak,
+		})
+	}
+
+	if len(run.Applied) > 0 {
+		result.IsTie = true
+		result.TieBreaks = run.Applied
+		result.TieBreak = &run.Applied[len(run.Applied)-1]
+	}
+
+	if len(ballots) == 0 {
+		return result, nil
+	}
+	if run.Winner == 0 {
+		result.IsTie = true
+		result.Status = ResultStatusPending
+		result.PendingTie = run.PendingTie
+		result.TieSeats = len(run.PendingTie) - 1
+		return result, nil
+	}
+
+	if run.WonByTieBreak {
+		result.DecidedBy = result.TieBreak.Method
+		return result, nil
+	}
+	last := run.Rounds[len(run.Rounds)-1]
+	result.Margin = last.Counts[0].Votes
+	if len(last.Counts) > 1 {
+		result.Margin -= last.Counts[1].Votes
+	}
+	result.DecidedBy = DecidedByVotes
+	return result, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"E-voting/internal/models"
+	"reflect"
+	"testing"
+)
+
+func repeatBallot(n int, prefs ...uint) [][]uint {
+	out := make([][]uint, n)
+	for i := range out {
+		out[i] = append([]uint(nil), prefs...)
+	}
+	return out
+}
+
+func ballots(groups ...[][]uint) [][]uint {
+	var out [][]uint
+	for _, g := range groups {
+		out = append(out, g...)
+	}
+	return out
+}
+
+func TestRunInstantRunoff(t *testing.T) {
+	// Round 1: 1 and 2 hold 3 each, 3 and 4 hold 2 each. The tie for last
+	// cannot be settled by combining (4 >= 3), so it needs a draw. Once 4 is
+	// out its ballots go to 3, leaving 1 and 2 tied for last in round 2.
+	twoTies := ballots(
+		repeatBallot(3, 1),
+		repeatBallot(3, 2),
+		repeatBallot(2, 3),
+		repeatBallot(2, 4, 3),
+	)
+	firstDraw := models.TieBreak{TiedCandidateIDs: "3,4", WinnerCandidateIDs: "3"}
+	secondDraw := models.TieBreak{TiedCandidateIDs: "1,2", WinnerCandidateIDs: "1"}
+
+	tests := []struct {
+		name        string
+		candidates  []uint
+		ballots     [][]uint
+		tieBreaks   []models.TieBreak
+		winner      uint
+		pendingTie  []uint
+		applied     int
+		rounds      int
+		byTieBreak  bool
+		eliminated1 []uint
+	}{
+		{
+			name:       "majority in the first round",
+			candidates: []uint{1, 2},
+			ballots:    ballots(repeatBallot(3, 1), repeatBallot(1, 2)),
+			winner:     1,
+			rounds:     1,
+		},
+		{
+			name:        "transfer decides the winner",
+			candidates:  []uint{1, 2, 3},
+			ballots:     ballots(repeatBallot(2, 1), repeatBallot(2, 2), repeatBallot(1, 3, 2)),
+			winner:      2,
+			rounds:      2,
+			eliminated1: []uint{3},
+		},
+		{
+			name:       "no ballots is no result, not a tie",
+			candidates: []uint{1, 2, 3},
+			ballots:    nil,
+			rounds:     0,
+		},
+		{
+			name:        "hopeless candidates go out together",
+			candidates:  []uint{1, 2, 3, 4},
+			ballots:     ballots(repeatBallot(5, 1), repeatBallot(4, 2), repeatBallot(1, 3), repeatBallot(1, 4)),
+			winner:      1,
+			rounds:      2,
+			eliminated1: []uint{3, 4},
+		},
+		{
+			name:       "tie for last waits for a draw",
+			candidates: []uint{1, 2, 3, 4},
+			ballots:    twoTies,
+			pendingTie: []uint{3, 4},
+			rounds:     1,
+		},
+		{
+			name:       "second tie waits for its own draw",
+			candidates: []uint{1, 2, 3, 4},
+			ballots:    twoTies,
+			tieBreaks:  []models.TieBreak{firstDraw},
+			pendingTie: []uint{1, 2},
+			applied:    1,
+			rounds:     2,
+		},
+		{
+			name:        "each tied round uses its recorded draw",
+			candidates:  []uint{1, 2, 3, 4},
+			ballots:     twoTies,
+			tieBreaks:   []models.TieBreak{firstDraw, secondDraw},
+			winner:      3,
+			applied:     2,
+			rounds:      3,
+			eliminated1: []uint{4},
+		},
+		{
+			name:       "draw between the last two decides the winner",
+			candidates: []uint{1, 2},
+			ballots:    ballots(repeatBallot(2, 1), repeatBallot(2, 2)),
+			tieBreaks:  []models.TieBreak{{TiedCandidateIDs: "1,2", WinnerCandidateIDs: "2"}},
+			winner:     2,
+			applied:    1,
+			rounds:     2,
+			byTieBreak: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runInstantRunoff(tt.candidates, tt.ballots, tt.tieBreaks)
+			if out.Winner != tt.winner {
+				t.Errorf("winner = %d, want %d", out.Winner, tt.winner)
+			}
+			if !reflect.DeepEqual(out.PendingTie, tt.pendingTie) {
+				t.Errorf("pending tie = %v, want %v", out.PendingTie, tt.pendingTie)
+			}
+			if len(out.Applied) != tt.applied {
+				t.Errorf("tie-breaks applied = %d, want %d", len(out.Applied), tt.applied)
+			}
+			if len(out.Rounds) != tt.rounds {
+				t.Fatalf("rounds = %d, want %d", len(out.Rounds), tt.rounds)
+			}
+			if out.WonByTieBreak != tt.byTieBreak {
+				t.Errorf("won by tie-break = %v, want %v", out.WonByTieBreak, tt.byTieBreak)
+			}
+			if tt.eliminated1 != nil && !reflect.DeepEqual(out.Rounds[0].Eliminated, tt.eliminated1) {
+				t.Errorf("round 1 eliminated = %v, want %v", out.Rounds[0].Eliminated, tt.eliminated1)
+			}
+		})
+	}
+}
+
+func TestRunInstantRunoffBackwardTieBreak(t *testing.T) {
+	// 5 goes out first and its ballot lifts 4 level with 3 in round 2. 4
+	// held fewer votes in round 1, so 4 goes out without a draw.
+	b := ballots(
+		repeatBallot(6, 1),
+		repeatBallot(5, 2),
+		repeatBallot(3, 3),
+		repeatBallot(2, 4),
+		repeatBallot(1, 5, 4),
+	)
+	out := runInstantRunoff([]uint{1, 2, 3, 4, 5}, b, nil)
+	if len(out.PendingTie) != 0 {
+		t.Fatalf("unexpected pending tie %v", out.PendingTie)
+	}
+	if len(out.Rounds) < 2 || !reflect.DeepEqual(out.Rounds[1].Eliminated, []uint{4}) {
+		t.Fatalf("round 2 should eliminate 4, got %+v", out.Rounds)
+	}
+}
+
+func TestMatchTieBreak(t *testing.T) {
+	tbs := []models.TieBreak{
+		{TiedCandidateIDs: "3,4", WinnerCandidateIDs: "3"},
+		{TiedCandidateIDs: "1,2", WinnerCandidateIDs: "1"},
+	}
+	if tb := matchTieBreak(tbs, []uint{1, 2}); tb == nil || tb.WinnerCandidateIDs != "1" {
+		t.Errorf("matchTieBreak(1,2) = %+v", tb)
+	}
+	if tb := matchTieBreak(tbs, []uint{1, 2, 3}); tb != nil {
+		t.Errorf("matchTieBreak(1,2,3) = %+v, want nil", tb)
+	}
+}
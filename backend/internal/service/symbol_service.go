@@ -0,0 +1,218 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PartyNational   = "NATIONAL"
+	PartyState      = "STATE"
+	PartyRegistered = "REGISTERED"
+
+	IndependentLabel = "Independent"
+)
+
+// inactiveNominations no longer hold a symbol.
+var inactiveNominations = []string{NominationRejected, NominationWithdrawn}
+
+func NormalizeRecognition(recognition string) (string, error) {
+	recognition = strings.ToUpper(strings.TrimSpace(recognition))
+	switch recognition {
+	case "":
+		return PartyRegistered, nil
+	case PartyNational, PartyState, PartyRegistered:
+		return recognition, nil
+	}
+	return "", errors.New("party recognition must be NATIONAL, STATE or REGISTERED")
+}
+
+// IsIndependentLabel catches stand-in parties invented for independents.
+func IsIndependentLabel(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return name == "independent" || name == "independents" || name == "ind"
+}
+
+func isRecognisedParty(p *models.Party) bool {
+	return p != nil && p.ID != 0 && (p.Recognition == PartyNational || p.Recognition == PartyState)
+}
+
+// IsReservedSymbol reports whether a symbol belongs to a recognised party.
+func IsReservedSymbol(symbol string, exceptPartyID uint) bool {
+	var count int64
+	database.PostgresDB.Model(&models.Party{}).
+		Where("LOWER(symbol) = LOWER(?) AND recognition IN ? AND id <> ?", symbol, []string{PartyNational, PartyState}, exceptPartyID).
+		Count(&count)
+	return count > 0
+}
+
+// FreeSymbolEntry is a free symbol and, within one election, the candidate
+// already holding it.
+type FreeSymbolEntry struct {
+	models.FreeSymbol
+	TakenBy     uint   `json:"taken_by,omitempty"`
+	TakenByName string `json:"taken_by_name,omitempty"`
+}
+
+func ListFreeSymbols(electionID uint) ([]FreeSymbolEntry, error) {
+	var symbols []models.FreeSymbol
+	if err := database.PostgresDB.Order("name asc").Find(&symbols).Error; err != nil {
+		return nil, err
+	}
+
+	taken := make(map[string]models.Candidate)
+	if electionID > 0 {
+		var candidates []models.Candidate
+		database.PostgresDB.
+			Where("election_id = ? AND symbol <> '' AND nomination_status NOT IN ?", electionID, inactiveNominations).
+			Find(&candidates)
+		for _, cand := range candidates {
+			taken[strings.ToLower(cand.Symbol)] = cand
+		}
+	}
+
+	out := make([]FreeSymbolEntry, 0, len(symbols))
+	for _, s := range symbols {
+		entry := FreeSymbolEntry{FreeSymbol: s}
+		if cand, ok := taken[strings.ToLower(s.Name)]; ok {
+			entry.TakenBy, entry.TakenByName = cand.ID, cand.FullName
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// CheckSymbolChoice validates a free symbol chosen by a candidate outside the
+// recognised parties and returns its listed spelling. A symbol can only be
+// held by one candidate per election.
+func CheckSymbolChoice(cand models.Candidate, symbol string) (string, error) {
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return "", errors.New("symbol is required")
+	}
+
+	var election models.Election
+	if err := database.PostgresDB.First(&election, cand.ElectionID).Error; err != nil {
+		return "", errors.New("election not found")
+	}
+	if election.BallotFrozenAt != nil {
+		return "", errors.New("the ballot has been frozen; symbols can no longer change")
+	}
+	for _, s := range inactiveNominations {
+		if cand.NominationStatus == s {
+			return "", fmt.Errorf("a %s nomination cannot be allotted a symbol", s)
+		}
+	}
+
+	if cand.PartyID != nil {
+		var party models.Party
+		if err := database.PostgresDB.First(&party, *cand.PartyID).Error; err == nil && isRecognisedParty(&party) {
+			return "", fmt.Errorf("candidates of %s stand on the party symbol", party.Name)
+		}
+	}
+
+	var free models.FreeSymbol
+	if err := database.PostgresDB.Where("LOWER(name) = LOWER(?)", symbol).First(&free).Error; err != nil {
+		return "", fmt.Errorf("%q is not on the free symbol list", symbol)
+	}
+	if IsReservedSymbol(free.Name, 0) {
+		return "", fmt.Errorf("%q is reserved for a recognised party", free.Name)
+	}
+
+	var holder models.Candidate
+	err := database.PostgresDB.
+		Where("election_id = ? AND id <> ? AND LOWER(symbol) = LOWER(?) AND nomination_status NOT IN ?", cand.ElectionID, cand.ID, free.Name, inactiveNominations).
+		First(&holder).Error
+	if err == nil {
+		return "", fmt.Errorf("%q has already been allotted to %s", free.Name, holder.FullName)
+	}
+	return free.Name, nil
+}
+
+// AllotSymbol records a candidate's choice from the free symbol list.
+func AllotSymbol(cand *models.Candidate, symbol string) error {
+	symbol, err := CheckSymbolChoice(*cand, symbol)
+	if err != nil {
+		return err
+	}
+	if err := database.PostgresDB.Model(cand).Update("symbol", symbol).Error; err != nil {
+		return errors.New("failed to allot symbol")
+	}
+	cand.Symbol = symbol
+	return nil
+}
+
+// freezeBallot orders the final list the way the official ballot does:
+// candidates of national and state parties first, then everyone else, each
+// group alphabetically. It numbers them, fixes their symbols and stamps the
+// election so the ballot can no longer change.
+func freezeBallot(tx *gorm.DB, electionID uint, now time.Time) error {
+	var candidates []models.Candidate
+	if err := tx.Preload("Party").
+		Where("election_id = ? AND nomination_status = ?", electionID, NominationFinal).
+		Find(&candidates).Error; err != nil {
+		return errors.New("failed to load the final list")
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ri, rj := isRecognisedParty(candidates[i].Party), isRecognisedParty(candidates[j].Party)
+		if ri != rj {
+			return ri
+		}
+		ni, nj := strings.ToLower(candidates[i].FullName), strings.ToLower(candidates[j].FullName)
+		if ni != nj {
+			return ni < nj
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	// Candidates of the same recognised party may share its symbol.
+	holders := make(map[string]models.Candidate)
+	for i := range candidates {
+		cand := &candidates[i]
+		if isRecognisedParty(cand.Party) {
+			if cand.Party.Symbol == "" {
+				return fmt.Errorf("%s has no reserved symbol", cand.Party.Name)
+			}
+			cand.Symbol = cand.Party.Symbol
+		}
+		if cand.Symbol == "" {
+			return fmt.Errorf("%s has not been allotted a symbol", cand.FullName)
+		}
+
+		key := strings.ToLower(cand.Symbol)
+		if other, ok := holders[key]; ok && (!isRecognisedParty(cand.Party) || !isRecognisedParty(other.Party) || other.Party.ID != cand.Party.ID) {
+			return fmt.Errorf("%s and %s hold the same symbol %q", other.FullName, cand.FullName, cand.Symbol)
+		}
+		holders[key] = *cand
+
+		cand.SerialNo = i + 1
+		if err := tx.Model(cand).Updates(map[string]interface{}{
+			"serial_no": cand.SerialNo,
+			"symbol":    cand.Symbol,
+		}).Error; err != nil {
+			return errors.New("failed to number the ballot")
+		}
+	}
+
+	return tx.Model(&models.Election{}).Where("id = ?", electionID).Update("ballot_frozen_at", now).Error
+}
+
+// CheckBallotEditable refuses candidate changes once an election's ballot is frozen.
+func CheckBallotEditable(electionID uint) error {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return errors.New("election not found")
+	}
+	if election.BallotFrozenAt != nil {
+		return errors.New("the ballot has been frozen")
+	}
+	return nil
+}
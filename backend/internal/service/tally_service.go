@@ -0,0 +1,314 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/repository"
+	"E-voting/internal/utils"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordVoteTally bumps the running tallies inside the caller's vote transaction.
+// An approval ballot passes every selected candidate but counts as one ballot.
+// Weight is the ballot's vote weight, 1 outside weighted elections.
+func RecordVoteTally(tx *gorm.DB, electionID uint, weight int64, candidateIDs ...uint) error {
+	now := time.Now()
+
+	for _, candidateID := range candidateIDs {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "election_id"}, {Name: "candidate_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"vote_count":     gorm.Expr("candidate_tallies.vote_count + 1"),
+				"weighted_count": gorm.Expr("candidate_tallies.weighted_count + ?", weight),
+				"updated_at":     now,
+			}),
+		}).Create(&models.CandidateTally{
+			ElectionID:    electionID,
+			CandidateID:   candidateID,
+			VoteCount:     1,
+			WeightedCount: weight,
+			UpdatedAt:     now,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "election_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"total_votes":    gorm.Expr("election_tallies.total_votes + 1"),
+			"weighted_total": gorm.Expr("election_tallies.weighted_total + ?", weight),
+			"version":        gorm.Expr("election_tallies.version + 1"),
+			"updated_at":     now,
+		}),
+	}).Create(&models.ElectionTally{
+		ElectionID:    electionID,
+		TotalVotes:    1,
+		WeightedTotal: weight,
+		Version:       1,
+		UpdatedAt:     now,
+	}).Error
+}
+
+// RebuildTallies recomputes every tally from the votes table, counting each
+// selection of an approval ballot and each answer of a referendum ballot. A
+// referendum vote row carries candidate_id 0 like a NOTA vote, so referendums
+// get no candidate tallies.
+func RebuildTallies() (int64, error) {
+	var elections int64
+
+	err := database.PostgresDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM candidate_tallies").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			INSERT INTO candidate_tallies (election_id, candidate_id, vote_count, weighted_count, updated_at)
+			SELECT election_id, candidate_id, COUNT(*), SUM(weight), NOW()
+			FROM (
+				SELECT votes.election_id, votes.candidate_id, votes.weight
+				FROM votes JOIN elections ON elections.id = votes.election_id
+				WHERE elections.ballot_type IS DISTINCT FROM 'APPROVAL'
+					AND elections.ballot_type IS DISTINCT FROM 'REFERENDUM'
+				UNION ALL
+				SELECT vote_selections.election_id, vote_selections.candidate_id, votes.weight
+				FROM vote_selections
+				JOIN votes ON votes.id = vote_selections.vote_id
+				JOIN elections ON elections.id = vote_selections.election_id
+				WHERE elections.ballot_type = 'APPROVAL'
+			) AS marks
+			GROUP BY election_id, candidate_id
+		`).Error; err != nil {
+			return err
+		}
+
+		res := tx.Exec(`
+			INSERT INTO election_tallies (election_id, total_votes, weighted_total, version, updated_at)
+			SELECT election_id, COUNT(*), SUM(weight), 1, NOW()
+			FROM votes
+			GROUP BY election_id
+			ON CONFLICT (election_id) DO UPDATE SET
+				total_votes = EXCLUDED.total_votes,
+				weighted_total = EXCLUDED.weighted_total,
+				version = election_tallies.version + 1,
+				updated_at = NOW()
+		`)
+		if res.Error != nil {
+			return res.Error
+		}
+		elections = res.RowsAffected
+
+		if err := tx.Exec(`
+			UPDATE election_tallies
+			SET total_votes = 0, weighted_total = 0, version = version + 1, updated_at = NOW()
+			WHERE total_votes <> 0 AND election_id NOT IN (SELECT DISTINCT election_id FROM votes)
+		`).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM option_tallies").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			INSERT INTO option_tallies (question_id, option_id, vote_count)
+			SELECT question_id, option_id, COUNT(*)
+			FROM ballot_answers
+			GROUP BY question_id, option_id
+		`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM question_tallies").Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO question_tallies (question_id, election_id, answered)
+			SELECT question_id, election_id, COUNT(DISTINCT vote_id)
+			FROM ballot_answers
+			GROUP BY question_id, election_id
+		`).Error
+	})
+
+	if err == nil {
+		InvalidateResultsCache()
+	}
+	return elections, err
+}
+
+// EnsureTallies backfills the tally tables on first start against an existing votes table.
+func EnsureTallies() {
+	var tallies, votes int64
+	database.PostgresDB.Model(&models.ElectionTally{}).Count(&tallies)
+	if tallies > 0 {
+		return
+	}
+	database.PostgresDB.Model(&models.Vote{}).Count(&votes)
+	if votes == 0 {
+		return
+	}
+
+	n, err := RebuildTallies()
+	if err != nil {
+		log.Printf("Failed to backfill vote tallies: %v", err)
+		return
+	}
+	log.Printf(" Vote tallies backfilled for %d elections", n)
+}
+
+func TotalVotesCast() int64 {
+	var total int64
+	database.PostgresDB.Model(&models.ElectionTally{}).Select("COALESCE(SUM(total_votes), 0)").Scan(&total)
+	return total
+}
+
+// --- Public results cache ---
+
+type cachedResponse struct {
+	Stamp     string
+	Body      []byte
+	ETag      string
+	MaxAge    int
+	ExpiresAt time.Time
+}
+
+// resultsCacheMaxEntries bounds the cache; keys come from request parameters.
+const resultsCacheMaxEntries = 512
+
+var (
+	resultsCache    = make(map[string]cachedResponse)
+	resultsCacheMux sync.RWMutex
+)
+
+// ResultsStamp changes whenever any tally moves, a result is declared or an
+// election is edited or (un)published. It is read from the database, so
+// every replica agrees on it without being told about the change.
+func ResultsStamp() string {
+	var row struct {
+		Versions        int64
+		Count           int64
+		Published       int64
+		ElectionUpdated *time.Time
+		ResultUpdated   *time.Time
+	}
+	database.PostgresDB.Raw(`SELECT
+		(SELECT COALESCE(SUM(version), 0) FROM election_tallies) AS versions,
+		(SELECT COUNT(*) FROM election_tallies) AS count,
+		(SELECT COUNT(*) FROM elections WHERE is_published) AS published,
+		(SELECT MAX(updated_at) FROM elections) AS election_updated,
+		(SELECT MAX(updated_at) FROM election_results) AS result_updated`).
+		Scan(&row)
+	return strings.Join([]string{
+		strconv.FormatInt(row.Versions, 10),
+		strconv.FormatInt(row.Count, 10),
+		strconv.FormatInt(row.Published, 10),
+		stampTime(row.ElectionUpdated),
+		stampTime(row.ResultUpdated),
+	}, "-")
+}
+
+// ElectionResultsStamp looks an election up for the public results and
+// returns a stamp that moves with its tally, its declared result and any
+// edit to the election, publication included. found is false when there is
+// no such election.
+func ElectionResultsStamp(electionID uint) (stamp string, published, found bool) {
+	var row struct {
+		IsPublished     bool
+		ElectionUpdated *time.Time
+		Version         int64
+		ResultUpdated   *time.Time
+	}
+	res := database.PostgresDB.Model(&models.Election{}).
+		Select("elections.is_published, elections.updated_at AS election_updated, "+
+			"COALESCE(election_tallies.version, 0) AS version, election_results.updated_at AS result_updated").
+		Joins("LEFT JOIN election_tallies ON election_tallies.election_id = elections.id").
+		Joins("LEFT JOIN election_results ON election_results.election_id = elections.id").
+		Where("elections.id = ?", electionID).
+		Scan(&row)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return "", false, false
+	}
+	stamp = strings.Join([]string{
+		strconv.FormatInt(row.Version, 10),
+		stampTime(row.ElectionUpdated),
+		stampTime(row.ResultUpdated),
+	}, "-")
+	return stamp, row.IsPublished, true
+}
+
+func stampTime(t *time.Time) string {
+	if t == nil {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// resultsCacheTTL is the results_cache_ttl setting in seconds. It is read
+// when a response is stored, not on every hit.
+func resultsCacheTTL() int {
+	if v, err := strconv.Atoi(repository.GetSettingValue("results_cache_ttl")); err == nil && v >= 0 {
+		return v
+	}
+	return 5
+}
+
+// GetCachedResults returns a stored response that is still fresh for stamp,
+// with the max-age it may be cached for downstream.
+func GetCachedResults(key, stamp string) (body []byte, etag string, maxAge int, ok bool) {
+	resultsCacheMux.RLock()
+	entry, found := resultsCache[key]
+	resultsCacheMux.RUnlock()
+
+	if !found || entry.Stamp != stamp || time.Now().After(entry.ExpiresAt) {
+		return nil, "", 0, false
+	}
+	return entry.Body, entry.ETag, entry.MaxAge, true
+}
+
+// StoreCachedResults keeps a response for results_cache_ttl seconds and
+// returns its ETag and that TTL as the max-age to send. A full cache first
+// drops expired entries and then the one closest to expiry.
+func StoreCachedResults(key, stamp string, body []byte) (string, int) {
+	etag := utils.ETagFor(body)
+	ttl := resultsCacheTTL()
+
+	resultsCacheMux.Lock()
+	if _, ok := resultsCache[key]; !ok && len(resultsCache) >= resultsCacheMaxEntries {
+		evictCachedResults(time.Now())
+	}
+	resultsCache[key] = cachedResponse{
+		Stamp:     stamp,
+		Body:      body,
+		ETag:      etag,
+		MaxAge:    ttl,
+		ExpiresAt: time.Now().Add(time.Duration(ttl) * time.Second),
+	}
+	resultsCacheMux.Unlock()
+
+	return etag, ttl
+}
+
+// evictCachedResults makes room for one entry. The caller holds resultsCacheMux.
+func evictCachedResults(now time.Time) {
+	oldest := ""
+	for k, e := range resultsCache {
+		if now.After(e.ExpiresAt) {
+			delete(resultsCache, k)
+		} else if oldest == "" || e.ExpiresAt.Before(resultsCache[oldest].ExpiresAt) {
+			oldest = k
+		}
+	}
+	if len(resultsCache) >= resultsCacheMaxEntries && oldest != "" {
+		delete(resultsCache, oldest)
+	}
+}
+
+func InvalidateResultsCache() {
+	resultsCacheMux.Lock()
+	resultsCache = make(map[string]cachedResponse)
+	resultsCacheMux.Unlock()
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEvictCachedResults(t *testing.T) {
+	now := time.Now()
+	fill := func(expired int) {
+		resultsCache = make(map[string]cachedResponse)
+		for i := 0; i < resultsCacheMaxEntries; i++ {
+			expires := now.Add(time.Duration(i+1) * time.Second)
+			if i < expired {
+				expires = now.Add(-time.Second)
+			}
+			resultsCache[fmt.Sprintf("results:%d", i)] = cachedResponse{ExpiresAt: expires}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		expired int
+		want    int
+		gone    string
+	}{
+		{name: "expired entries are swept", expired: 3, want: resultsCacheMaxEntries - 3, gone: "results:0"},
+		{name: "nearest expiry goes when nothing has expired", expired: 0, want: resultsCacheMaxEntries - 1, gone: "results:0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fill(tt.expired)
+			evictCachedResults(now)
+			if len(resultsCache) != tt.want {
+				t.Errorf("entries = %d, want %d", len(resultsCache), tt.want)
+			}
+			if _, ok := resultsCache[tt.gone]; ok {
+				t.Errorf("%s survived eviction", tt.gone)
+			}
+		})
+	}
+	resultsCache = make(map[string]cachedResponse)
+}
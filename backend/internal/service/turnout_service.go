@@ -0,0 +1,230 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/models"
+	"E-voting/internal/repository"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TurnoutBucket struct {
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	Votes      int64     `json:"votes"`
+	Cumulative int64     `json:"cumulative"`
+}
+
+type TurnoutArea struct {
+	District       string  `json:"district"`
+	Block          string  `json:"block,omitempty"`
+	LocalBody      string  `json:"local_body,omitempty"`
+	Ward           string  `json:"ward,omitempty"`
+	Eligible       int64   `json:"eligible"`
+	Polled         int64   `json:"polled"`
+	TurnoutPercent float64 `json:"turnout_percent"`
+	Suppressed     bool    `json:"suppressed"`
+}
+
+type TurnoutReport struct {
+	ElectionID       uint                     `json:"election_id"`
+	ElectionTitle    string                   `json:"election_title"`
+	EligibleElectors int64                    `json:"eligible_electors"`
+	VotesPolled      int64                    `json:"votes_polled"`
+	TurnoutPercent   float64                  `json:"turnout_percent"`
+	MinCellSize      int64                    `json:"min_cell_size"`
+	Hourly           []TurnoutBucket          `json:"hourly"`
+	Breakdown        map[string][]TurnoutArea `json:"breakdown"`
+}
+
+// turnoutMinCell is the smallest count ever reported for a time bucket or
+// area, so that a single participation cannot be singled out.
+func turnoutMinCell() int64 {
+	if v, err := strconv.ParseInt(repository.GetSettingValue("turnout_min_cell_size"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return 10
+}
+
+func percent(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
+
+func BuildTurnoutReport(electionID uint) (*TurnoutReport, error) {
+	var election models.Election
+	if err := database.PostgresDB.First(&election, electionID).Error; err != nil {
+		return nil, errors.New("election not found")
+	}
+
+	minCell := turnoutMinCell()
+	report := &TurnoutReport{
+		ElectionID:    election.ID,
+		ElectionTitle: election.Title,
+		MinCellSize:   minCell,
+		Breakdown:     make(map[string][]TurnoutArea),
+	}
+
+	// 1. Electorate & Polled
+	EligibleVoters(database.PostgresDB.Model(&models.Voter{}), election).
+		Where("voters.is_verified = ? AND voters.is_blocked = ?", true, false).
+		Count(&report.EligibleElectors)
+
+	database.PostgresDB.Model(&models.ElectionParticipation{}).
+		Where("election_id = ?", election.ID).
+		Count(&report.VotesPolled)
+
+	report.TurnoutPercent = percent(report.VotesPolled, report.EligibleElectors)
+
+	// 2. Hourly series
+	var hours []struct {
+		Hour  time.Time
+		Votes int64
+	}
+	if err := database.PostgresDB.Model(&models.ElectionParticipation{}).
+		Select("date_trunc('hour', timestamp) as hour, COUNT(*) as votes").
+		Where("election_id = ?", election.ID).
+		Group("hour").
+		Order("hour asc").
+		Scan(&hours).Error; err != nil {
+		return nil, errors.New("failed to build hourly turnout")
+	}
+
+	// Hours below the minimum cell size are merged forward until the bucket is
+	// large enough; a small trailing remainder is folded into the last bucket.
+	var pending *TurnoutBucket
+	var cumulative int64
+	for _, h := range hours {
+		if pending == nil {
+			pending = &TurnoutBucket{From: h.Hour}
+		}
+		pending.To = h.Hour.Add(time.Hour)
+		pending.Votes += h.Votes
+
+		if pending.Votes >= minCell {
+			cumulative += pending.Votes
+			pending.Cumulative = cumulative
+			report.Hourly = append(report.Hourly, *pending)
+			pending = nil
+		}
+	}
+	if pending != nil && len(report.Hourly) > 0 {
+		last := &report.Hourly[len(report.Hourly)-1]
+		last.To = pending.To
+		last.Votes += pending.Votes
+		last.Cumulative += pending.Votes
+	}
+
+	// 3. Geographic breakdown
+	levels := []struct {
+		Name    string
+		Columns string
+	}{
+		{"district", "voters.district"},
+		{"block", "voters.district, voters.block"},
+		{"local_body", "voters.district, voters.block, voters.panchayath"},
+		{"ward", "voters.district, voters.block, voters.panchayath, voters.ward"},
+	}
+
+	for depth, level := range levels {
+		var eligible, polled []struct {
+			District   string
+			Block      string
+			Panchayath string
+			Ward       string
+			Total      int64
+		}
+
+		if err := EligibleVoters(database.PostgresDB.Model(&models.Voter{}), election).
+			Select(level.Columns+", COUNT(*) as total").
+			Where("voters.is_verified = ? AND voters.is_blocked = ?", true, false).
+			Group(level.Columns).
+			Scan(&eligible).Error; err != nil {
+			return nil, errors.New("failed to build turnout breakdown")
+		}
+
+		if err := database.PostgresDB.Table("election_participations").
+			Select(level.Columns+", COUNT(*) as total").
+			Joins("JOIN voters ON voters.id = election_participations.voter_id").
+			Where("election_participations.election_id = ?", election.ID).
+			Group(level.Columns).
+			Scan(&polled).Error; err != nil {
+			return nil, errors.New("failed to build turnout breakdown")
+		}
+
+		areas := make(map[string]*TurnoutArea)
+		var keys []string
+		area := func(d, b, p, w string) *TurnoutArea {
+			key := d + "|" + b + "|" + p + "|" + w
+			a, ok := areas[key]
+			if !ok {
+				a = &TurnoutArea{District: d, Block: b, LocalBody: p, Ward: w}
+				areas[key] = a
+				keys = append(keys, key)
+			}
+			return a
+		}
+
+		for _, row := range eligible {
+			area(row.District, row.Block, row.Panchayath, row.Ward).Eligible = row.Total
+		}
+		for _, row := range polled {
+			area(row.District, row.Block, row.Panchayath, row.Ward).Polled = row.Total
+		}
+
+		sort.Strings(keys)
+		rows := make([]TurnoutArea, 0, len(keys))
+		for _, key := range keys {
+			rows = append(rows, *areas[key])
+		}
+		suppressTurnoutCells(rows, minCell, depth)
+		report.Breakdown[level.Name] = rows
+	}
+
+	return report, nil
+}
+
+// suppressTurnoutCells blanks the areas polled by fewer than minCell electors.
+// Areas sharing the first depth levels of the breakdown add up to their
+// parent's count, so where only one of them would be blank the smallest of the
+// rest is blanked with it; otherwise the blank could be recovered by
+// subtracting its siblings from the parent.
+func suppressTurnoutCells(rows []TurnoutArea, minCell int64, depth int) {
+	groups := make(map[string][]int)
+	for i, a := range rows {
+		parent := strings.Join([]string{a.District, a.Block, a.LocalBody, a.Ward}[:depth], "|")
+		groups[parent] = append(groups[parent], i)
+	}
+
+	for _, members := range groups {
+		small, complement := 0, -1
+		for _, i := range members {
+			a := &rows[i]
+			switch {
+			case a.Polled <= 0:
+			case a.Polled < minCell:
+				a.Suppressed = true
+				small++
+			case complement < 0 || a.Polled < rows[complement].Polled:
+				complement = i
+			}
+		}
+		if small == 1 && complement >= 0 {
+			rows[complement].Suppressed = true
+		}
+	}
+
+	for i := range rows {
+		if rows[i].Suppressed {
+			rows[i].Polled = 0
+		} else {
+			rows[i].TurnoutPercent = percent(rows[i].Polled, rows[i].Eligible)
+		}
+	}
+}
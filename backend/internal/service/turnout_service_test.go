@@ -0,0 +1,59 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuppressTurnoutCells(t *testing.T) {
+	ward := func(body, ward string, polled int64) TurnoutArea {
+		return TurnoutArea{District: "Kollam", Block: "Chavara", LocalBody: body, Ward: ward, Eligible: 100, Polled: polled}
+	}
+
+	tests := []struct {
+		name      string
+		rows      []TurnoutArea
+		wantShown []int64
+	}{
+		{
+			name:      "large cells are shown",
+			rows:      []TurnoutArea{ward("Panmana", "1", 40), ward("Panmana", "2", 30)},
+			wantShown: []int64{40, 30},
+		},
+		{
+			name:      "a lone small cell takes its smallest sibling with it",
+			rows:      []TurnoutArea{ward("Panmana", "1", 40), ward("Panmana", "2", 3), ward("Panmana", "3", 25)},
+			wantShown: []int64{40, 0, 0},
+		},
+		{
+			name:      "two small cells already hide each other",
+			rows:      []TurnoutArea{ward("Panmana", "1", 40), ward("Panmana", "2", 3), ward("Panmana", "3", 4)},
+			wantShown: []int64{40, 0, 0},
+		},
+		{
+			name:      "siblings are counted per parent",
+			rows:      []TurnoutArea{ward("Panmana", "1", 40), ward("Panmana", "2", 3), ward("Thevalakkara", "1", 25), ward("Thevalakkara", "2", 30)},
+			wantShown: []int64{0, 0, 25, 30},
+		},
+		{
+			name:      "empty wards are neither small nor a complement",
+			rows:      []TurnoutArea{ward("Panmana", "1", 0), ward("Panmana", "2", 3), ward("Panmana", "3", 25)},
+			wantShown: []int64{0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suppressTurnoutCells(tt.rows, 10, 3)
+			var shown []int64
+			for _, a := range tt.rows {
+				if a.Suppressed && a.TurnoutPercent != 0 {
+					t.Errorf("ward %s/%s is suppressed but reports %.2f%%", a.LocalBody, a.Ward, a.TurnoutPercent)
+				}
+				shown = append(shown, a.Polled)
+			}
+			if !reflect.DeepEqual(shown, tt.wantShown) {
+				t.Errorf("polled = %v, want %v", shown, tt.wantShown)
+			}
+		})
+	}
+}
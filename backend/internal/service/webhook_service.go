@@ -0,0 +1,365 @@
+package service
+
+import (
+	"E-voting/internal/database"
+	"E-voting/internal/events"
+	"E-voting/internal/models"
+	"E-voting/internal/repository"
+	"E-voting/internal/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	WebhookElectionOpened     = "election.opened"
+	WebhookElectionClosed     = "election.closed"
+	WebhookResultsPublished   = "results.published"
+	WebhookResultsUnpublished = "results.unpublished"
+	WebhookTurnoutMilestone   = "turnout.milestone"
+	WebhookPing               = "webhook.ping"
+
+	DeliveryPending   = "PENDING"
+	DeliveryDelivered = "DELIVERED"
+	DeliveryFailed    = "FAILED"
+
+	webhookBaseDelay = 30 * time.Second
+	webhookMaxDelay  = 6 * time.Hour
+	webhookClaimTTL  = 2 * time.Minute
+)
+
+var WebhookEvents = []string{
+	WebhookElectionOpened,
+	WebhookElectionClosed,
+	WebhookResultsPublished,
+	WebhookResultsUnpublished,
+	WebhookTurnoutMilestone,
+}
+
+var turnoutMilestones = []int{10, 25, 50, 75, 90}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+type webhookEnvelope struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// NormalizeWebhookEvents validates an event filter and returns it in stored form.
+func NormalizeWebhookEvents(names []string) (string, error) {
+	if len(names) == 0 {
+		return "*", nil
+	}
+	var out []string
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "*" {
+			return "*", nil
+		}
+		known := false
+		for _, e := range WebhookEvents {
+			if e == n {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return "", fmt.Errorf("unknown webhook event %q", n)
+		}
+		out = append(out, n)
+	}
+	return strings.Join(out, ","), nil
+}
+
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("webhook URL must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+func webhookMaxAttempts() int {
+	if v, err := strconv.Atoi(repository.GetSettingValue("webhook_max_attempts")); err == nil && v > 0 {
+		return v
+	}
+	return 6
+}
+
+// webhookBackoff doubles the delay after every failed attempt.
+func webhookBackoff(attempts int) time.Duration {
+	d := webhookBaseDelay
+	for i := 1; i < attempts && d < webhookMaxDelay; i++ {
+		d *= 2
+	}
+	if d > webhookMaxDelay {
+		d = webhookMaxDelay
+	}
+	return d
+}
+
+func subscribedTo(sub models.WebhookSubscription, event string) bool {
+	if sub.Events == "*" || sub.Events == "" {
+		return true
+	}
+	for _, e := range strings.Split(sub.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+func newDelivery(subID uint, event string, data interface{}) (*models.WebhookDelivery, error) {
+	id, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(webhookEnvelope{ID: id, Event: event, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	d := &models.WebhookDelivery{
+		SubscriptionID: subID,
+		EventID:        id,
+		Event:          event,
+		Payload:        string(body),
+		Status:         DeliveryPending,
+		NextAttemptAt:  &now,
+	}
+	return d, database.PostgresDB.Create(d).Error
+}
+
+// EnqueueWebhookEvent records a delivery for every active subscription that
+// wants the event and makes the first attempt straight away.
+func EnqueueWebhookEvent(event string, data interface{}) {
+	var subs []models.WebhookSubscription
+	if err := database.PostgresDB.Where("is_active = ?", true).Find(&subs).Error; err != nil {
+		log.Printf("Webhook: failed to load subscriptions: %v", err)
+		return
+	}
+
+	for _, sub := range subs {
+		if !subscribedTo(sub, event) {
+			continue
+		}
+		d, err := newDelivery(sub.ID, event, data)
+		if err != nil {
+			log.Printf("Webhook: failed to queue %s for subscription %d: %v", event, sub.ID, err)
+			continue
+		}
+		go attemptDelivery(d.ID)
+	}
+}
+
+// SendWebhookPing delivers a test event synchronously so the caller sees the outcome.
+func SendWebhookPing(sub models.WebhookSubscription) (*models.WebhookDelivery, error) {
+	d, err := newDelivery(sub.ID, WebhookPing, map[string]interface{}{
+		"subscription_id": sub.ID,
+		"name":            sub.Name,
+	})
+	if err != nil {
+		return nil, errors.New("failed to queue ping")
+	}
+	return attemptDelivery(d.ID), nil
+}
+
+// RedeliverWebhook sends the original payload again as a new delivery, keeping
+// the event ID so receivers can de-duplicate.
+func RedeliverWebhook(deliveryID uint) (*models.WebhookDelivery, error) {
+	var orig models.WebhookDelivery
+	if err := database.PostgresDB.First(&orig, deliveryID).Error; err != nil {
+		return nil, errors.New("delivery not found")
+	}
+	now := time.Now()
+	d := models.WebhookDelivery{
+		SubscriptionID: orig.SubscriptionID,
+		EventID:        orig.EventID,
+		Event:          orig.Event,
+		Payload:        orig.Payload,
+		Status:         DeliveryPending,
+		NextAttemptAt:  &now,
+		RedeliveryOf:   &orig.ID,
+	}
+	if err := database.PostgresDB.Create(&d).Error; err != nil {
+		return nil, errors.New("failed to queue redelivery")
+	}
+	return attemptDelivery(d.ID), nil
+}
+
+// RetryWebhookDeliveries picks up pending deliveries whose backoff has elapsed.
+func RetryWebhookDeliveries() (int, []string) {
+	var due []models.WebhookDelivery
+	if err := database.PostgresDB.
+		Where("status = ? AND next_attempt_at <= ?", DeliveryPending, time.Now()).
+		Order("next_attempt_at asc").Limit(100).
+		Find(&due).Error; err != nil {
+		return 0, []string{fmt.Sprintf("DB Error: %v", err)}
+	}
+
+	delivered := 0
+	logs := []string{}
+	for _, d := range due {
+		res := attemptDelivery(d.ID)
+		if res == nil {
+			continue
+		}
+		if res.Status == DeliveryDelivered {
+			delivered++
+		}
+		logs = append(logs, fmt.Sprintf("Delivery %d (%s): %s after %d attempt(s)", res.ID, res.Event, res.Status, res.Attempts))
+	}
+	return delivered, logs
+}
+
+// attemptDelivery claims a pending delivery, posts it and records the outcome.
+// The claim and the read are one UPDATE ... RETURNING, and the outcome is only
+// written while the claim is still held. It returns nil when another worker
+// holds the claim or the delivery has already gone out.
+func attemptDelivery(id uint) *models.WebhookDelivery {
+	now := time.Now()
+	var d models.WebhookDelivery
+	res := database.PostgresDB.Model(&d).Clauses(clause.Returning{}).
+		Where("id = ? AND status = ? AND delivered_at IS NULL AND next_attempt_at <= ?", id, DeliveryPending, now).
+		Update("next_attempt_at", now.Add(webhookClaimTTL))
+	if res.Error != nil || res.RowsAffected == 0 || d.NextAttemptAt == nil {
+		return nil
+	}
+	claim := *d.NextAttemptAt
+
+	var sub models.WebhookSubscription
+	if err := database.PostgresDB.First(&sub, d.SubscriptionID).Error; err != nil || !sub.IsActive {
+		d.Status = DeliveryFailed
+		d.LastError = "subscription removed or disabled"
+		d.NextAttemptAt = nil
+		saveDeliveryOutcome(&d, claim)
+		return &d
+	}
+
+	d.Attempts++
+	code, err := postWebhook(sub, d)
+	d.LastStatusCode = code
+	if err == nil {
+		delivered := time.Now()
+		d.Status = DeliveryDelivered
+		d.DeliveredAt = &delivered
+		d.LastError = ""
+		d.NextAttemptAt = nil
+	} else {
+		d.LastError = err.Error()
+		if d.Attempts >= webhookMaxAttempts() {
+			d.Status = DeliveryFailed
+			d.NextAttemptAt = nil
+		} else {
+			next := time.Now().Add(webhookBackoff(d.Attempts))
+			d.NextAttemptAt = &next
+		}
+	}
+
+	saveDeliveryOutcome(&d, claim)
+	return &d
+}
+
+// saveDeliveryOutcome writes the result of an attempt unless the claim has
+// lapsed and another worker has taken the delivery over.
+func saveDeliveryOutcome(d *models.WebhookDelivery, claim time.Time) {
+	res := database.PostgresDB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", d.ID, DeliveryPending, claim).
+		Updates(map[string]interface{}{
+			"status":           d.Status,
+			"attempts":         d.Attempts,
+			"last_status_code": d.LastStatusCode,
+			"last_error":       d.LastError,
+			"delivered_at":     d.DeliveredAt,
+			"next_attempt_at":  d.NextAttemptAt,
+		})
+	if res.Error != nil {
+		log.Printf("Webhook: failed to record delivery %d: %v", d.ID, res.Error)
+	} else if res.RowsAffected == 0 {
+		log.Printf("Webhook: claim on delivery %d lapsed before its outcome was recorded", d.ID)
+	}
+}
+
+func postWebhook(sub models.WebhookSubscription, d models.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "E-Voting-Webhooks/1.0")
+	req.Header.Set("X-EVoting-Event", d.Event)
+	req.Header.Set("X-EVoting-Delivery", d.EventID)
+	req.Header.Set("X-EVoting-Timestamp", ts)
+	req.Header.Set("X-EVoting-Signature", utils.SignWebhook(sub.Secret, ts, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// CheckTurnoutMilestones publishes TurnoutMilestone the first time an ongoing
+// election's turnout crosses each threshold.
+func CheckTurnoutMilestones() {
+	var elections []models.Election
+	if err := database.PostgresDB.Where("is_active = ? AND status = ?", true, "ONGOING").Find(&elections).Error; err != nil {
+		return
+	}
+
+	for _, e := range elections {
+		var tally models.ElectionTally
+		if err := database.PostgresDB.Where("election_id = ?", e.ID).First(&tally).Error; err != nil || tally.TotalVotes == 0 {
+			continue
+		}
+
+		var eligible int64
+		EligibleVoters(database.PostgresDB.Model(&models.Voter{}), e).
+			Where("voters.is_verified = ? AND voters.is_blocked = ?", true, false).
+			Count(&eligible)
+		if eligible == 0 {
+			continue
+		}
+
+		reached := float64(tally.TotalVotes) / float64(eligible) * 100
+		for _, m := range turnoutMilestones {
+			if reached < float64(m) {
+				break
+			}
+			res := database.PostgresDB.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.TurnoutMilestone{ElectionID: e.ID, Percent: m, ReachedAt: time.Now()})
+			if res.Error != nil || res.RowsAffected == 0 {
+				continue
+			}
+			events.Publish(events.TurnoutMilestone{
+				ElectionID: e.ID,
+				Title:      e.Title,
+				Percent:    m,
+				VotesCast:  tally.TotalVotes,
+				Eligible:   eligible,
+			})
+		}
+	}
+}
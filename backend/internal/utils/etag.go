@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ETagFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// SendWithETag answers 304 when the client already holds this representation.
+func SendWithETag(c *fiber.Ctx, body []byte, etag string, contentType string, maxAge int) error {
+	c.Set("ETag", etag)
+	c.Set("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+
+	for _, candidate := range strings.Split(c.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == etag || candidate == "*" || candidate == "W/"+etag {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	c.Set("Content-Type", contentType)
+	return c.Status(200).Send(body)
+}
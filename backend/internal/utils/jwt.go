@@ -2,6 +2,7 @@ package utils
 
 import (
 	"E-voting/internal/config"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -22,3 +23,21 @@ func GenerateJWT(userID uint, role string, permissions string, isSuper bool, nam
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(config.Config.JWTSecret))
 }
+
+func ParseJWT(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(config.Config.JWTSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
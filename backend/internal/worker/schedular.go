@@ -20,3 +20,17 @@ func StartScheduler() {
 		}
 	}()
 }
+
+// StartWebhookDispatcher announces turnout milestones and retries webhook
+// deliveries whose backoff has elapsed.
+func StartWebhookDispatcher() {
+	ticker := time.NewTicker(15 * time.Second)
+	go func() {
+		for range ticker.C {
+			service.CheckTurnoutMilestones()
+			if n, logs := service.RetryWebhookDeliveries(); len(logs) > 0 {
+				log.Printf(" [Worker] Webhook retries: %d delivered of %d", n, len(logs))
+			}
+		}
+	}()
+}